@@ -0,0 +1,184 @@
+// Command pricer encrypts and decrypts prices from the command line.
+//
+// Usage:
+//
+//	pricer [flags] encrypt [price ...]
+//	pricer [flags] decrypt [encrypted price ...]
+//
+// Prices are read from arguments, or from stdin (one per line) when no
+// argument is given. Every flag can also be set from its environment
+// variable, e.g. PRICER_ENCRYPTION_KEY, so that keys don't end up in
+// the shell history.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/benjaminch/pricers/doubleclick"
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Getenv, os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command with args, returning its exit code.
+func run(args []string, getenv func(string) string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("pricer", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	envOr := func(name string, fallback string) string {
+		if value := getenv(name); value != "" {
+			return value
+		}
+		return fallback
+	}
+
+	encryptionKey := flags.String("encryption-key", envOr("PRICER_ENCRYPTION_KEY", ""), "encryption key (env PRICER_ENCRYPTION_KEY)")
+	integrityKey := flags.String("integrity-key", envOr("PRICER_INTEGRITY_KEY", ""), "integrity key (env PRICER_INTEGRITY_KEY)")
+	isBase64Keys := flags.String("base64-keys", envOr("PRICER_BASE64_KEYS", "false"), "whether keys are base64 websafe encoded (env PRICER_BASE64_KEYS)")
+	keyDecodingMode := flags.String("key-decoding-mode", envOr("PRICER_KEY_DECODING_MODE", helpers.Hexa.String()), "keys decoding mode, hexa, utf-8, web-safe-base64, std-base64, pem or auto (env PRICER_KEY_DECODING_MODE)")
+	keyLength := flags.String("key-length", envOr("PRICER_KEY_LENGTH", strconv.Itoa(doubleclick.DefaultKeyLength)), "expected decoded keys length in bytes, 0 for any (env PRICER_KEY_LENGTH)")
+	scaleFactor := flags.String("scale-factor", envOr("PRICER_SCALE_FACTOR", strconv.FormatFloat(doubleclick.DefaultScaleFactor, 'f', -1, 64)), "price scale factor (env PRICER_SCALE_FACTOR)")
+	priceEncoding := flags.String("price-encoding", envOr("PRICER_PRICE_ENCODING", helpers.Base64.String()), "encrypted prices encoding, base64 or hex (env PRICER_PRICE_ENCODING)")
+	base64Variant := flags.String("base64-variant", envOr("PRICER_BASE64_VARIANT", helpers.URLSafe.String()), "encrypted prices base64 alphabet, url-safe or standard (env PRICER_BASE64_VARIANT)")
+	seed := flags.String("seed", envOr("PRICER_SEED", ""), "seed used to encrypt prices, a unique one per price when empty (env PRICER_SEED)")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: pricer [flags] encrypt|decrypt [price ...]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return 2
+	}
+
+	pricer, err := buildPricer(*encryptionKey, *integrityKey, *isBase64Keys, *keyDecodingMode, *keyLength, *scaleFactor, *priceEncoding, *base64Variant)
+	if err != nil {
+		fmt.Fprintln(stderr, "Error creating pricer :", err)
+		return 1
+	}
+
+	var process func(input string) (string, error)
+	switch command := flags.Arg(0); command {
+	case "encrypt":
+		process = func(input string) (string, error) {
+			price, err := strconv.ParseFloat(input, 64)
+			if err != nil {
+				return "", err
+			}
+			priceSeed := *seed
+			if priceSeed == "" {
+				priceSeed = helpers.NewSeed()
+			}
+			return pricer.Encrypt(priceSeed, price)
+		}
+	case "decrypt":
+		process = func(input string) (string, error) {
+			price, err := pricer.Decrypt(input)
+			if err != nil {
+				return "", err
+			}
+			return strconv.FormatFloat(price, 'f', -1, 64), nil
+		}
+	default:
+		fmt.Fprintf(stderr, "Unknown command : %s\n", command)
+		flags.Usage()
+		return 2
+	}
+
+	inputs := flags.Args()[1:]
+	if len(inputs) == 0 {
+		inputs, err = readLines(stdin)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error reading stdin :", err)
+			return 1
+		}
+	}
+
+	exitCode := 0
+	for _, input := range inputs {
+		output, err := process(input)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error processing %s : %s\n", input, err)
+			exitCode = 1
+			continue
+		}
+		fmt.Fprintln(stdout, output)
+	}
+
+	return exitCode
+}
+
+// buildPricer returns a pricer parsing its configuration from strings.
+func buildPricer(
+	encryptionKey string,
+	integrityKey string,
+	isBase64Keys string,
+	keyDecodingMode string,
+	keyLength string,
+	scaleFactor string,
+	priceEncoding string,
+	base64Variant string) (*doubleclick.DoubleClickPricer, error) {
+	if encryptionKey == "" || integrityKey == "" {
+		return nil, errors.New("encryption and integrity keys are required")
+	}
+	parsedIsBase64Keys, err := strconv.ParseBool(isBase64Keys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64-keys: %w", err)
+	}
+	parsedKeyDecodingMode, err := helpers.ParseKeyDecodingMode(keyDecodingMode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key-decoding-mode: %w", err)
+	}
+	parsedKeyLength, err := strconv.Atoi(keyLength)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key-length: %w", err)
+	}
+	parsedScaleFactor, err := strconv.ParseFloat(scaleFactor, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scale-factor: %w", err)
+	}
+	parsedPriceEncoding, err := helpers.ParsePriceEncoding(priceEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("invalid price-encoding: %w", err)
+	}
+	parsedBase64Variant, err := helpers.ParseBase64Variant(base64Variant)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64-variant: %w", err)
+	}
+
+	return doubleclick.NewPricer(
+		doubleclick.WithKeys(encryptionKey, integrityKey),
+		doubleclick.WithBase64Keys(parsedIsBase64Keys),
+		doubleclick.WithKeyDecodingMode(parsedKeyDecodingMode),
+		doubleclick.WithKeyLength(parsedKeyLength),
+		doubleclick.WithScaleFactor(parsedScaleFactor),
+		doubleclick.WithPriceEncoding(parsedPriceEncoding),
+		doubleclick.WithBase64Variant(parsedBase64Variant),
+	)
+}
+
+// readLines returns every non blank line read from r, trimmed.
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, scanner.Err()
+}
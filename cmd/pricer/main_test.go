@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	// Setup:
+	keysFlags := []string{
+		"-encryption-key", "652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"-integrity-key", "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+	}
+	env := map[string]string{
+		"PRICER_ENCRYPTION_KEY": "ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU",
+		"PRICER_INTEGRITY_KEY":  "vQo9-4KtlcXmPhWaYvc8asqYuiSVMiGUdZ1RLXfrK7U",
+		"PRICER_BASE64_KEYS":    "true",
+	}
+
+	var runTestCase = []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		stdin    string
+		exitCode int
+		stdout   string
+		stderr   string
+	}{
+		{
+			name:     "encrypt from args",
+			args:     append(keysFlags, "-seed", "test", "encrypt", "1.354"),
+			exitCode: 0,
+			stdout:   "CY9rzUYh03PK3k6DJie09sczu2K8g808L63rHg\n",
+		},
+		{
+			name:     "decrypt from args",
+			args:     append(keysFlags, "decrypt", "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", "1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA"),
+			exitCode: 0,
+			stdout:   "1.354\n1.354\n",
+		},
+		{
+			name:     "decrypt from stdin with keys from env",
+			args:     []string{"-key-decoding-mode", "utf-8", "decrypt"},
+			env:      env,
+			stdin:    "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg\n\nce131TRp7waIZI2qOiRr2DMm2sSIeGh_wIAwVQ\n",
+			exitCode: 0,
+			stdout:   "1.354\n3.24\n",
+		},
+		{
+			name:     "decrypt failure",
+			args:     append(keysFlags, "decrypt", "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpA"),
+			exitCode: 1,
+			stderr:   "Error processing anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpA : Failed to decrypt\n",
+		},
+		{
+			name:     "encrypt invalid price",
+			args:     append(keysFlags, "encrypt", "abc"),
+			exitCode: 1,
+			stderr:   "Error processing abc : strconv.ParseFloat: parsing \"abc\": invalid syntax\n",
+		},
+		{
+			name:     "missing keys",
+			args:     []string{"decrypt", "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg"},
+			exitCode: 1,
+			stderr:   "Error creating pricer : encryption and integrity keys are required\n",
+		},
+		{
+			name:     "unknown command",
+			args:     append(keysFlags, "unknown"),
+			exitCode: 2,
+			stderr:   "Unknown command : unknown\n",
+		},
+	}
+
+	for _, testCase := range runTestCase {
+		t.Run(testCase.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			getenv := func(name string) string {
+				return testCase.env[name]
+			}
+
+			// Execute:
+			exitCode := run(testCase.args, getenv, strings.NewReader(testCase.stdin), &stdout, &stderr)
+
+			// Verify:
+			assert.Equal(t, testCase.exitCode, exitCode)
+			assert.Equal(t, testCase.stdout, stdout.String())
+			assert.True(t, strings.HasPrefix(stderr.String(), testCase.stderr), "Stderr should start with %q but was %q", testCase.stderr, stderr.String())
+		})
+	}
+}
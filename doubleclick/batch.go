@@ -0,0 +1,137 @@
+package doubleclick
+
+import (
+	"sync"
+	"time"
+)
+
+// EncryptRequest is a clear price to encrypt with EncryptBatch, along with its seed.
+type EncryptRequest struct {
+	Seed  string
+	Price float64
+}
+
+// EncryptResult is the outcome of encrypting an EncryptRequest with EncryptBatch:
+// either the encrypted price, or the error encrypting it.
+type EncryptResult struct {
+	Encrypted string
+	Err       error
+}
+
+// EncryptBatch encrypts several clear prices at once, e.g. one per impression,
+// reusing HMACs and buffers across prices. Results are keyed by the ID items
+// are keyed by. A failing price doesn't abort the batch, its error is reported
+// in its result while other results hold their encrypted price.
+func (dc *DoubleClickPricer) EncryptBatch(items map[string]EncryptRequest) map[string]EncryptResult {
+	results := make(map[string]EncryptResult, len(items))
+
+	state, err := dc.acquireState()
+	if err != nil {
+		for id := range items {
+			results[id] = EncryptResult{Err: err}
+		}
+		return results
+	}
+	defer dc.releaseState(state)
+	for id, item := range items {
+		var start time.Time
+		if dc.observer != nil {
+			start = time.Now()
+		}
+		encrypted, err := dc.encryptItemWith(state, item)
+		if dc.observer != nil {
+			dc.observer.ObserveEncrypt(time.Since(start), err)
+		}
+		results[id] = EncryptResult{Encrypted: encrypted, Err: err}
+	}
+
+	return results
+}
+
+// encryptItemWith encrypts item using state.
+func (dc *DoubleClickPricer) encryptItemWith(state *cryptoState, item EncryptRequest) (string, error) {
+	if err := dc.validateSeed(item.Seed); err != nil {
+		return "", err
+	}
+	data, err := dc.scalePrice(item.Price)
+	if err != nil {
+		return "", err
+	}
+	if dc.isDebugMode == true {
+		dc.logger.Debugf("Micro price bytes : %v", data)
+	}
+
+	return dc.encryptWith(state, dc.seedIV(item.Seed), data)
+}
+
+// DecryptBatch decrypts several encrypted prices at once, reusing HMACs
+// and buffers across prices.
+// Index i of returned prices and errors corresponds to index i of
+// encryptedPrices. A failing price doesn't abort the batch, its error is
+// reported at its index while other errors are nil.
+func (dc *DoubleClickPricer) DecryptBatch(encryptedPrices []string) ([]float64, []error) {
+	prices := make([]float64, len(encryptedPrices))
+	errs := make([]error, len(encryptedPrices))
+
+	dc.decryptBatchTo(prices, errs, encryptedPrices)
+
+	return prices, errs
+}
+
+// DecryptParallel decrypts several encrypted prices as DecryptBatch does, sharding
+// them across workers goroutines, each of them with its own HMACs and buffers,
+// e.g. for offline jobs decrypting millions of prices.
+// Order is preserved: index i of returned prices and errors corresponds to index i
+// of encryptedPrices. With workers <= 1, prices are decrypted sequentially.
+func (dc *DoubleClickPricer) DecryptParallel(encryptedPrices []string, workers int) ([]float64, []error) {
+	if workers > len(encryptedPrices) {
+		workers = len(encryptedPrices)
+	}
+	if workers <= 1 {
+		return dc.DecryptBatch(encryptedPrices)
+	}
+
+	prices := make([]float64, len(encryptedPrices))
+	errs := make([]error, len(encryptedPrices))
+
+	// Each worker decrypts a contiguous shard, writing its own indexes only.
+	var wg sync.WaitGroup
+	shardSize := (len(encryptedPrices) + workers - 1) / workers
+	for start := 0; start < len(encryptedPrices); start += shardSize {
+		end := start + shardSize
+		if end > len(encryptedPrices) {
+			end = len(encryptedPrices)
+		}
+
+		wg.Add(1)
+		go func(start int, end int) {
+			defer wg.Done()
+			dc.decryptBatchTo(prices[start:end], errs[start:end], encryptedPrices[start:end])
+		}(start, end)
+	}
+	wg.Wait()
+
+	return prices, errs
+}
+
+// decryptBatchTo decrypts encryptedPrices with a single state, setting
+// prices and errors at their index in prices and errs.
+func (dc *DoubleClickPricer) decryptBatchTo(prices []float64, errs []error, encryptedPrices []string) {
+	state, err := dc.acquireState()
+	if err != nil {
+		for i := range encryptedPrices {
+			errs[i] = err
+		}
+		return
+	}
+	defer dc.releaseState(state)
+	for i, encryptedPrice := range encryptedPrices {
+		dc.startDecrypt(state)
+		priceMicro, err := dc.decryptWith(state, encryptedPrice)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		prices[i] = dc.toPrice(priceMicro)
+	}
+}
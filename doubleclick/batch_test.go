@@ -0,0 +1,241 @@
+package doubleclick
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func TestDecryptBatch(t *testing.T) {
+	// Setup:
+	var pricer *DoubleClickPricer
+	var err error
+	pricer, err = buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Valid prices mixed with corrupt ones
+	var pricesTestCase = []struct {
+		encrypted string
+		clear     float64
+		err       error
+	}{
+		{"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", 1.354, nil},
+		{"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lX!!", 0, ErrMalformedBase64},
+		{"ce131TRp7waIZI2qOiRr2DMm2sSIeGh_wIAwVQ", 3.24, nil},
+		{"ce131TRp7waIZI2q", 0, ErrInvalidCiphertextLength},
+		{tamperSignature("K6tfPnPvN_5E2xS3GssrFYeouJJRkBQqxR_FxQ", 2), 0, ErrSignatureMismatch},
+		{"L91lB6giyIXh2o4CeUf0F7sCXozKWRXAUeMUfg", 100, nil},
+	}
+
+	var encryptedPrices []string
+	for _, price := range pricesTestCase {
+		encryptedPrices = append(encryptedPrices, price.encrypted)
+	}
+
+	// Execute:
+	prices, errs := pricer.DecryptBatch(encryptedPrices)
+
+	// Verify:
+	assert.Len(t, prices, len(pricesTestCase))
+	assert.Len(t, errs, len(pricesTestCase))
+	for i, price := range pricesTestCase {
+		if price.err == nil {
+			assert.Nil(t, errs[i], "Decryption failed. Error : %s", errs[i])
+			assert.InDelta(t, prices[i], price.clear, 0.001, "Decryption failed. Should be : %f but was : %f", price.clear, prices[i])
+		} else {
+			assert.True(t, errors.Is(errs[i], price.err), "Error should be %v but was : %v", price.err, errs[i])
+		}
+	}
+}
+
+func TestDecryptBatchEmpty(t *testing.T) {
+	// Setup:
+	pricer, err := buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Execute:
+	prices, errs := pricer.DecryptBatch(nil)
+
+	// Verify:
+	assert.Empty(t, prices)
+	assert.Empty(t, errs)
+}
+
+var benchmarkEncryptedPrices = []string{
+	"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg",
+	"ce131TRp7waIZI2qOiRr2DMm2sSIeGh_wIAwVQ",
+	"K6tfPnPvN_5E2xS3GssrFYeouJJRkBQqxR_FxQ",
+	"lEzCWnwgB21Dy2_H43PKZeZaNDstZZElZRFTDQ",
+	"L91lB6giyIXh2o4CeUf0F7sCXozKWRXAUeMUfg",
+	"8WY0BgWbds1eEVNFkrXVIr1GU08iueKrP0wXfw",
+}
+
+func BenchmarkDecryptBatch(b *testing.B) {
+	pricer, err := buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+	if err != nil {
+		b.Fatal("Error creating new Pricer : ", err)
+	}
+
+	b.Run("Batch", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			pricer.DecryptBatch(benchmarkEncryptedPrices)
+		}
+	})
+
+	b.Run("Loop", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			for _, encryptedPrice := range benchmarkEncryptedPrices {
+				pricer.Decrypt(encryptedPrice)
+			}
+		}
+	})
+}
+
+func TestDecryptParallel(t *testing.T) {
+	// Setup:
+	pricer, err := buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Every 7th price is corrupt, the others decrypt to their index
+	var encryptedPrices []string
+	for i := 0; i < 1000; i++ {
+		encrypted, err := pricer.EncryptMicros(fmt.Sprintf("seed-%d", i), uint64(i))
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		if i%7 == 0 {
+			encrypted = tamperSignature(encrypted, i%4)
+		}
+		encryptedPrices = append(encryptedPrices, encrypted)
+	}
+
+	for _, workers := range []int{-1, 0, 1, 2, 3, 8, 1000, 2000} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			// Execute:
+			prices, errs := pricer.DecryptParallel(encryptedPrices, workers)
+
+			// Verify:
+			assert.Len(t, prices, len(encryptedPrices))
+			assert.Len(t, errs, len(encryptedPrices))
+			for i := range encryptedPrices {
+				if i%7 == 0 {
+					assert.Equal(t, ErrSignatureMismatch, errs[i], "Price %d should fail", i)
+					assert.Equal(t, float64(0), prices[i])
+				} else {
+					assert.Nil(t, errs[i], "Decryption failed. Error : %s", errs[i])
+					assert.Equal(t, float64(i)/1000000, prices[i], "Price %d out of order", i)
+				}
+			}
+		})
+	}
+
+	// Execute:
+	prices, errs := pricer.DecryptParallel(nil, 4)
+
+	// Verify:
+	assert.Empty(t, prices)
+	assert.Empty(t, errs)
+}
+
+func BenchmarkDecryptParallel(b *testing.B) {
+	pricer, err := buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+	if err != nil {
+		b.Fatal("Error creating new Pricer : ", err)
+	}
+	var encryptedPrices []string
+	for i := 0; i < 10000; i++ {
+		encryptedPrices = append(encryptedPrices, benchmarkEncryptedPrices[i%len(benchmarkEncryptedPrices)])
+	}
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("%d workers", workers), func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				pricer.DecryptParallel(encryptedPrices, workers)
+			}
+		})
+	}
+}
+
+func TestEncryptBatch(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithMinSeedLength(1))
+	items := map[string]EncryptRequest{
+		"imp-1":    {Seed: "seed-1", Price: 1.354},
+		"imp-2":    {Seed: "seed-2", Price: 3.24},
+		"overflow": {Seed: "seed-3", Price: 1e30},
+		"no-seed":  {Seed: "", Price: 1},
+		"imp-3":    {Seed: "seed-4", Price: 100},
+	}
+
+	// Execute:
+	results := pricer.EncryptBatch(items)
+
+	// Verify:
+	assert.Len(t, results, len(items))
+	assert.True(t, errors.Is(results["overflow"].Err, ErrPriceOverflow), "Unexpected error : %s", results["overflow"].Err)
+	assert.Equal(t, "", results["overflow"].Encrypted)
+	assert.True(t, errors.Is(results["no-seed"].Err, ErrInvalidSeed), "Unexpected error : %s", results["no-seed"].Err)
+	for _, id := range []string{"imp-1", "imp-2", "imp-3"} {
+		assert.Nil(t, results[id].Err, "Encryption failed. Error : %s", results[id].Err)
+		expected, err := pricer.Encrypt(items[id].Seed, items[id].Price)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		assert.Equal(t, expected, results[id].Encrypted)
+		price, err := pricer.Decrypt(results[id].Encrypted)
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+		assert.InDelta(t, items[id].Price, price, 0.000001)
+	}
+}
+
+func TestEncryptBatchEmpty(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+
+	// Execute:
+	results := pricer.EncryptBatch(nil)
+
+	// Verify:
+	assert.NotNil(t, results)
+	assert.Empty(t, results)
+}
@@ -0,0 +1,191 @@
+package doubleclick
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+// Benchmarks below report allocations per operation.
+//
+// Before reusing HMAC sum and encoding buffers (go1.27, linux amd64):
+//   BenchmarkEncrypt    3522 ns/op    1528 B/op    21 allocs/op
+//   BenchmarkDecrypt    3302 ns/op    1512 B/op    21 allocs/op
+// After reusing HMAC sum and encoding buffers:
+//   BenchmarkEncrypt          3061 ns/op    1616 B/op    17 allocs/op
+//   BenchmarkAppendEncrypt    2430 ns/op    1520 B/op    15 allocs/op
+//   BenchmarkDecrypt          2512 ns/op    1600 B/op    17 allocs/op
+// After pooling HMACs per pricer:
+//   BenchmarkEncrypt           749 ns/op      48 B/op     1 allocs/op
+//   BenchmarkAppendEncrypt     801 ns/op       0 B/op     0 allocs/op
+//   BenchmarkDecrypt           768 ns/op      48 B/op     1 allocs/op
+// Encrypt remaining allocation is the returned string, Decrypt one
+// comes from base 64 padding.
+// After padding encrypted prices on the stack:
+//   BenchmarkDecrypt           786 ns/op       0 B/op     0 allocs/op
+//   BenchmarkDecryptInto       954 ns/op       0 B/op     0 allocs/op
+// SeededEncryptor computing the pad of a fixed seed once, against Encrypt on the same machine:
+//   BenchmarkEncrypt          1134 ns/op      48 B/op     1 allocs/op
+//   BenchmarkSeededEncryptor   560 ns/op      48 B/op     1 allocs/op
+
+func buildBenchmarkPricer(b *testing.B) *DoubleClickPricer {
+	return buildTestPricer(b)
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	pricer := buildBenchmarkPricer(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		pricer.Encrypt("", 1.354)
+	}
+}
+
+func BenchmarkAppendEncrypt(b *testing.B) {
+	pricer := buildBenchmarkPricer(b)
+	buf := make([]byte, 0, 64)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		buf, _ = pricer.AppendEncrypt(buf[:0], "", 1.354)
+	}
+}
+
+func BenchmarkEncryptParallel(b *testing.B) {
+	pricer := buildBenchmarkPricer(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			pricer.Encrypt("", 1.354)
+		}
+	})
+}
+
+func BenchmarkDecrypt(b *testing.B) {
+	pricer := buildBenchmarkPricer(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	}
+}
+
+func TestAppendEncrypt(t *testing.T) {
+	// Setup:
+	pricer, err := buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+	assert.Nil(t, err)
+	prefix := []byte("price=")
+
+	// Execute:
+	result, err := pricer.AppendEncrypt(prefix, "", 1.354)
+	encrypted, encryptErr := pricer.Encrypt("", 1.354)
+
+	// Verify:
+	assert.Nil(t, err)
+	assert.Nil(t, encryptErr)
+	assert.Equal(t, "price="+encrypted, string(result))
+	assert.Equal(t, "price=1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA", string(result))
+}
+
+func BenchmarkDecryptInto(b *testing.B) {
+	pricer := buildBenchmarkPricer(b)
+	buffers := sync.Pool{New: func() interface{} {
+		return new([28]byte)
+	}}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		dst := buffers.Get().(*[28]byte)
+		pricer.DecryptInto(dst[:], "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+		buffers.Put(dst)
+	}
+}
+
+func TestDecryptInto(t *testing.T) {
+	// Setup:
+	pricer, err := buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+	assert.Nil(t, err)
+	var buffersTestCase = []struct {
+		name      string
+		dst       []byte
+		encrypted string
+	}{
+		{"exact", make([]byte, 28), "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg"},
+		{"exact padded", make([]byte, 28), "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg=="},
+		{"oversized", make([]byte, 128), "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg"},
+		// Longer than the stack buffer encrypted prices are copied to
+		{"oversized with trailing bytes", make([]byte, 128), "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg" + strings.Repeat("A", 62)},
+	}
+
+	for _, buffer := range buffersTestCase {
+		t.Run(buffer.name, func(t *testing.T) {
+			// Execute:
+			price, err := pricer.DecryptInto(buffer.dst, buffer.encrypted)
+			expected, decryptErr := pricer.Decrypt(buffer.encrypted)
+
+			// Verify:
+			assert.Nil(t, err, "Decryption failed. Error : %s", err)
+			assert.Nil(t, decryptErr, "Decryption failed. Error : %s", decryptErr)
+			assert.Equal(t, expected, price)
+			assert.InDelta(t, 1.354, price, 0.000001)
+		})
+	}
+}
+
+func TestDecryptIntoShortBuffer(t *testing.T) {
+	// Setup:
+	pricer, err := buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+	assert.Nil(t, err)
+	var buffersTestCase = []struct {
+		dst          []byte
+		encrypted    string
+		errorMessage string
+	}{
+		{nil, "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", "buffer too small: expected at least 28 bytes, got 0"},
+		{make([]byte, 27), "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", "buffer too small: expected at least 28 bytes, got 27"},
+		// Trailing bytes have to fit too
+		{make([]byte, 28), "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpgAAAA", "buffer too small: expected at least 31 bytes, got 28"},
+	}
+
+	for _, buffer := range buffersTestCase {
+		// Execute:
+		price, err := pricer.DecryptInto(buffer.dst, buffer.encrypted)
+
+		// Verify:
+		assert.Equal(t, float64(0), price)
+		assert.True(t, errors.Is(err, ErrShortBuffer), "Unexpected error : %s", err)
+		assert.EqualError(t, err, buffer.errorMessage)
+	}
+}
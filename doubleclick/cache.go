@@ -0,0 +1,75 @@
+package doubleclick
+
+import (
+	"container/list"
+	"sync"
+)
+
+// decryptCache is a bounded LRU cache of price bytes keyed on encrypted prices.
+// Only successfully decrypted prices, whose integrity signature matched, are added.
+// A decryptCache is safe for concurrent use by multiple goroutines.
+type decryptCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	// order holds cacheEntry values, most recently used first.
+	order *list.List
+}
+
+// cacheEntry is a cached decrypted price.
+type cacheEntry struct {
+	encryptedPrice string
+	priceMicro     [8]byte
+	// isZeroIV tells whether the price IV is all zeros, for cache hits
+	// to warn as decryptions do with helpers.WarnZeroIV.
+	isZeroIV bool
+}
+
+// newDecryptCache returns an empty decryptCache holding at most size prices.
+func newDecryptCache(size int) *decryptCache {
+	return &decryptCache{
+		size:    size,
+		entries: make(map[string]*list.Element, size),
+		order:   list.New(),
+	}
+}
+
+// get returns the entry cached for encryptedPrice, if any.
+func (c *decryptCache) get(encryptedPrice string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[encryptedPrice]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	c.order.MoveToFront(element)
+
+	return element.Value.(cacheEntry), true
+}
+
+// add caches price bytes for encryptedPrice, and whether its IV is all zeros,
+// evicting the least recently used price if the cache is full.
+func (c *decryptCache) add(encryptedPrice string, priceMicro [8]byte, isZeroIV bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[encryptedPrice]; ok {
+		c.order.MoveToFront(element)
+		return
+	}
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(cacheEntry).encryptedPrice)
+	}
+	c.entries[encryptedPrice] = c.order.PushFront(cacheEntry{encryptedPrice: encryptedPrice, priceMicro: priceMicro, isZeroIV: isZeroIV})
+}
+
+// len returns how many prices are cached.
+func (c *decryptCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
@@ -0,0 +1,163 @@
+package doubleclick
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countOpens returns how many encrypted prices were opened, i.e. had their pad
+// and signature HMACs computed, from the debug lines logger received.
+func countOpens(logger *recordingLogger) int {
+	var opens int
+	for _, line := range logger.lines {
+		if strings.HasPrefix(line, "Pad : ") {
+			opens++
+		}
+	}
+
+	return opens
+}
+
+func buildCachingPricer(t *testing.T, size int) (*DoubleClickPricer, *recordingLogger) {
+	logger := &recordingLogger{}
+	pricer := buildTestPricer(t, WithDecryptCache(size), WithDebug(true), WithLogger(logger))
+
+	return pricer, logger
+}
+
+func TestDecryptCacheHit(t *testing.T) {
+	// Setup:
+	pricer, logger := buildCachingPricer(t, 8)
+
+	// Execute:
+	first, firstErr := pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	second, secondErr := pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	micros, microsErr := pricer.DecryptMicros("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+
+	// Verify:
+	assert.Nil(t, firstErr, "Unexpected error : %s", firstErr)
+	assert.Nil(t, secondErr, "Unexpected error : %s", secondErr)
+	assert.Nil(t, microsErr, "Unexpected error : %s", microsErr)
+	assert.InDelta(t, 1.354, first, 0.000001)
+	assert.Equal(t, first, second)
+	assert.Equal(t, uint64(1354000), micros)
+	assert.Equal(t, 1, countOpens(logger), "Cache hits shouldn't compute HMACs")
+}
+
+func TestDecryptCacheSkipsFailures(t *testing.T) {
+	// Setup:
+	pricer, logger := buildCachingPricer(t, 8)
+	var encryptedPrices = []string{
+		tamperSignature("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", 0),
+		"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg"[:24] + "AAAA" + "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg"[28:],
+		"anCGGFJApcfB6ZGc6mindhpTrYXHY4",
+		"not base64 !",
+	}
+
+	for _, encryptedPrice := range encryptedPrices {
+		// Execute:
+		_, firstErr := pricer.Decrypt(encryptedPrice)
+		_, secondErr := pricer.Decrypt(encryptedPrice)
+
+		// Verify:
+		assert.NotNil(t, firstErr)
+		assert.Equal(t, firstErr, secondErr)
+	}
+	assert.Equal(t, 0, pricer.keys.Load().cache.len())
+	assert.Equal(t, 4, countOpens(logger), "Tampered prices should be opened every time")
+}
+
+func TestDecryptCacheEviction(t *testing.T) {
+	// Setup:
+	pricer, logger := buildCachingPricer(t, 2)
+	var encryptedPrices []string
+	for _, price := range []float64{1, 2, 3} {
+		encrypted, err := pricer.Encrypt("", price)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		encryptedPrices = append(encryptedPrices, encrypted)
+	}
+
+	// Execute:
+	for _, encryptedPrice := range encryptedPrices {
+		pricer.Decrypt(encryptedPrice)
+	}
+	opens := countOpens(logger)
+	// Last two are cached, first one was evicted.
+	pricer.Decrypt(encryptedPrices[2])
+	pricer.Decrypt(encryptedPrices[1])
+	cachedOpens := countOpens(logger)
+	pricer.Decrypt(encryptedPrices[0])
+
+	// Verify:
+	assert.Equal(t, 2, pricer.keys.Load().cache.len())
+	assert.Equal(t, opens, cachedOpens)
+	assert.Equal(t, opens+1, countOpens(logger))
+}
+
+func TestDecryptCacheTrailingBytes(t *testing.T) {
+	// Setup:
+	pricer, _ := buildCachingPricer(t, 8)
+
+	// Execute:
+	price, err := pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpgAA")
+
+	// Verify:
+	assert.Nil(t, err, "Unexpected error : %s", err)
+	assert.InDelta(t, 1.354, price, 0.000001)
+	assert.Equal(t, 0, pricer.keys.Load().cache.len())
+}
+
+func TestDecryptCacheConcurrent(t *testing.T) {
+	// Setup:
+	pricer, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+		WithDecryptCache(4),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	var encryptedPrices []string
+	for _, price := range []float64{1, 2, 3, 4, 5, 6} {
+		encrypted, err := pricer.Encrypt("", price)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		encryptedPrices = append(encryptedPrices, encrypted)
+	}
+
+	// Execute:
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				index := (worker + i) % len(encryptedPrices)
+				price, err := pricer.Decrypt(encryptedPrices[index])
+
+				// Verify:
+				assert.Nil(t, err, "Unexpected error : %s", err)
+				assert.InDelta(t, float64(index+1), price, 0.000001)
+			}
+		}(worker)
+	}
+	wg.Wait()
+	assert.Equal(t, 4, pricer.keys.Load().cache.len())
+}
+
+func TestDecryptCacheInvalidSize(t *testing.T) {
+	// Execute:
+	pricer, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+		WithDecryptCache(-1),
+	)
+
+	// Verify:
+	assert.Nil(t, pricer)
+	assert.EqualError(t, err, "decrypt cache size should be positive, got -1")
+}
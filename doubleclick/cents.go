@@ -0,0 +1,78 @@
+package doubleclick
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+// EncryptCents encrypts a clear price expressed in cents and a given seed.
+// Cents are scaled with integer arithmetic only, never going through a float,
+// e.g. 201 cents always give 2010000 micros while Encrypt(seed, 2.01) gives
+// 2009999 with the float scale factor. The scale factor must be an integer.
+func (dc *DoubleClickPricer) EncryptCents(seed string, cents int64) (encrypted string, err error) {
+	if dc.observer != nil {
+		defer dc.observeEncrypt(time.Now(), &err)
+	}
+
+	if err := dc.validateSeed(seed); err != nil {
+		return "", err
+	}
+	if dc.maxPrice != nil && float64(cents) > *dc.maxPrice*100 {
+		return "", fmt.Errorf("%w: %d cents, expected at most %g", ErrPriceAboveMax, cents, *dc.maxPrice)
+	}
+	scaleFactor, err := dc.centsScaleFactor()
+	if err != nil {
+		return "", err
+	}
+	micros, err := helpers.CentsToMicros(cents, scaleFactor)
+	if err != nil {
+		return "", err
+	}
+	var data [8]byte
+	binary.BigEndian.PutUint64(data[:], micros)
+	if dc.isDebugMode == true {
+		dc.logger.Debugf("Micro price bytes : %v", data)
+	}
+
+	return dc.encrypt(dc.seedIV(seed), data)
+}
+
+// DecryptCents decrypts an encrypted price and returns it in cents, scaled with integer
+// arithmetic only. Fractions of cents are truncated, and neither rounding nor clamping
+// policies apply. The scale factor must be an integer. With WithNegativePrices,
+// micros are read as a signed 64 bits integer, negative cents being truncated towards zero.
+func (dc *DoubleClickPricer) DecryptCents(encryptedPrice string) (cents int64, err error) {
+	scaleFactor, err := dc.centsScaleFactor()
+	if err != nil {
+		return 0, err
+	}
+	priceMicro, err := dc.decrypt(encryptedPrice)
+	if err != nil {
+		return 0, err
+	}
+
+	micros := binary.BigEndian.Uint64(priceMicro[:])
+	if dc.allowNegativePrices && int64(micros) < 0 {
+		cents, err := helpers.MicrosToCents(-micros, scaleFactor)
+		return -cents, err
+	}
+
+	return helpers.MicrosToCents(micros, scaleFactor)
+}
+
+// centsScaleFactor returns the scale factor as an integer, for cents to be
+// scaled without float arithmetic.
+func (dc *DoubleClickPricer) centsScaleFactor() (uint64, error) {
+	if dc.integerScale != 0 {
+		return uint64(dc.integerScale), nil
+	}
+	if dc.scaleFactor != math.Trunc(dc.scaleFactor) || dc.scaleFactor >= math.MaxUint64 {
+		return 0, fmt.Errorf("%w: scale factor should be an integer to scale cents, got %g", ErrInvalidScaleFactor, dc.scaleFactor)
+	}
+
+	return uint64(dc.scaleFactor), nil
+}
@@ -0,0 +1,132 @@
+package doubleclick
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecryptCents(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+
+	for _, cents := range []int64{0, 1, 135, 201, 1005, 100000} {
+		// Execute:
+		encrypted, err := pricer.EncryptCents("seed", cents)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		decrypted, err := pricer.DecryptCents(encrypted)
+
+		// Verify:
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+		assert.Equal(t, cents, decrypted)
+	}
+}
+
+func TestEncryptCentsMatchesFloatAPI(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+
+	// Execute:
+	encrypted, err := pricer.EncryptCents("seed", 135)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	expected, err := pricer.Encrypt("seed", 1.35)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+
+	// Verify:
+	assert.Equal(t, expected, encrypted)
+}
+
+func TestEncryptCentsDoesNotRound(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+
+	// Execute:
+	encryptedCents, err := pricer.EncryptCents("seed", 201)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	encryptedFloat, err := pricer.Encrypt("seed", 2.01)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+
+	// Verify:
+	// 2.01 * 1000000 is 2009999.9999999998 as a float, truncated to 2009999 micros
+	micros, err := pricer.DecryptMicros(encryptedFloat)
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.Equal(t, uint64(2009999), micros)
+	micros, err = pricer.DecryptMicros(encryptedCents)
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.Equal(t, uint64(2010000), micros)
+	cents, err := pricer.DecryptCents(encryptedFloat)
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.Equal(t, int64(200), cents)
+}
+
+func TestDecryptCentsTruncates(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+	encrypted, err := pricer.EncryptMicros("seed", 1354000)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+
+	// Execute:
+	cents, err := pricer.DecryptCents(encrypted)
+
+	// Verify:
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.Equal(t, int64(135), cents)
+}
+
+func TestDecryptCentsNegativePrices(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithNegativePrices(true))
+	encrypted, err := pricer.Encrypt("seed", -1.354)
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+	encryptedPositive, err := pricer.Encrypt("seed", 1.354)
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+
+	// Execute:
+	cents, err := pricer.DecryptCents(encrypted)
+	positiveCents, errPositive := pricer.DecryptCents(encryptedPositive)
+
+	// Verify:
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.Equal(t, int64(-135), cents)
+	assert.Nil(t, errPositive, "Decryption failed. Error : %s", errPositive)
+	assert.Equal(t, int64(135), positiveCents)
+}
+
+func TestCentsWithIntegerScaleFactor(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithIntegerScaleFactor(1000))
+
+	// Execute:
+	encrypted, err := pricer.EncryptCents("seed", 201)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	micros, err := pricer.DecryptMicros(encrypted)
+
+	// Verify:
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.Equal(t, uint64(2010), micros)
+}
+
+func TestCentsErrors(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+	fractionalPricer := buildTestPricer(t, WithScaleFactor(1000.5))
+	maxPricer := buildTestPricer(t, WithMaxPrice(10))
+
+	// Execute:
+	_, errNegative := pricer.EncryptCents("seed", -1)
+	_, errFractionalEncrypt := fractionalPricer.EncryptCents("seed", 1)
+	_, errFractionalDecrypt := fractionalPricer.DecryptCents("1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA")
+	_, errAboveMax := maxPricer.EncryptCents("seed", 1001)
+	_, errAtMax := maxPricer.EncryptCents("seed", 1000)
+	_, errMalformed := pricer.DecryptCents("!")
+
+	// Verify:
+	assert.NotNil(t, errNegative)
+	assert.True(t, errors.Is(errFractionalEncrypt, ErrInvalidScaleFactor), "Unexpected error : %s", errFractionalEncrypt)
+	assert.True(t, errors.Is(errFractionalDecrypt, ErrInvalidScaleFactor), "Unexpected error : %s", errFractionalDecrypt)
+	assert.True(t, errors.Is(errAboveMax, ErrPriceAboveMax), "Unexpected error : %s", errAboveMax)
+	assert.Nil(t, errAtMax, "Unexpected error : %s", errAtMax)
+	assert.NotNil(t, errMalformed)
+}
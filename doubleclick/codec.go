@@ -0,0 +1,65 @@
+package doubleclick
+
+import (
+	"github.com/benjaminch/pricers"
+)
+
+var _ pricers.Pricer = (*Codec)(nil)
+
+// Codec encrypts and decrypts prices exactly as a DoubleClickPricer built
+// with the same options does, through a pricer it doesn't expose: it has no
+// SetKeys nor Close, so its keys can't be rotated nor its pricer closed.
+// Settings held by value, such as keys, scale factor or max price, are fixed
+// at construction. Options holding references, such as observer, logger, IV
+// deriver, random source or rate provider, are shared with the caller, and
+// the decrypt cache, if any, keeps filling along calls. A Codec is safe for
+// concurrent use by multiple goroutines.
+type Codec struct {
+	pricer *DoubleClickPricer
+}
+
+// NewCodec returns a Codec configured with opts, see NewPricer for defaults.
+func NewCodec(opts ...Option) (*Codec, error) {
+	pricer, err := NewPricer(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Codec{pricer: pricer}, nil
+}
+
+// Encrypt encrypts a clear price and a given seed.
+func (c *Codec) Encrypt(seed string, price float64) (string, error) {
+	return c.pricer.Encrypt(seed, price)
+}
+
+// AppendEncrypt encrypts a clear price and a given seed, appending
+// the encoded encrypted price to dst and returning the extended buffer.
+func (c *Codec) AppendEncrypt(dst []byte, seed string, price float64) ([]byte, error) {
+	return c.pricer.AppendEncrypt(dst, seed, price)
+}
+
+// EncryptMicros encrypts a price already expressed in micros and a given seed.
+func (c *Codec) EncryptMicros(seed string, micros uint64) (string, error) {
+	return c.pricer.EncryptMicros(seed, micros)
+}
+
+// Decrypt decrypts an encrypted price.
+func (c *Codec) Decrypt(encryptedPrice string) (float64, error) {
+	return c.pricer.Decrypt(encryptedPrice)
+}
+
+// DecryptMicros decrypts an encrypted price and returns it in micros.
+func (c *Codec) DecryptMicros(encryptedPrice string) (uint64, error) {
+	return c.pricer.DecryptMicros(encryptedPrice)
+}
+
+// CiphertextLen returns the length of encrypted prices once decoded.
+func (c *Codec) CiphertextLen() int {
+	return c.pricer.CiphertextLen()
+}
+
+// EncodedLen returns the length of encrypted prices as returned by Encrypt.
+func (c *Codec) EncodedLen() int {
+	return c.pricer.EncodedLen()
+}
@@ -0,0 +1,131 @@
+package doubleclick
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func buildCodecOptions() []Option {
+	return []Option{
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+	}
+}
+
+func TestCodecBehavesAsPricer(t *testing.T) {
+	// Setup:
+	codec, err := NewCodec(buildCodecOptions()...)
+	assert.Nil(t, err, "Error creating new Codec : ", err)
+	pricer, err := NewPricer(buildCodecOptions()...)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	for _, price := range []float64{0, 0.01, 1.354, 12.75, 1000} {
+		// Execute:
+		fromCodec, err := codec.Encrypt("seed", price)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		fromPricer, err := pricer.Encrypt("seed", price)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		appended, err := codec.AppendEncrypt(nil, "seed", price)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		decrypted, err := codec.Decrypt(fromPricer)
+
+		// Verify:
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+		assert.Equal(t, fromPricer, fromCodec)
+		assert.Equal(t, fromPricer, string(appended))
+		assert.InDelta(t, price, decrypted, 0.000001)
+	}
+
+	// Micros
+	// Execute:
+	encrypted, err := codec.EncryptMicros("seed", 1354000)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	micros, err := pricer.DecryptMicros(encrypted)
+
+	// Verify:
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.Equal(t, uint64(1354000), micros)
+	micros, err = codec.DecryptMicros(encrypted)
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.Equal(t, uint64(1354000), micros)
+
+	// Lengths
+	// Verify:
+	assert.Equal(t, pricer.CiphertextLen(), codec.CiphertextLen())
+	assert.Equal(t, pricer.EncodedLen(), codec.EncodedLen())
+}
+
+func TestCodecPropagatesErrors(t *testing.T) {
+	// Execute:
+	codec, err := NewCodec(WithKeys("", ""))
+
+	// Verify:
+	assert.NotNil(t, err)
+	assert.Nil(t, codec)
+}
+
+func TestCodecCannotBeMutated(t *testing.T) {
+	// Setup:
+	codecType := reflect.TypeOf(Codec{})
+	codecPtrType := reflect.TypeOf(&Codec{})
+
+	// Verify:
+	for i := 0; i < codecType.NumField(); i++ {
+		assert.False(t, codecType.Field(i).IsExported(), "Codec field %s is exported", codecType.Field(i).Name)
+	}
+	for i := 0; i < codecPtrType.NumMethod(); i++ {
+		name := codecPtrType.Method(i).Name
+		assert.False(t, strings.HasPrefix(name, "Set") || strings.HasPrefix(name, "With"), "Codec method %s may mutate it", name)
+	}
+}
+
+func TestCodecIgnoresLaterOptionChanges(t *testing.T) {
+	// Setup:
+	opts := append(buildCodecOptions(), WithMaxPrice(10))
+	codec, err := NewCodec(opts...)
+	assert.Nil(t, err, "Error creating new Codec : ", err)
+
+	// Execute:
+	opts[0] = WithKeys("00", "00")
+	opts[1] = WithMaxPrice(100)
+	_, errAboveMax := codec.Encrypt("seed", 50)
+	encrypted, err := codec.Encrypt("seed", 1.354)
+
+	// Verify:
+	assert.True(t, errors.Is(errAboveMax, ErrPriceAboveMax), "Unexpected error : %s", errAboveMax)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	price, err := codec.Decrypt(encrypted)
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.InDelta(t, 1.354, price, 0.000001)
+}
+
+func TestCodecAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops items at random with the race detector")
+	}
+
+	// Setup:
+	codec, err := NewCodec(buildCodecOptions()...)
+	assert.Nil(t, err, "Error creating new Codec : ", err)
+	encrypted, err := codec.Encrypt("seed", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	dst := make([]byte, 0, 64)
+
+	// Execute:
+	encryptAllocs := testing.AllocsPerRun(100, func() {
+		dst, _ = codec.AppendEncrypt(dst[:0], "seed", 1.354)
+	})
+	decryptAllocs := testing.AllocsPerRun(100, func() {
+		_, _ = codec.Decrypt(encrypted)
+	})
+
+	// Verify:
+	assert.Equal(t, float64(0), encryptAllocs)
+	assert.Equal(t, float64(0), decryptAllocs)
+}
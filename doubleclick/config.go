@@ -0,0 +1,92 @@
+package doubleclick
+
+import (
+	"encoding/json"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+// PricerConfig describes the non secret configuration of a DoubleClickPricer,
+// e.g. to dump which configuration an instance runs with. Keys are only
+// described by their fingerprints, see helpers.KeyFingerprint.
+type PricerConfig struct {
+	EncryptionKeyFingerprint string                  `json:"encryption_key_fingerprint"`
+	IntegrityKeyFingerprint  string                  `json:"integrity_key_fingerprint"`
+	KeyDecodingMode          helpers.KeyDecodingMode `json:"key_decoding_mode"`
+	KeyLength                int                     `json:"key_length"`
+	ScaleFactor              float64                 `json:"scale_factor"`
+	IsIntegerScaleFactor     bool                    `json:"is_integer_scale_factor"`
+	Base64Variant            helpers.Base64Variant   `json:"base64_variant"`
+	PriceEncoding            helpers.PriceEncoding   `json:"price_encoding"`
+	SignatureLayout          helpers.SignatureLayout `json:"signature_layout"`
+	SignatureLength          int                     `json:"signature_length"`
+	PadOffset                int                     `json:"pad_offset"`
+	HashAlgorithm            helpers.HashAlgorithm   `json:"hash_algorithm"`
+	IsStrict                 bool                    `json:"is_strict"`
+	IsUnsigned               bool                    `json:"is_unsigned"`
+	DetectSwappedKeys        bool                    `json:"detect_swapped_keys"`
+	MaxMicros                uint64                  `json:"max_micros"`
+	MaxPrice                 *float64                `json:"max_price,omitempty"`
+	AllowNegativePrices      bool                    `json:"allow_negative_prices"`
+	RoundingMode             helpers.RoundingMode    `json:"rounding_mode,omitempty"`
+	RoundingDecimals         int                     `json:"rounding_decimals,omitempty"`
+	ZeroIVPolicy             helpers.ZeroIVPolicy    `json:"zero_iv_policy,omitempty"`
+	PriceFloor               *float64                `json:"price_floor,omitempty"`
+	PriceCeiling             *float64                `json:"price_ceiling,omitempty"`
+	MinSeedLength            int                     `json:"min_seed_length"`
+	DecryptCacheSize         int                     `json:"decrypt_cache_size"`
+	Currency                 string                  `json:"currency,omitempty"`
+	IsDebugMode              bool                    `json:"is_debug_mode"`
+}
+
+// Config returns the pricer non secret configuration, keys being
+// described by the fingerprints of the keys currently in use.
+func (dc *DoubleClickPricer) Config() PricerConfig {
+	keys := dc.keys.Load()
+
+	return PricerConfig{
+		EncryptionKeyFingerprint: helpers.KeyFingerprint(keys.encryptionKey),
+		IntegrityKeyFingerprint:  helpers.KeyFingerprint(keys.integrityKey),
+		KeyDecodingMode:          keys.keyDecodingMode,
+		KeyLength:                dc.keyLength,
+		ScaleFactor:              dc.scaleFactor,
+		IsIntegerScaleFactor:     dc.integerScale != 0,
+		Base64Variant:            dc.base64Variant,
+		PriceEncoding:            dc.priceEncoding,
+		SignatureLayout:          dc.signatureLayout,
+		SignatureLength:          dc.signatureLength,
+		PadOffset:                dc.padOffset,
+		HashAlgorithm:            dc.hashAlgorithm,
+		IsStrict:                 dc.isStrict,
+		IsUnsigned:               dc.isUnsigned,
+		DetectSwappedKeys:        dc.detectSwappedKeys,
+		MaxMicros:                dc.maxMicros,
+		MaxPrice:                 copyFloat(dc.maxPrice),
+		AllowNegativePrices:      dc.allowNegativePrices,
+		RoundingMode:             dc.roundingMode,
+		RoundingDecimals:         dc.roundingDecimals,
+		ZeroIVPolicy:             dc.zeroIVPolicy,
+		PriceFloor:               copyFloat(dc.priceFloor),
+		PriceCeiling:             copyFloat(dc.priceCeiling),
+		MinSeedLength:            dc.minSeedLength,
+		DecryptCacheSize:         dc.decryptCacheSize,
+		Currency:                 dc.currency,
+		IsDebugMode:              dc.isDebugMode,
+	}
+}
+
+// MarshalJSON encodes the pricer non secret configuration, as returned by Config,
+// so that a pricer can be logged or dumped without leaking its keys.
+func (dc *DoubleClickPricer) MarshalJSON() ([]byte, error) {
+	return json.Marshal(dc.Config())
+}
+
+// copyFloat returns a copy of f, so that callers can't change pricer settings through it.
+func copyFloat(f *float64) *float64 {
+	if f == nil {
+		return nil
+	}
+	c := *f
+
+	return &c
+}
@@ -0,0 +1,93 @@
+package doubleclick
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func TestPricerConfig(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithStrict(true), WithMaxPrice(50), WithPriceEncoding(helpers.Hex))
+
+	// Execute:
+	config := pricer.Config()
+
+	// Verify:
+	keys := pricer.keys.Load()
+	assert.Equal(t, helpers.KeyFingerprint(keys.encryptionKey), config.EncryptionKeyFingerprint)
+	assert.Equal(t, helpers.KeyFingerprint(keys.integrityKey), config.IntegrityKeyFingerprint)
+	assert.Equal(t, helpers.Hexa, config.KeyDecodingMode)
+	assert.Equal(t, DefaultKeyLength, config.KeyLength)
+	assert.Equal(t, DefaultScaleFactor, config.ScaleFactor)
+	assert.False(t, config.IsIntegerScaleFactor)
+	assert.Equal(t, helpers.URLSafe, config.Base64Variant)
+	assert.Equal(t, helpers.Hex, config.PriceEncoding)
+	assert.Equal(t, helpers.PriceIV, config.SignatureLayout)
+	assert.Equal(t, helpers.SHA1, config.HashAlgorithm)
+	assert.True(t, config.IsStrict)
+	assert.Equal(t, DefaultMaxMicros, config.MaxMicros)
+	assert.Equal(t, 50.0, *config.MaxPrice)
+
+	// Changing returned config doesn't change the pricer
+	// Execute:
+	*config.MaxPrice = 500
+	_, err := pricer.Encrypt("seed", 100)
+
+	// Verify:
+	assert.True(t, errors.Is(err, ErrPriceAboveMax), "Unexpected error : %s", err)
+}
+
+func TestPricerMarshalJSON(t *testing.T) {
+	// Setup:
+	encryptionKey := "652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135"
+	integrityKey := "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5"
+	pricer := buildTestPricer(t)
+	keys := pricer.keys.Load()
+
+	// Execute:
+	encoded, err := json.Marshal(pricer)
+
+	// Verify:
+	assert.Nil(t, err, "Unexpected error : %s", err)
+	dump := string(encoded)
+	assert.Contains(t, dump, `"scale_factor":1000000`)
+	assert.Contains(t, dump, `"encryption_key_fingerprint":"`+helpers.KeyFingerprint(keys.encryptionKey)+`"`)
+	assert.Contains(t, dump, `"integrity_key_fingerprint":"`+helpers.KeyFingerprint(keys.integrityKey)+`"`)
+	for _, key := range [][]byte{keys.encryptionKey, keys.integrityKey} {
+		assert.NotContains(t, dump, hex.EncodeToString(key))
+		assert.NotContains(t, dump, base64.StdEncoding.EncodeToString(key))
+		assert.NotContains(t, dump, base64.RawURLEncoding.EncodeToString(key))
+		assert.NotContains(t, dump, strings.Trim(strings.Join(strings.Fields(fmt.Sprint(key)), ","), "[]"))
+	}
+	assert.NotContains(t, dump, encryptionKey)
+	assert.NotContains(t, dump, integrityKey)
+}
+
+func TestPricerConfigAfterSetKeys(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+	before := pricer.Config()
+
+	// Execute:
+	err := pricer.SetKeys(
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		false,
+		helpers.Hexa,
+	)
+	after := pricer.Config()
+
+	// Verify:
+	assert.Nil(t, err, "Unexpected error : %s", err)
+	assert.Equal(t, before.EncryptionKeyFingerprint, after.IntegrityKeyFingerprint)
+	assert.Equal(t, before.IntegrityKeyFingerprint, after.EncryptionKeyFingerprint)
+}
@@ -0,0 +1,39 @@
+package doubleclick
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// EncryptCurrency encrypts a clear price expressed in currency from and a given seed,
+// converting the price to the currency set with WithCurrency before scaling it.
+// Prices already in that currency are encrypted as is, without looking rates up.
+func (dc *DoubleClickPricer) EncryptCurrency(seed string, price float64, from string) (string, error) {
+	converted, err := dc.convert(price, from)
+	if err != nil {
+		return "", err
+	}
+
+	return dc.Encrypt(seed, converted)
+}
+
+// convert converts price from currency from to the pricer's currency.
+func (dc *DoubleClickPricer) convert(price float64, from string) (float64, error) {
+	if dc.currency == "" {
+		return 0, fmt.Errorf("%w: no currency configured", ErrCurrencyConversion)
+	}
+	if strings.EqualFold(from, dc.currency) {
+		return price, nil
+	}
+
+	rate, err := dc.rateProvider.Rate(strings.ToUpper(from), dc.currency)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s to %s: %w", ErrCurrencyConversion, from, dc.currency, err)
+	}
+	if !(rate > 0) || math.IsInf(rate, 0) {
+		return 0, fmt.Errorf("%w: %s to %s: invalid rate %g", ErrCurrencyConversion, from, dc.currency, rate)
+	}
+
+	return price * rate, nil
+}
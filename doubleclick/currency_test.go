@@ -0,0 +1,116 @@
+package doubleclick
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+// stubRates is a RateProvider serving fixed rates, keyed by "FROM/TO".
+type stubRates struct {
+	rates   map[string]float64
+	err     error
+	lookups int
+}
+
+func (s *stubRates) Rate(from string, to string) (float64, error) {
+	s.lookups++
+	if s.err != nil {
+		return 0, s.err
+	}
+	rate, ok := s.rates[from+"/"+to]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", helpers.ErrUnknownCurrency, from)
+	}
+
+	return rate, nil
+}
+
+func TestEncryptCurrency(t *testing.T) {
+	var tests = []struct {
+		name     string
+		price    float64
+		from     string
+		expected float64
+		lookups  int
+	}{
+		{name: "same currency", price: 1.354, from: "USD", expected: 1.354, lookups: 0},
+		{name: "same currency, lower case", price: 1.354, from: "usd", expected: 1.354, lookups: 0},
+		{name: "conversion", price: 2, from: "EUR", expected: 2.2, lookups: 1},
+		{name: "conversion, lower case", price: 2, from: "eur", expected: 2.2, lookups: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup:
+			rates := &stubRates{rates: map[string]float64{"EUR/USD": 1.1}}
+			pricer := buildTestPricer(t, WithCurrency("usd", rates))
+
+			// Execute:
+			encrypted, err := pricer.EncryptCurrency("seed", tt.price, tt.from)
+			require.NoError(t, err, "Unexpected error : %s", err)
+			decrypted, err := pricer.Decrypt(encrypted)
+
+			// Verify:
+			require.NoError(t, err, "Unexpected error : %s", err)
+			assert.InDelta(t, tt.expected, decrypted, 0.000001)
+			assert.Equal(t, tt.lookups, rates.lookups)
+		})
+	}
+}
+
+func TestEncryptCurrencyErrors(t *testing.T) {
+	var tests = []struct {
+		name     string
+		rates    *stubRates
+		from     string
+		expected error
+	}{
+		{name: "unknown currency", rates: &stubRates{}, from: "GBP", expected: helpers.ErrUnknownCurrency},
+		{name: "stale rate", rates: &stubRates{err: helpers.ErrStaleRate}, from: "EUR", expected: helpers.ErrStaleRate},
+		{name: "zero rate", rates: &stubRates{rates: map[string]float64{"EUR/USD": 0}}, from: "EUR", expected: ErrCurrencyConversion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup:
+			pricer := buildTestPricer(t, WithCurrency("usd", tt.rates))
+
+			// Execute:
+			encrypted, err := pricer.EncryptCurrency("seed", 1.354, tt.from)
+
+			// Verify:
+			assert.Empty(t, encrypted)
+			assert.True(t, errors.Is(err, ErrCurrencyConversion), "Unexpected error : %s", err)
+			assert.True(t, errors.Is(err, tt.expected), "Unexpected error : %s", err)
+		})
+	}
+}
+
+func TestEncryptCurrencyWithoutCurrency(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+
+	// Execute:
+	_, err := pricer.EncryptCurrency("seed", 1.354, "USD")
+
+	// Verify:
+	assert.True(t, errors.Is(err, ErrCurrencyConversion), "Unexpected error : %s", err)
+}
+
+func TestNewPricerCurrencyWithoutRateProvider(t *testing.T) {
+	// Execute:
+	pricer, err := NewPricer(
+		WithKeys(testEncryptionKey, testIntegrityKey),
+		WithCurrency("USD", nil),
+	)
+
+	// Verify:
+	assert.Nil(t, pricer)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,103 @@
+package doubleclick
+
+import (
+	"encoding/binary"
+	"strings"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+// DecryptResult holds the elements of a decrypted price,
+// whether or not its integrity signature is valid.
+type DecryptResult struct {
+	// IV is the Initialization Vector the price was encrypted with.
+	IV [16]byte
+	// PriceMicros is the price before the scale factor is applied.
+	PriceMicros uint64
+	// Price is the clear price, PriceMicros divided by the scale factor.
+	Price float64
+	// IntegrityValid tells whether the integrity signature matches.
+	// Price can't be trusted otherwise.
+	IntegrityValid bool
+	// OriginalLength is the length of the encrypted price as received.
+	OriginalLength int
+	// NormalizedLength is the length of the encrypted price once spaces around
+	// it are trimmed and its base 64 padding is normalized, as it is decoded.
+	NormalizedLength int
+	// PaddingFixed tells whether base 64 padding had to be added or fixed,
+	// e.g. for encrypted prices emitted unpadded. Always false for hexa prices.
+	PaddingFixed bool
+	// TrailingBytes is the number of decoded bytes past the message,
+	// ignored unless the pricer is strict.
+	TrailingBytes int
+}
+
+// DecryptDetailed decrypts an encrypted price, returning its elements
+// even when its integrity signature doesn't match, so that suspicious
+// prices can be inspected. A malformed encrypted price returns an error.
+// Lengths and padding tell benign encoding differences, such as a missing
+// padding, from tampering when triaging signature mismatches.
+func (dc *DoubleClickPricer) DecryptDetailed(encryptedPrice string) (DecryptResult, error) {
+	var result DecryptResult
+
+	state, err := dc.acquireDecryptState()
+	if err != nil {
+		return result, err
+	}
+	defer dc.releaseState(state)
+
+	decoded, err := dc.decode(state, encryptedPrice)
+	if err != nil {
+		return result, err
+	}
+
+	opened, _, err := dc.openRawWith(state, decoded)
+	if err != nil {
+		return result, err
+	}
+
+	result.IV = opened.IV
+	result.PriceMicros = binary.BigEndian.Uint64(opened.Price[:])
+	result.Price = dc.toPrice(opened.Price)
+	result.IntegrityValid = opened.IsIntegrityValid
+
+	normalized := strings.TrimSpace(encryptedPrice)
+	if dc.priceEncoding != helpers.Hex {
+		padded := helpers.AddBase64Padding(normalized)
+		result.PaddingFixed = padded != normalized
+		normalized = padded
+	}
+	result.OriginalLength = len(encryptedPrice)
+	result.NormalizedLength = len(normalized)
+	if len(decoded) > dc.messageLength() {
+		result.TrailingBytes = len(decoded) - dc.messageLength()
+	}
+
+	return result, err
+}
+
+// DecryptRawMicros decrypts an encrypted price and returns its 8 price bytes as is,
+// big endian micros before the scale factor is applied, along with whether its
+// integrity signature is valid, e.g. for audit tools storing them verbatim.
+// As with DecryptDetailed, a signature mismatch isn't an error, prices aren't
+// checked against the max micros, and unsigned prices are never reported valid.
+// A malformed encrypted price returns an error.
+func (dc *DoubleClickPricer) DecryptRawMicros(encryptedPrice string) ([8]byte, bool, error) {
+	state, err := dc.acquireDecryptState()
+	if err != nil {
+		return [8]byte{}, false, err
+	}
+	defer dc.releaseState(state)
+
+	decoded, err := dc.decode(state, encryptedPrice)
+	if err != nil {
+		return [8]byte{}, false, err
+	}
+
+	opened, _, err := dc.openRawWith(state, decoded)
+	if err != nil {
+		return [8]byte{}, false, err
+	}
+
+	return opened.Price, opened.IsIntegrityValid, err
+}
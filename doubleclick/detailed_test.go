@@ -0,0 +1,201 @@
+package doubleclick
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func TestDecryptDetailed(t *testing.T) {
+	// Setup:
+	pricer, err := buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	var pricesTestCase = []struct {
+		encrypted      string
+		priceMicros    uint64
+		integrityValid bool
+	}{
+		// Valid
+		{"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", 1354000, true},
+		// Tampered signature, price is still recovered
+		{tamperSignature("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", 0), 1354000, false},
+	}
+
+	for _, price := range pricesTestCase {
+		// Execute:
+		result, err := pricer.DecryptDetailed(price.encrypted)
+
+		// Verify:
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+		assert.Equal(t, "6a7086185240a5c7c1e9919cea68a776", hex.EncodeToString(result.IV[:]))
+		assert.Equal(t, price.priceMicros, result.PriceMicros)
+		assert.InDelta(t, 1.354, result.Price, 0.000001)
+		assert.Equal(t, price.integrityValid, result.IntegrityValid)
+	}
+}
+
+func TestDecryptDetailedMalformed(t *testing.T) {
+	// Setup:
+	pricer, err := buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	var pricesTestCase = []struct {
+		encrypted string
+		err       error
+	}{
+		{"anCGGFJApcfB6ZGc6mindhpTrYXHY4", ErrInvalidCiphertextLength},
+		{"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lX!!", ErrMalformedBase64},
+	}
+
+	for _, price := range pricesTestCase {
+		// Execute:
+		result, err := pricer.DecryptDetailed(price.encrypted)
+
+		// Verify:
+		assert.True(t, errors.Is(err, price.err), "Unexpected error : %s", err)
+		assert.Equal(t, DecryptResult{}, result)
+	}
+}
+
+func TestDecryptDetailedPadding(t *testing.T) {
+	// Setup:
+	pricer, err := buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	message, err := base64.RawURLEncoding.DecodeString("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	assert.Nil(t, err, "Unexpected error : %s", err)
+	withTrailingBytes := base64.URLEncoding.EncodeToString(append(message, 0, 0))
+
+	var pricesTestCase = []struct {
+		name             string
+		encrypted        string
+		originalLength   int
+		normalizedLength int
+		paddingFixed     bool
+		trailingBytes    int
+	}{
+		{"unpadded", "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", 38, 40, true, 0},
+		{"padded", "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg==", 40, 40, false, 0},
+		{"wrongly padded", "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg=", 39, 40, true, 0},
+		{"surrounded with spaces", " anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg==\n", 42, 40, false, 0},
+		{"trailing bytes", withTrailingBytes, 40, 40, false, 2},
+	}
+
+	for _, price := range pricesTestCase {
+		t.Run(price.name, func(t *testing.T) {
+			// Execute:
+			result, err := pricer.DecryptDetailed(price.encrypted)
+
+			// Verify:
+			assert.Nil(t, err, "Decryption failed. Error : %s", err)
+			assert.True(t, result.IntegrityValid)
+			assert.Equal(t, uint64(1354000), result.PriceMicros)
+			assert.Equal(t, price.originalLength, result.OriginalLength)
+			assert.Equal(t, price.normalizedLength, result.NormalizedLength)
+			assert.Equal(t, price.paddingFixed, result.PaddingFixed)
+			assert.Equal(t, price.trailingBytes, result.TrailingBytes)
+		})
+	}
+}
+
+func TestDecryptDetailedHexPadding(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithPriceEncoding(helpers.Hex))
+	encrypted, err := pricer.Encrypt("seed", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+
+	// Execute:
+	result, err := pricer.DecryptDetailed(encrypted)
+
+	// Verify:
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.False(t, result.PaddingFixed)
+	assert.Equal(t, 56, result.OriginalLength)
+	assert.Equal(t, 56, result.NormalizedLength)
+}
+
+func TestDecryptRawMicros(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithMaxMicros(1))
+	var expected [8]byte
+	binary.BigEndian.PutUint64(expected[:], 1354000)
+
+	var pricesTestCase = []struct {
+		name           string
+		encrypted      string
+		integrityValid bool
+	}{
+		{"valid", "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", true},
+		{"tampered signature", tamperSignature("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", 0), false},
+	}
+
+	for _, price := range pricesTestCase {
+		t.Run(price.name, func(t *testing.T) {
+			// Execute:
+			raw, integrityValid, err := pricer.DecryptRawMicros(price.encrypted)
+
+			// Verify:
+			assert.Nil(t, err, "Decryption failed. Error : %s", err)
+			assert.Equal(t, expected, raw)
+			assert.Equal(t, []byte{0, 0, 0, 0, 0, 0x14, 0xa9, 0x10}, raw[:])
+			assert.Equal(t, price.integrityValid, integrityValid)
+		})
+	}
+}
+
+func TestDecryptRawMicrosIgnoresScaleFactor(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithScaleFactor(100))
+	encrypted, err := pricer.Encrypt("seed", 1.5)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+
+	// Execute:
+	raw, integrityValid, err := pricer.DecryptRawMicros(encrypted)
+
+	// Verify:
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.True(t, integrityValid)
+	assert.Equal(t, uint64(150), binary.BigEndian.Uint64(raw[:]))
+}
+
+func TestDecryptRawMicrosMalformed(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+
+	// Execute:
+	raw, integrityValid, errShort := pricer.DecryptRawMicros("anCGGFJApcfB6ZGc6mindhpTrYXHY4")
+	_, _, errMalformed := pricer.DecryptRawMicros("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lX!!")
+
+	// Verify:
+	assert.True(t, errors.Is(errShort, ErrInvalidCiphertextLength), "Unexpected error : %s", errShort)
+	assert.True(t, errors.Is(errMalformed, ErrMalformedBase64), "Unexpected error : %s", errMalformed)
+	assert.Equal(t, [8]byte{}, raw)
+	assert.False(t, integrityValid)
+}
@@ -1,30 +1,69 @@
 package doubleclick
 
 import (
-	"crypto/md5"
+	"context"
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"hash"
+	"io"
+	"math"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/benjaminch/pricers"
 	"github.com/benjaminch/pricers/helpers"
-
-	"github.com/golang/glog"
+	"github.com/benjaminch/pricers/internal/core"
 )
 
+var _ pricers.Pricer = (*DoubleClickPricer)(nil)
+
 // DoubleClickPricer implementing price encryption and decryption
 // Specs : https://developers.google.com/ad-exchange/rtb/response-guide/decrypt-price
+// A DoubleClickPricer is safe for concurrent use by multiple goroutines.
+// A DoubleClickPricer must not be copied after creation.
 type DoubleClickPricer struct {
-	encryptionKeyRaw string
-	integrityKeyRaw  string
-	encryptionKey    hash.Hash
-	integrityKey     hash.Hash
-	keyDecodingMode  helpers.KeyDecodingMode
-	scaleFactor      float64
-	isDebugMode      bool
+	keys      atomic.Pointer[pricerKeys]
+	keyLength int
+	// requireDistinctKeys is kept for SetKeys to check new keys as NewPricer does.
+	requireDistinctKeys bool
+	scaleFactor         float64
+	integerScale        int64
+	base64Encoding      *base64.Encoding
+	base64Variant       helpers.Base64Variant
+	rawBase64           *base64.Encoding
+	priceEncoding       helpers.PriceEncoding
+	signatureLayout     helpers.SignatureLayout
+	signatureLength     int
+	padOffset           int
+	hashAlgorithm       helpers.HashAlgorithm
+	newHash             func() hash.Hash
+	isDebugMode         bool
+	logger              helpers.Logger
+	observer            helpers.Observer
+	roundingMode        helpers.RoundingMode
+	zeroIVPolicy        helpers.ZeroIVPolicy
+	roundingDecimals    int
+	priceFloor          *float64
+	priceCeiling        *float64
+	maxPrice            *float64
+	allowNegativePrices bool
+	isStrict            bool
+	isUnsigned          bool
+	detectSwappedKeys   bool
+	maxMicros           uint64
+	minSeedLength       int
+	ivDeriver           IVDeriver
+	randomSource        io.Reader
+	decryptCacheSize    int
+	currency            string
+	rateProvider        helpers.RateProvider
+	states              sync.Pool
+	closed              atomic.Bool
 }
 
 // NewDoubleClickPricer returns a DoubleClickPricer struct.
@@ -42,155 +81,879 @@ func NewDoubleClickPricer(
 	keyDecodingMode helpers.KeyDecodingMode,
 	scaleFactor float64,
 	isDebugMode bool) (*DoubleClickPricer, error) {
+	return NewDoubleClickPricerWithLogger(
+		encryptionKey,
+		integrityKey,
+		isBase64Keys,
+		keyDecodingMode,
+		scaleFactor,
+		isDebugMode,
+		nil)
+}
+
+// NewDoubleClickPricerWithLogger returns a DoubleClickPricer struct
+// emitting its debug lines through logger.
+// Parameters are the same as NewDoubleClickPricer ones, debug lines
+// are only emitted when debug mode is on. A nil logger discards
+// every debug line.
+// For backward compatibility, decoded keys may be of any non empty length
+// and decrypted prices aren't checked against DefaultMaxMicros.
+func NewDoubleClickPricerWithLogger(
+	encryptionKey string,
+	integrityKey string,
+	isBase64Keys bool,
+	keyDecodingMode helpers.KeyDecodingMode,
+	scaleFactor float64,
+	isDebugMode bool,
+	logger helpers.Logger) (*DoubleClickPricer, error) {
+	return NewPricer(
+		WithKeys(encryptionKey, integrityKey),
+		WithBase64Keys(isBase64Keys),
+		WithKeyDecodingMode(keyDecodingMode),
+		WithScaleFactor(scaleFactor),
+		WithDebug(isDebugMode),
+		WithLogger(logger),
+		WithKeyLength(AnyKeyLength),
+		WithMaxMicros(NoMaxMicros))
+}
+
+// NewPricerFromBytes returns a DoubleClickPricer struct from already decoded
+// 32 bytes keys, e.g. returned as raw bytes by a KMS, without encoding them to
+// strings first. Keys are copied. Other settings are NewPricer defaults.
+func NewPricerFromBytes(encryptionKey []byte, integrityKey []byte, scaleFactor float64) (*DoubleClickPricer, error) {
+	return NewPricer(
+		WithKeyBytes(encryptionKey, integrityKey),
+		WithScaleFactor(scaleFactor),
+	)
+}
+
+// NewGoogleLayoutPricer returns a DoubleClickPricer struct from 32 bytes keys
+// given as web safe base 64 strings, as most exchanges sharing Google's
+// layout hand them out. Other settings are NewPricer defaults: micro prices,
+// 16 bytes IV || 8 bytes price || 4 bytes signature, web safe base 64 encoded.
+// opts are applied last, e.g. for an exchange to state its own defaults.
+func NewGoogleLayoutPricer(encryptionKey string, integrityKey string, opts ...Option) (*DoubleClickPricer, error) {
+	return NewPricer(append([]Option{
+		WithKeys(encryptionKey, integrityKey),
+		WithBase64Keys(true),
+		WithKeyDecodingMode(helpers.Utf8),
+	}, opts...)...)
+}
+
+// Decrypt decrypts a single encrypted price with a throwaway pricer built as
+// NewDoubleClickPricer does from the same parameters, debug mode off, e.g. for
+// one-off decryptions in scripts. Keys are decoded and HMACs built on each call:
+// callers decrypting several prices should build a pricer once instead.
+func Decrypt(
+	encryptionKey string,
+	integrityKey string,
+	encryptedPrice string,
+	isBase64Keys bool,
+	keyDecodingMode helpers.KeyDecodingMode,
+	scaleFactor float64) (float64, error) {
+	pricer, err := NewDoubleClickPricer(encryptionKey, integrityKey, isBase64Keys, keyDecodingMode, scaleFactor, false)
+	if err != nil {
+		return 0, err
+	}
+
+	return pricer.Decrypt(encryptedPrice)
+}
+
+// NewPricer returns a DoubleClickPricer struct configured with opts.
+// When omitted, keys are decoded as hexa and expected to be 32 bytes long,
+// scale factor is 1,000,000, decrypted prices can't exceed DefaultMaxMicros
+// and debug mode is off.
+func NewPricer(opts ...Option) (*DoubleClickPricer, error) {
 	var err error
-	var encryptingFun, integrityFun hash.Hash
 
-	encryptingFun, err = helpers.CreateHmac(encryptionKey, isBase64Keys, keyDecodingMode)
+	c := newConfig(opts...)
+
+	base64Encoding := c.base64Variant.Encoding()
+	if base64Encoding == nil {
+		return nil, fmt.Errorf("unknown base64 variant: %s", c.base64Variant)
+	}
+	if c.priceEncoding != helpers.Base64 && c.priceEncoding != helpers.Hex {
+		return nil, fmt.Errorf("unknown price encoding: %s", c.priceEncoding)
+	}
+	if c.signatureLayout != helpers.PriceIV && c.signatureLayout != helpers.IVPrice {
+		return nil, fmt.Errorf("unknown signature layout: %s", c.signatureLayout)
+	}
+	if c.hashAlgorithm.Hash() == nil {
+		return nil, fmt.Errorf("unknown hash algorithm: %s", c.hashAlgorithm)
+	}
+	maxSignatureLength := core.MaxSignatureLength
+	if c.newHash == nil && c.hashAlgorithm.Hash()().Size() < maxSignatureLength {
+		maxSignatureLength = c.hashAlgorithm.Hash()().Size()
+	}
+	if c.signatureLength < 1 || c.signatureLength > maxSignatureLength {
+		return nil, fmt.Errorf("signature length should be between 1 and %d bytes, got %d", maxSignatureLength, c.signatureLength)
+	}
+	maxPadOffset := math.MaxInt - core.PriceLength
+	if c.newHash == nil {
+		maxPadOffset = c.hashAlgorithm.Hash()().Size() - core.PriceLength
+	}
+	if c.padOffset < 0 || c.padOffset > maxPadOffset {
+		return nil, fmt.Errorf("pad offset should be between 0 and %d bytes, got %d", maxPadOffset, c.padOffset)
+	}
+
+	if c.decryptCacheSize < 0 {
+		return nil, fmt.Errorf("decrypt cache size should be positive, got %d", c.decryptCacheSize)
+	}
+	var keys *pricerKeys
+	if c.encryptionKeyBytes != nil || c.integrityKeyBytes != nil {
+		keys, err = newKeys(c.encryptionKeyBytes, c.integrityKeyBytes, c.keyLength, c.decryptCacheSize)
+	} else {
+		keys, err = decodeKeys(c.encryptionKey, c.integrityKey, c.isBase64Keys, c.keyDecodingMode, c.keyLength, c.decryptCacheSize)
+	}
 	if err != nil {
 		return nil, err
 	}
-	integrityFun, err = helpers.CreateHmac(integrityKey, isBase64Keys, keyDecodingMode)
-	if err != nil {
+
+	if _, err = helpers.ParseRoundingMode(c.roundingMode.String()); err != nil {
+		return nil, fmt.Errorf("unknown rounding mode: %s", c.roundingMode)
+	}
+	if _, err = helpers.ParseZeroIVPolicy(c.zeroIVPolicy.String()); err != nil {
+		return nil, fmt.Errorf("unknown zero IV policy: %s", c.zeroIVPolicy)
+	}
+	if c.roundingDecimals < 0 {
+		return nil, fmt.Errorf("rounding decimals should be positive, got %d", c.roundingDecimals)
+	}
+	if c.priceFloor != nil && c.priceCeiling != nil && *c.priceFloor > *c.priceCeiling {
+		return nil, fmt.Errorf("price floor %g is above price ceiling %g", *c.priceFloor, *c.priceCeiling)
+	}
+	if c.maxPrice != nil && !(*c.maxPrice >= 0) {
+		return nil, fmt.Errorf("max price should be positive, got %g", *c.maxPrice)
+	}
+	if c.ivDeriver == nil {
+		return nil, errors.New("IV deriver is nil")
+	}
+	if c.randomSource == nil {
+		return nil, errors.New("random source is nil")
+	}
+	if c.minSeedLength < 0 {
+		return nil, fmt.Errorf("minimum seed length should be positive, got %d", c.minSeedLength)
+	}
+	if c.currency != "" && c.rateProvider == nil {
+		return nil, fmt.Errorf("currency %s requires a rate provider", c.currency)
+	}
+	if c.integerScaleFactor < 0 {
+		return nil, fmt.Errorf("%w: integer scale factor should be positive, got %d", ErrInvalidScaleFactor, c.integerScaleFactor)
+	}
+	if !(c.scaleFactor > 0) || math.IsInf(c.scaleFactor, 1) {
+		return nil, fmt.Errorf("%w: scale factor should be positive and finite, got %g", ErrInvalidScaleFactor, c.scaleFactor)
+	}
+
+	logger := c.logger
+	if logger == nil {
+		logger = helpers.NopLogger{}
+	}
+
+	if c.isDebugMode == true {
+		logger.Debugf("Keys decoding mode : %s", c.keyDecodingMode)
+		// Keys are secrets: only their fingerprints are logged, see helpers.KeyFingerprint.
+		logger.Debugf("Encryption key fingerprint : %s", helpers.KeyFingerprint(keys.encryptionKey))
+		logger.Debugf("Integrity key fingerprint : %s", helpers.KeyFingerprint(keys.integrityKey))
+	}
+	if err = checkDistinctKeys(keys, c.requireDistinctKeys, logger); err != nil {
 		return nil, err
 	}
 
-	if isDebugMode == true {
-		fmt.Println("Keys decoding mode : ", keyDecodingMode)
-		fmt.Println("Encryption key : ", encryptionKey)
-		encryptionKeyHexa, err := hex.DecodeString(encryptionKey)
-		if err != nil {
-			encryptionKeyHexa = []byte(encryptionKey)
-		}
-		fmt.Println("Encryption key (bytes) : ", []byte(encryptionKeyHexa))
-		fmt.Println("Integrity key : ", integrityKey)
-		integrityKeyHexa, err := hex.DecodeString(integrityKey)
-		if err != nil {
-			integrityKeyHexa = []byte(integrityKey)
-		}
-		fmt.Println("Integrity key (bytes) : ", []byte(integrityKeyHexa))
+	pricer := &DoubleClickPricer{
+		keyLength:           c.keyLength,
+		requireDistinctKeys: c.requireDistinctKeys,
+		scaleFactor:         c.scaleFactor,
+		integerScale:        c.integerScaleFactor,
+		base64Encoding:      base64Encoding,
+		base64Variant:       c.base64Variant,
+		rawBase64:           base64Encoding.WithPadding(base64.NoPadding),
+		priceEncoding:       c.priceEncoding,
+		signatureLayout:     c.signatureLayout,
+		signatureLength:     c.signatureLength,
+		padOffset:           c.padOffset,
+		hashAlgorithm:       c.hashAlgorithm,
+		newHash:             c.newHash,
+		isDebugMode:         c.isDebugMode,
+		logger:              logger,
+		observer:            c.observer,
+		roundingMode:        c.roundingMode,
+		zeroIVPolicy:        c.zeroIVPolicy,
+		roundingDecimals:    c.roundingDecimals,
+		priceFloor:          c.priceFloor,
+		priceCeiling:        c.priceCeiling,
+		maxPrice:            c.maxPrice,
+		allowNegativePrices: c.allowNegativePrices,
+		isStrict:            c.isStrict,
+		isUnsigned:          c.isUnsigned,
+		detectSwappedKeys:   c.detectSwappedKeys,
+		maxMicros:           c.maxMicros,
+		minSeedLength:       c.minSeedLength,
+		ivDeriver:           c.ivDeriver,
+		randomSource:        c.randomSource,
+		currency:            strings.ToUpper(c.currency),
+		decryptCacheSize:    c.decryptCacheSize,
+		rateProvider:        c.rateProvider,
+	}
+	pricer.keys.Store(keys)
+	pricer.states.New = func() interface{} {
+		return pricer.newCryptoState()
 	}
 
-	return &DoubleClickPricer{
-			encryptionKeyRaw: encryptionKey,
-			integrityKeyRaw:  integrityKey,
-			encryptionKey:    encryptingFun,
-			integrityKey:     integrityFun,
-			keyDecodingMode:  keyDecodingMode,
-			scaleFactor:      scaleFactor,
-			isDebugMode:      isDebugMode},
-		err
+	return pricer, err
+}
+
+// validateKeyLength returns an error if decoded key isn't expectedLength bytes long.
+// With AnyKeyLength, key only has to be non empty.
+func validateKeyLength(key []byte, expectedLength int) error {
+	if len(key) == 0 {
+		return errors.New("key is empty")
+	}
+	if expectedLength != AnyKeyLength && len(key) != expectedLength {
+		return fmt.Errorf("expected %d bytes, got %d", expectedLength, len(key))
+	}
+
+	return nil
 }
 
 // Encrypt encrypts a clear price and a given seed.
-func (dc *DoubleClickPricer) Encrypt(
-	seed string,
-	price float64,
-	isDebugMode bool) (string, error) {
-	var err error
+// Base 64 encrypted prices are emitted without padding, 38 characters long
+// for 28 bytes, while Decrypt accepts them padded or not.
+func (dc *DoubleClickPricer) Encrypt(seed string, price float64) (string, error) {
+	return dc.EncryptContext(context.Background(), seed, price)
+}
 
-	// Result
-	var (
-		iv        [16]byte
-		encoded   [8]byte
-		signature [4]byte
-	)
+// EncryptContext encrypts a clear price and a given seed.
+// If ctx is already done, its error is returned and nothing is encrypted.
+func (dc *DoubleClickPricer) EncryptContext(ctx context.Context, seed string, price float64) (encrypted string, err error) {
+	if dc.observer != nil {
+		defer dc.observeEncrypt(time.Now(), &err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if err := dc.validateSeed(seed); err != nil {
+		return "", err
+	}
+
+	data, err := dc.scalePrice(price)
+	if err != nil {
+		return "", err
+	}
+	if dc.isDebugMode == true {
+		dc.logger.Debugf("Micro price bytes : %v", data)
+	}
+
+	return dc.encrypt(dc.seedIV(seed), data)
+}
+
+// AppendEncrypt encrypts a clear price and a given seed, appending
+// the encoded encrypted price to dst and returning the extended buffer.
+// Reusing dst across calls avoids allocating the encrypted price string.
+func (dc *DoubleClickPricer) AppendEncrypt(dst []byte, seed string, price float64) (encrypted []byte, err error) {
+	if dc.observer != nil {
+		defer dc.observeEncrypt(time.Now(), &err)
+	}
+
+	if err := dc.validateSeed(seed); err != nil {
+		return dst, err
+	}
+	data, err := dc.scalePrice(price)
+	if err != nil {
+		return dst, err
+	}
+	if dc.isDebugMode == true {
+		dc.logger.Debugf("Micro price bytes : %v", data)
+	}
+
+	state, err := dc.acquireState()
+	if err != nil {
+		return dst, err
+	}
+	message, err := dc.encryptRawWith(state, dc.seedIV(seed), data)
+	dc.releaseState(state)
+	if err != nil {
+		return dst, err
+	}
+
+	return dc.appendEncoded(dst, message[:dc.messageLength()]), err
+}
+
+// EncryptRaw encrypts a clear price and a given seed.
+// Returned encrypted price is made of raw bytes, not base 64 encoded.
+func (dc *DoubleClickPricer) EncryptRaw(seed string, price float64) (encrypted []byte, err error) {
+	if dc.observer != nil {
+		defer dc.observeEncrypt(time.Now(), &err)
+	}
+
+	if err := dc.validateSeed(seed); err != nil {
+		return nil, err
+	}
+	data, err := dc.scalePrice(price)
+	if err != nil {
+		return nil, err
+	}
+	if dc.isDebugMode == true {
+		dc.logger.Debugf("Micro price bytes : %v", data)
+	}
+
+	state, err := dc.acquireState()
+	if err != nil {
+		return nil, err
+	}
+	message, err := dc.encryptRawWith(state, dc.seedIV(seed), data)
+	dc.releaseState(state)
+	if err != nil {
+		return nil, err
+	}
+
+	return message[:dc.messageLength()], err
+}
+
+// EncryptMicros encrypts a price already expressed in micros and a given seed.
+// Micros are encrypted as is, the scale factor is not applied, so that
+// no precision is lost in a float round-trip.
+func (dc *DoubleClickPricer) EncryptMicros(seed string, micros uint64) (encrypted string, err error) {
+	if dc.observer != nil {
+		defer dc.observeEncrypt(time.Now(), &err)
+	}
+
+	if err := dc.validateSeed(seed); err != nil {
+		return "", err
+	}
+	var data [8]byte
+	binary.BigEndian.PutUint64(data[:], micros)
 
-	defer glog.Flush()
+	return dc.encrypt(dc.seedIV(seed), data)
+}
 
-	data := helpers.ApplyScaleFactor(price, dc.scaleFactor, isDebugMode)
+// EncryptMicrosFloat encrypts a price already expressed in micros as a float
+// and a given seed. As with EncryptMicros the scale factor is not applied;
+// fractional micros are truncated. It is the same as Encrypt on a pricer
+// built with WithScaleFactor(1).
+func (dc *DoubleClickPricer) EncryptMicrosFloat(seed string, micros float64) (encrypted string, err error) {
+	if dc.observer != nil {
+		defer dc.observeEncrypt(time.Now(), &err)
+	}
 
-	// Create Initialization Vector from seed
-	sum := md5.Sum([]byte(seed))
-	copy(iv[:], sum[:])
-	if isDebugMode == true {
-		fmt.Println("Seed : ", seed)
-		fmt.Println("Initialization vector : ", iv)
+	if err := dc.validateSeed(seed); err != nil {
+		return "", err
+	}
+	data, err := helpers.ScalePrice(micros, 1)
+	if err != nil {
+		return "", err
+	}
+	if dc.isDebugMode == true {
+		dc.logger.Debugf("Micro price bytes : %v", data)
 	}
 
-	//pad = hmac(e_key, iv), first 8 bytes
-	pad := helpers.HmacSum(dc.encryptionKey, iv[:])[:8]
-	if isDebugMode == true {
-		fmt.Println("// pad = hmac(e_key, iv), first 8 bytes")
-		fmt.Println("Pad : ", pad)
+	return dc.encrypt(dc.seedIV(seed), data)
+}
+
+// EncryptWithIV encrypts a clear price using iv as Initialization Vector
+// instead of deriving it from a seed, e.g. to reproduce a known encrypted
+// price or to re-encrypt with the IV of an existing one.
+func (dc *DoubleClickPricer) EncryptWithIV(iv [16]byte, price float64) (encrypted string, err error) {
+	if dc.observer != nil {
+		defer dc.observeEncrypt(time.Now(), &err)
 	}
 
-	// enc_data = pad <xor> data
-	for i := range data {
-		encoded[i] = pad[i] ^ data[i]
+	data, err := dc.scalePrice(price)
+	if err != nil {
+		return "", err
 	}
-	if isDebugMode == true {
-		fmt.Println("// enc_data = pad <xor> data")
-		fmt.Println("Encoded price bytes : ", encoded)
+	if dc.isDebugMode == true {
+		dc.logger.Debugf("Micro price bytes : %v", data)
+		dc.logger.Debugf("Initialization vector : %v", iv)
 	}
 
-	// signature = hmac(i_key, data || iv), first 4 bytes
-	sig := helpers.HmacSum(dc.integrityKey, append(data[:], iv[:]...))[:4]
-	copy(signature[:], sig[:])
-	if isDebugMode == true {
-		fmt.Println("// signature = hmac(i_key, data || iv), first 4 bytes")
-		fmt.Println("Signature : ", sig)
+	return dc.encrypt(iv, data)
+}
+
+// EncryptRandom encrypts a clear price with an Initialization Vector made of
+// 16 bytes read from the pricer random source, crypto/rand.Reader unless set
+// with WithRandomSource, rather than derived from a seed. IVs are then as
+// unpredictable as the random source is.
+func (dc *DoubleClickPricer) EncryptRandom(price float64) (encrypted string, err error) {
+	if dc.observer != nil {
+		defer dc.observeEncrypt(time.Now(), &err)
+	}
+
+	var iv [16]byte
+	if _, err := io.ReadFull(dc.randomSource, iv[:]); err != nil {
+		return "", fmt.Errorf("reading random IV: %w", err)
+	}
+	data, err := dc.scalePrice(price)
+	if err != nil {
+		return "", err
+	}
+	if dc.isDebugMode == true {
+		dc.logger.Debugf("Micro price bytes : %v", data)
+		dc.logger.Debugf("Initialization vector : %v", iv)
+	}
+
+	return dc.encrypt(iv, data)
+}
+
+// validateSeed returns ErrInvalidSeed if seed is shorter than the pricer minimum seed length.
+func (dc *DoubleClickPricer) validateSeed(seed string) error {
+	if len(seed) >= dc.minSeedLength {
+		return nil
+	}
+	if seed == "" {
+		return fmt.Errorf("%w: seed is empty", ErrInvalidSeed)
+	}
+
+	return fmt.Errorf("%w: expected at least %d bytes, got %d", ErrInvalidSeed, dc.minSeedLength, len(seed))
+}
+
+// seedIV returns the Initialization Vector derived from seed with the pricer IV deriver.
+func (dc *DoubleClickPricer) seedIV(seed string) [16]byte {
+	iv := dc.ivDeriver(seed)
+	if dc.isDebugMode == true {
+		dc.logger.Debugf("Seed : %s", seed)
+		dc.logger.Debugf("Initialization vector : %v", iv)
+	}
+
+	return iv
+}
+
+// encrypt encrypts price bytes with a given Initialization Vector.
+func (dc *DoubleClickPricer) encrypt(iv [16]byte, data [8]byte) (string, error) {
+	state, err := dc.acquireState()
+	if err != nil {
+		return "", err
+	}
+	defer dc.releaseState(state)
+
+	return dc.encryptWith(state, iv, data)
+}
+
+// encryptWith encrypts price bytes with a given Initialization Vector using state.
+func (dc *DoubleClickPricer) encryptWith(state *cryptoState, iv [16]byte, data [8]byte) (string, error) {
+	message, err := dc.encryptRawWith(state, iv, data)
+	if err != nil {
+		return "", err
 	}
 
 	// final_message = WebSafeBase64Encode( iv || enc_price || signature )
-	return strings.TrimRight(base64.URLEncoding.EncodeToString(append(append(iv[:], encoded[:]...), signature[:]...)), "="), err
+	state.encoded = dc.appendEncoded(state.encoded[:0], message[:dc.messageLength()])
+	return string(state.encoded), err
+}
+
+// encryptRawWith encrypts price bytes with a given Initialization Vector using state.
+// Only the first messageLength bytes of the returned message are used.
+func (dc *DoubleClickPricer) encryptRawWith(state *cryptoState, iv [16]byte, data [8]byte) ([core.MaxMessageLength]byte, error) {
+	sealed, err := dc.sealWith(state, iv, data)
+
+	// message = iv || enc_price || signature
+	return sealed.Message, err
+}
+
+// sealWith encrypts price bytes with a given Initialization Vector using state,
+// returning the message along with the elements it is made of.
+func (dc *DoubleClickPricer) sealWith(state *cryptoState, iv [16]byte, data [8]byte) (core.Sealed, error) {
+	sealed, err := state.core.Seal(iv, data)
+	if err != nil {
+		return core.Sealed{}, fmt.Errorf("%w: %w", ErrEncrypt, err)
+	}
+	if dc.isDebugMode == true {
+		dc.logger.Debugf("// pad = hmac(e_key, iv), first 8 bytes")
+		dc.logger.Debugf("Pad : %v", sealed.Pad)
+		dc.logger.Debugf("// enc_data = pad <xor> data")
+		dc.logger.Debugf("Encoded price bytes : %v", sealed.Encoded)
+		dc.logger.Debugf("// signature = hmac(i_key, data || iv), first 4 bytes")
+		// Copied so that sealed doesn't escape when debug mode is off.
+		dc.logger.Debugf("Signature : %v", append([]byte(nil), sealed.Signature[:dc.signatureLength]...))
+	}
+
+	return sealed, nil
+}
+
+// messageLength returns the length of encrypted price messages, once decoded.
+func (dc *DoubleClickPricer) messageLength() int {
+	if dc.isUnsigned {
+		return core.UnsignedMessageLength
+	}
+
+	return core.UnsignedMessageLength + dc.signatureLength
+}
+
+// CiphertextLen returns the length of encrypted prices once decoded, iv (16 bytes)
+// and price (8 bytes) followed by the signature unless unsigned, e.g. to allocate
+// exact size buffers for DecryptInto or EncryptRaw.
+func (dc *DoubleClickPricer) CiphertextLen() int {
+	return dc.messageLength()
+}
+
+// EncodedLen returns the length of encrypted prices as returned by Encrypt,
+// i.e. CiphertextLen bytes encoded as unpadded web safe base 64 or as hexa.
+func (dc *DoubleClickPricer) EncodedLen() int {
+	if dc.priceEncoding == helpers.Hex {
+		return hex.EncodedLen(dc.messageLength())
+	}
+
+	return dc.rawBase64.EncodedLen(dc.messageLength())
+}
+
+// lengthError returns ErrInvalidCiphertextLength wrapped with the decoded length
+// and the expected one broken down by component, telling which of them is missing.
+func (dc *DoubleClickPricer) lengthError(decodedLength int, qualifier string) error {
+	components := fmt.Sprintf("IV %d + price %d", core.IVLength, core.PriceLength)
+	if !dc.isUnsigned {
+		components += fmt.Sprintf(" + sig %d", dc.signatureLength)
+	}
+
+	return fmt.Errorf("%w: decoded %d bytes, expected %s%d (%s)",
+		ErrInvalidCiphertextLength, decodedLength, qualifier, dc.messageLength(), components)
 }
 
 // Decrypt decrypts an ecrypted price.
-func (dc *DoubleClickPricer) Decrypt(encryptedPrice string, isDebugMode bool) (float64, error) {
-	var err error
+func (dc *DoubleClickPricer) Decrypt(encryptedPrice string) (float64, error) {
+	return dc.DecryptContext(context.Background(), encryptedPrice)
+}
+
+// DecryptContext decrypts an ecrypted price.
+// If ctx is already done, its error is returned and nothing is decrypted.
+func (dc *DoubleClickPricer) DecryptContext(ctx context.Context, encryptedPrice string) (price float64, err error) {
 	var errPrice float64
 
-	// Decode base64
-	encryptedPrice = helpers.AddBase64Padding(encryptedPrice)
-	decoded, err := base64.URLEncoding.DecodeString(encryptedPrice)
+	if err := ctx.Err(); err != nil {
+		return errPrice, err
+	}
+
+	priceMicro, err := dc.decrypt(encryptedPrice)
 	if err != nil {
 		return errPrice, err
 	}
 
-	if isDebugMode == true {
-		fmt.Println("Encrypted price : ", encryptedPrice)
-		fmt.Println("Base64 decoded price : ", decoded)
+	return dc.toPrice(priceMicro), err
+}
+
+// DecryptRaw decrypts an encrypted price made of raw bytes,
+// not base 64 encoded.
+func (dc *DoubleClickPricer) DecryptRaw(encryptedPrice []byte) (price float64, err error) {
+	var errPrice float64
+
+	state, err := dc.acquireDecryptState()
+	if err != nil {
+		return errPrice, err
+	}
+	priceMicro, err := dc.decryptRawWith(state, encryptedPrice)
+	dc.releaseState(state)
+	if err != nil {
+		return errPrice, err
 	}
 
-	// Get elements
-	var (
-		iv         [16]byte
-		p          [8]byte
-		signature  [4]byte
-		priceMicro [8]byte
-	)
+	return dc.toPrice(priceMicro), err
+}
 
-	defer glog.Flush()
+// DecryptRawAt decrypts an encrypted price made of raw bytes found at offset in buf,
+// e.g. in a binary bid response, without copying it. Only the 28 bytes (24 for
+// unsigned pricers) from offset are read, bytes after them being ignored even by
+// strict pricers. ErrInvalidCiphertextLength is returned if they don't fit in buf.
+func (dc *DoubleClickPricer) DecryptRawAt(buf []byte, offset int) (price float64, err error) {
+	var errPrice float64
 
-	copy(iv[:], decoded[0:16])
-	copy(p[:], decoded[16:24])
-	copy(signature[:], decoded[24:28])
+	messageLength := dc.messageLength()
+	if offset < 0 {
+		return errPrice, fmt.Errorf("%w: negative offset %d", ErrInvalidCiphertextLength, offset)
+	}
+	if offset > len(buf) {
+		return errPrice, fmt.Errorf("%w: offset %d past the end of %d bytes", ErrInvalidCiphertextLength, offset, len(buf))
+	}
+	if offset > len(buf)-messageLength {
+		return errPrice, fmt.Errorf("%w: expected %d bytes from offset %d, got %d", ErrInvalidCiphertextLength, messageLength, offset, len(buf)-offset)
+	}
 
-	// pad = hmac(e_key, iv)
-	pad := helpers.HmacSum(dc.encryptionKey, iv[:])[:8]
+	state, err := dc.acquireDecryptState()
+	if err != nil {
+		return errPrice, err
+	}
+	priceMicro, err := dc.decryptRawWith(state, buf[offset:offset+messageLength])
+	dc.releaseState(state)
+	if err != nil {
+		return errPrice, err
+	}
 
-	if isDebugMode == true {
-		fmt.Println("IV : ", hex.EncodeToString(iv[:]))
-		fmt.Println("Encoded price : ", hex.EncodeToString(p[:]))
-		fmt.Println("Signature : ", hex.EncodeToString(signature[:]))
-		fmt.Println("Pad : ", hex.EncodeToString(pad[:]))
+	return dc.toPrice(priceMicro), err
+}
+
+// DecryptInto decrypts an encrypted price as Decrypt does, decoding it into dst
+// instead of a buffer of the pricer, so that callers reusing dst, e.g. from a
+// sync.Pool, decrypt prices without allocating. dst must hold at least 28 bytes
+// (24 for unsigned pricers), and as many bytes as the decoded encrypted price,
+// ErrShortBuffer being returned otherwise. dst is overwritten.
+func (dc *DoubleClickPricer) DecryptInto(dst []byte, encryptedPrice string) (price float64, err error) {
+	var errPrice float64
+
+	required := dc.decodedLen(strings.TrimSpace(encryptedPrice))
+	if required < dc.messageLength() {
+		required = dc.messageLength()
+	}
+	if len(dst) < required {
+		return errPrice, fmt.Errorf("%w: expected at least %d bytes, got %d", ErrShortBuffer, required, len(dst))
 	}
 
-	// priceMicro = p <xor> pad
-	for i := range p {
-		priceMicro[i] = pad[i] ^ p[i]
+	state, err := dc.acquireDecryptState()
+	if err != nil {
+		return errPrice, err
+	}
+	defer dc.releaseState(state)
+	priceMicro, err := dc.decryptIntoWith(state, dst, encryptedPrice)
+	if err != nil {
+		return errPrice, err
+	}
+
+	return dc.toPrice(priceMicro), err
+}
+
+// scalePrice returns price bytes from a clear price, applying the scale factor,
+// once checked to be a valid price not above the pricer max price.
+func (dc *DoubleClickPricer) scalePrice(price float64) ([8]byte, error) {
+	if math.IsNaN(price) {
+		return [8]byte{}, fmt.Errorf("%w: price is NaN", ErrInvalidPrice)
+	}
+	if math.IsInf(price, 0) {
+		return [8]byte{}, fmt.Errorf("%w: price is infinite, got %g", ErrInvalidPrice, price)
+	}
+	if price < 0 && !dc.allowNegativePrices {
+		return [8]byte{}, fmt.Errorf("%w: price should be positive, got %g", ErrInvalidPrice, price)
+	}
+	if dc.maxPrice != nil && price > *dc.maxPrice {
+		return [8]byte{}, fmt.Errorf("%w: %g, expected at most %g", ErrPriceAboveMax, price, *dc.maxPrice)
+	}
+
+	return dc.scale(price)
+}
+
+// scale returns price bytes from a clear price, applying the scale factor only.
+// If negative prices are allowed, micros are encoded as a signed 64 bits integer.
+func (dc *DoubleClickPricer) scale(price float64) ([8]byte, error) {
+	if !dc.allowNegativePrices {
+		return dc.scaleUnsigned(price)
 	}
 
-	// conf_sig = hmac(i_key, data || iv)
-	sig := helpers.HmacSum(dc.integrityKey, append(priceMicro[:], iv[:]...))[:4]
+	data, err := dc.scaleUnsigned(math.Abs(price))
+	if err != nil {
+		return data, err
+	}
+	micros := binary.BigEndian.Uint64(data[:])
+	if micros > math.MaxInt64 && !(price < 0 && micros == 1<<63) {
+		return [8]byte{}, fmt.Errorf("%w: %g doesn't fit on 8 bytes as signed micros", ErrPriceOverflow, price)
+	}
+	if price < 0 {
+		// Two's complement of micros.
+		binary.BigEndian.PutUint64(data[:], -micros)
+	}
+
+	return data, nil
+}
 
-	// success = (conf_sig == sig)
-	for i := range sig {
-		if sig[i] != signature[i] {
-			return errPrice, errors.New("Failed to decrypt")
+// scaleUnsigned returns price bytes from a positive clear price, applying the scale factor only.
+func (dc *DoubleClickPricer) scaleUnsigned(price float64) ([8]byte, error) {
+	if dc.integerScale != 0 {
+		return helpers.ScalePriceInteger(price, dc.integerScale)
+	}
+
+	return helpers.ScalePrice(price, dc.scaleFactor)
+}
+
+// rawPrice returns the clear price from price bytes, applying the scale factor only.
+func (dc *DoubleClickPricer) rawPrice(priceMicro [8]byte) float64 {
+	micros := binary.BigEndian.Uint64(priceMicro[:])
+	if dc.allowNegativePrices && int64(micros) < 0 {
+		return -helpers.MicrosToPrice(-micros, dc.scaleFactor)
+	}
+
+	return helpers.MicrosToPrice(micros, dc.scaleFactor)
+}
+
+// toPrice returns the clear price from price bytes, applying the scale factor
+// and then rounding and clamping policies.
+func (dc *DoubleClickPricer) toPrice(priceMicro [8]byte) float64 {
+	price := helpers.RoundPrice(dc.rawPrice(priceMicro), dc.roundingMode, dc.roundingDecimals)
+	if dc.priceFloor != nil && price < *dc.priceFloor {
+		price = *dc.priceFloor
+	}
+	if dc.priceCeiling != nil && price > *dc.priceCeiling {
+		price = *dc.priceCeiling
+	}
+
+	return price
+}
+
+// DecryptMicros decrypts an encrypted price and returns it in micros.
+// The scale factor is not applied, so that no precision is lost
+// in a float round-trip. Since the integrity signature covers micros, not
+// prices, micros of prices whose scale factor is unknown can be converted
+// under several scale factors with helpers.MicrosToPrice, without decrypting again.
+func (dc *DoubleClickPricer) DecryptMicros(encryptedPrice string) (micros uint64, err error) {
+	var errMicros uint64
+
+	priceMicro, err := dc.decrypt(encryptedPrice)
+	if err != nil {
+		return errMicros, err
+	}
+
+	return binary.BigEndian.Uint64(priceMicro[:]), err
+}
+
+// decrypt decrypts an encrypted price and returns the price bytes.
+func (dc *DoubleClickPricer) decrypt(encryptedPrice string) ([8]byte, error) {
+	state, err := dc.acquireDecryptState()
+	if err != nil {
+		return [8]byte{}, err
+	}
+	defer dc.releaseState(state)
+
+	return dc.decryptWith(state, encryptedPrice)
+}
+
+// decryptWith decrypts an encrypted price using state and returns the price bytes,
+// from the decrypt cache if it is enabled and holds them.
+func (dc *DoubleClickPricer) decryptWith(state *cryptoState, encryptedPrice string) ([8]byte, error) {
+	return dc.decryptIntoWith(state, nil, encryptedPrice)
+}
+
+// decryptIntoWith decrypts an encrypted price using state, decoding it into dst
+// unless dst is nil, and returns the price bytes, from the decrypt cache if it
+// is enabled and holds them. dst must hold at least decodedLen bytes.
+func (dc *DoubleClickPricer) decryptIntoWith(state *cryptoState, dst []byte, encryptedPrice string) ([8]byte, error) {
+	var errPrice [8]byte
+
+	cache := state.keys.cache
+	if cache != nil {
+		if entry, ok := cache.get(encryptedPrice); ok {
+			if entry.isZeroIV && dc.zeroIVPolicy == helpers.WarnZeroIV {
+				dc.logger.Debugf(ZeroIVWarning)
+			}
+			dc.observeOpen(state, nil)
+			return entry.priceMicro, nil
+		}
+	}
+
+	var decoded []byte
+	var err error
+	if dst == nil {
+		decoded, err = dc.decode(state, encryptedPrice)
+	} else if decoded, err = dc.decodeInto(dst, strings.TrimSpace(encryptedPrice)); err != nil {
+		dc.observeOpen(state, err)
+	}
+	if err != nil {
+		return errPrice, err
+	}
+
+	if dc.isDebugMode == true {
+		dc.logger.Debugf("Encrypted price : %s", encryptedPrice)
+		dc.logger.Debugf("Decoded price : %v", decoded)
+	}
+
+	priceMicro, err := dc.decryptRawWith(state, decoded)
+	// Encrypted prices with trailing bytes aren't cached, so that they can't bloat the cache.
+	if err == nil && cache != nil && len(decoded) == dc.messageLength() {
+		cache.add(encryptedPrice, priceMicro, *(*[core.IVLength]byte)(decoded) == [core.IVLength]byte{})
+	}
+
+	return priceMicro, err
+}
+
+// decryptRawWith decrypts an encrypted price made of raw bytes using state
+// and returns the price bytes.
+func (dc *DoubleClickPricer) decryptRawWith(state *cryptoState, decoded []byte) ([8]byte, error) {
+	var errPrice [8]byte
+
+	opened, checkErr, err := dc.openRawWith(state, decoded)
+	if err != nil {
+		return errPrice, err
+	}
+	if checkErr != nil {
+		return errPrice, checkErr
+	}
+
+	return opened.Price, err
+}
+
+// ZeroIVWarning is the line emitted through the pricer logger, whether debug mode
+// is enabled or not, when decrypting a price whose IV is all zeros with helpers.WarnZeroIV.
+const ZeroIVWarning = "Warning : encrypted price initialization vector is all zeros"
+
+// checkOpened returns an error if the integrity signature of an encrypted
+// price opened with keys doesn't match, if its IV is all zeros while rejected,
+// or if its price is out of range.
+func (dc *DoubleClickPricer) checkOpened(keys *pricerKeys, opened core.Opened) error {
+	if !opened.IsIntegrityValid && !dc.isUnsigned {
+		if dc.detectSwappedKeys && dc.keysAppearSwapped(keys, opened) {
+			return fmt.Errorf("%w: %w", ErrSignatureMismatch, ErrKeysSwapped)
+		}
+		// Signatures are only detailed in debug mode, to help chasing key mismatches.
+		if dc.isDebugMode == true {
+			return fmt.Errorf("%w: received signature %s, computed %s", ErrSignatureMismatch,
+				hex.EncodeToString(opened.Signature[:dc.signatureLength]), hex.EncodeToString(opened.ComputedSignature[:dc.signatureLength]))
+		}
+		return ErrSignatureMismatch
+	}
+	if dc.zeroIVPolicy != helpers.AllowZeroIV && opened.IV == [core.IVLength]byte{} {
+		if dc.zeroIVPolicy == helpers.RejectZeroIV {
+			return ErrZeroIV
+		}
+		dc.logger.Debugf(ZeroIVWarning)
+	}
+	if micros := binary.BigEndian.Uint64(opened.Price[:]); micros > dc.maxMicros && !(dc.allowNegativePrices && int64(micros) < 0) {
+		return fmt.Errorf("%w: %d micros, expected at most %d", ErrPriceOutOfRange, micros, dc.maxMicros)
+	}
+
+	return nil
+}
+
+// openRawWith opens an encrypted price made of raw bytes using state, recomputing
+// its price bytes and checking them with checkOpened. Every decryption goes through
+// openRawWith, which reports it to the pricer observer. checkErr, the error Decrypt
+// returns for an opened price, is returned apart from err, as methods such as Verify
+// don't treat signature mismatches as errors.
+func (dc *DoubleClickPricer) openRawWith(state *cryptoState, decoded []byte) (opened core.Opened, checkErr error, err error) {
+	opened, err = dc.openRaw(state, decoded)
+	if err != nil {
+		dc.observeOpen(state, err)
+		return core.Opened{}, nil, err
+	}
+	checkErr = dc.checkOpened(state.keys, opened)
+	dc.observeOpen(state, checkErr)
+
+	return opened, checkErr, nil
+}
+
+// openRaw opens an encrypted price made of raw bytes using state,
+// recomputing its price bytes and checking its integrity signature.
+func (dc *DoubleClickPricer) openRaw(state *cryptoState, decoded []byte) (core.Opened, error) {
+	var err error
+
+	// iv (16 bytes) || p (8 bytes) || signature (4 bytes), without signature if unsigned
+	messageLength := dc.messageLength()
+	if len(decoded) < messageLength {
+		return core.Opened{}, dc.lengthError(len(decoded), "")
+	}
+	if dc.isStrict && len(decoded) != messageLength {
+		return core.Opened{}, dc.lengthError(len(decoded), "exactly ")
+	}
+
+	var opened core.Opened
+	if dc.isUnsigned {
+		opened, err = state.core.OpenUnsigned((*[core.UnsignedMessageLength]byte)(decoded[:core.UnsignedMessageLength]))
+	} else {
+		opened, err = state.core.Open(decoded)
+	}
+	if err != nil {
+		return core.Opened{}, fmt.Errorf("%w: %w", ErrDecrypt, err)
+	}
+	if dc.isDebugMode == true {
+		dc.logger.Debugf("IV : %s", hex.EncodeToString(opened.IV[:]))
+		dc.logger.Debugf("Encoded price : %s", hex.EncodeToString(opened.Encoded[:]))
+		dc.logger.Debugf("Signature : %s", hex.EncodeToString(opened.Signature[:dc.signatureLength]))
+		if !dc.isUnsigned {
+			dc.logger.Debugf("Computed signature : %s", hex.EncodeToString(opened.ComputedSignature[:dc.signatureLength]))
 		}
+		dc.logger.Debugf("Pad : %s", hex.EncodeToString(opened.Pad[:]))
 	}
-	price := float64(binary.BigEndian.Uint64(priceMicro[:])) / dc.scaleFactor
 
-	return price, err
+	return opened, err
 }
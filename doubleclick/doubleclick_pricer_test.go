@@ -1,10 +1,19 @@
 package doubleclick
 
 import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/benjaminch/pricers"
 	"github.com/benjaminch/pricers/helpers"
 )
 
@@ -12,6 +21,15 @@ func buildNewDoubleClickPricer(encryptionKey string, integrityKey string, isBase
 	return NewDoubleClickPricer(encryptionKey, integrityKey, isBase64Keys, keyDecodingMode, scaleFactor, isDebugMode)
 }
 
+// recordingLogger keeps every debug line it receives.
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
 type priceTestCase struct {
 	encrypted   string
 	clear       float64
@@ -54,7 +72,7 @@ func TestDecryptGoogleOfficialExamples(t *testing.T) {
 		// Execute:
 		var result float64
 		var err error
-		result, err = pricer.Decrypt(encryptedPrice.encrypted, false)
+		result, err = pricer.Decrypt(encryptedPrice.encrypted)
 
 		// Verify:
 		assert.Nil(t, err, "Decryption failed. Error : %s", err)
@@ -62,6 +80,57 @@ func TestDecryptGoogleOfficialExamples(t *testing.T) {
 	}
 }
 
+func TestDecryptWithoutPricer(t *testing.T) {
+	// Setup:
+	pricer, err := buildNewDoubleClickPricer(
+		"ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU",
+		"vQo9-4KtlcXmPhWaYvc8asqYuiSVMiGUdZ1RLXfrK7U",
+		true, // Keys are base64
+		helpers.Utf8,
+		1000000,
+		false,
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	for _, encrypted := range []string{
+		"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg",
+		"ce131TRp7waIZI2qOiRr2DMm2sSIeGh_wIAwVQ",
+		"8WY0BgWbds1eEVNFkrXVIr1GU08iueKrP0wXfw",
+		tamperSignature("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", 0),
+	} {
+		// Execute:
+		price, err := Decrypt(
+			"ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU",
+			"vQo9-4KtlcXmPhWaYvc8asqYuiSVMiGUdZ1RLXfrK7U",
+			encrypted,
+			true, // Keys are base64
+			helpers.Utf8,
+			1000000,
+		)
+		expectedPrice, expectedErr := pricer.Decrypt(encrypted)
+
+		// Verify:
+		assert.Equal(t, expectedPrice, price)
+		assert.Equal(t, expectedErr, err)
+	}
+
+	// Execute:
+	price, err := Decrypt(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+	)
+	_, errKeys := Decrypt("", "", "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", false, helpers.Hexa, 1000000)
+
+	// Verify:
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.InDelta(t, 1.354, price, 0.000001)
+	assert.True(t, errors.Is(errKeys, ErrInvalidKey), "Unexpected error : %s", errKeys)
+}
+
 func TestDecryptWithHexaKeys(t *testing.T) {
 	// Create a pricer with:
 	// - HEX keys
@@ -96,7 +165,7 @@ func TestDecryptWithHexaKeys(t *testing.T) {
 		// Execute:
 		var result float64
 		var err error
-		result, err = pricer.Decrypt(encryptedPrice.encrypted, false)
+		result, err = pricer.Decrypt(encryptedPrice.encrypted)
 
 		// Verify:
 		assert.Nil(t, err, "Decryption failed. Error : %s", err)
@@ -133,7 +202,7 @@ func TestDecryptWithUtf8Keys(t *testing.T) {
 		// Execute:
 		var result float64
 		var err error
-		result, err = pricer.Decrypt(encryptedPrice.encrypted, false)
+		result, err = pricer.Decrypt(encryptedPrice.encrypted)
 
 		// Verify:
 		assert.Nil(t, err, "Decryption failed. Error : %s", err)
@@ -175,7 +244,7 @@ func TestDecryptWithScaleFactor(t *testing.T) {
 
 		// Execute:
 		var result float64
-		result, err = pricer.Decrypt(priceTestCase.encrypted, false)
+		result, err = pricer.Decrypt(priceTestCase.encrypted)
 
 		// Verify:
 		assert.Nil(t, err, "Decryption failed. Error : %s", err)
@@ -183,8 +252,103 @@ func TestDecryptWithScaleFactor(t *testing.T) {
 	}
 }
 
+func TestDecryptWithInvalidLength(t *testing.T) {
+	// Setup:
+	var pricer *DoubleClickPricer
+	var err error
+	pricer, err = buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	valid, _ := base64.URLEncoding.DecodeString(helpers.AddBase64Padding("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg"))
+
+	// Encrypted prices too short to be decrypted
+	var invalidPrices = []struct {
+		encrypted string
+		length    int
+	}{
+		{"", 0},
+		{base64.URLEncoding.EncodeToString(valid[:27]), 27},
+		{base64.URLEncoding.EncodeToString(valid[:16]), 16},
+	}
+
+	for _, invalidPrice := range invalidPrices {
+		// Execute:
+		_, err = pricer.Decrypt(invalidPrice.encrypted)
+
+		// Verify:
+		assert.EqualError(t, err, fmt.Sprintf("invalid encrypted price: decoded %d bytes, expected 28 (IV 16 + price 8 + sig 4)", invalidPrice.length))
+	}
+}
+
+func TestDecryptWithOversizedPayload(t *testing.T) {
+	// Extra trailing bytes are ignored
+
+	// Setup:
+	var pricer *DoubleClickPricer
+	var err error
+	pricer, err = buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	valid, _ := base64.URLEncoding.DecodeString(helpers.AddBase64Padding("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg"))
+	oversized := base64.URLEncoding.EncodeToString(append(valid, 0x01, 0x02, 0x03, 0x04))
+
+	// Execute:
+	var result float64
+	result, err = pricer.Decrypt(oversized)
+
+	// Verify:
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.InDelta(t, result, 1.354, 0.001, "Decryption failed. Should be : %f but was : %f", 1.354, result)
+}
+
 func TestDecryptWithDebug(t *testing.T) {
-	// TODO: To be implemented
+	for _, isDebugMode := range []bool{false, true} {
+		// Setup:
+		var pricer *DoubleClickPricer
+		var err error
+		logger := &recordingLogger{}
+		pricer, err = NewDoubleClickPricerWithLogger(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+			false, // Keys are not base64
+			helpers.Hexa,
+			1000000,
+			isDebugMode,
+			logger,
+		)
+
+		assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+		// Execute:
+		var result float64
+		logger.lines = nil
+		result, err = pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+
+		// Verify:
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+		assert.InDelta(t, result, 1.354, 0.001, "Decryption failed. Should be : %f but was : %f", 1.354, result)
+		if isDebugMode {
+			assert.Contains(t, logger.lines, "IV : 6a7086185240a5c7c1e9919cea68a776", "IV should be logged")
+		} else {
+			assert.Empty(t, logger.lines, "No debug lines should be emitted when debug mode is off")
+		}
+	}
 }
 
 func TestEncryptWithHexaKeys(t *testing.T) {
@@ -221,7 +385,7 @@ func TestEncryptWithHexaKeys(t *testing.T) {
 		// Execute:
 		var result string
 		var err error
-		result, err = pricer.Encrypt("", price.clear, false)
+		result, err = pricer.Encrypt("", price.clear)
 
 		// Verify:
 		assert.Nil(t, err, "Encryption failed. Error : %s", err)
@@ -263,7 +427,7 @@ func TestEncryptWithUtf8Keys(t *testing.T) {
 	for _, price := range pricesTestCase {
 		var result string
 		var err error
-		result, err = pricer.Encrypt("", price.clear, false)
+		result, err = pricer.Encrypt("", price.clear)
 		if err != nil {
 			t.Errorf("Encryption failed. Error : %s", err)
 		}
@@ -309,7 +473,7 @@ func TestEncryptWithScaleFactor(t *testing.T) {
 
 		// Execute:
 		var result string
-		result, err = pricer.Encrypt("", priceTestCase.clear, false)
+		result, err = pricer.Encrypt("", priceTestCase.clear)
 
 		// Verify:
 		assert.Nil(t, err, "Encryption failed. Error : %s", err)
@@ -317,8 +481,110 @@ func TestEncryptWithScaleFactor(t *testing.T) {
 	}
 }
 
+func TestEncryptWithPriceOverflow(t *testing.T) {
+	// Setup:
+	var pricer *DoubleClickPricer
+	var err error
+	pricer, err = buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1,
+		false,
+	)
+
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Largest representable price succeeds
+	largest := math.Nextafter(1<<64, 0)
+
+	// Execute:
+	encrypted, err := pricer.Encrypt("", largest)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	micros, err := pricer.DecryptMicros(encrypted)
+
+	// Verify:
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.Equal(t, uint64(largest), micros)
+
+	// One above it fails
+	// Execute:
+	encrypted, err = pricer.Encrypt("", 1<<64)
+
+	// Verify:
+	assert.True(t, errors.Is(err, ErrPriceOverflow), "Error should be ErrPriceOverflow but was : %v", err)
+	assert.Empty(t, encrypted)
+
+	// Execute:
+	raw, err := pricer.EncryptRaw("", 1<<64)
+
+	// Verify:
+	assert.True(t, errors.Is(err, ErrPriceOverflow), "Error should be ErrPriceOverflow but was : %v", err)
+	assert.Nil(t, raw)
+}
+
+func TestEncryptIsDeterministic(t *testing.T) {
+	// Encrypting the same price with the same seed twice
+	// should produce byte-identical results.
+
+	// Setup:
+	var pricer *DoubleClickPricer
+	var err error
+	pricer, err = buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	var seedsToTest = []string{"", "test", "azertyuiopmlkjhgfdsqwxcvbn"}
+
+	for _, seed := range seedsToTest {
+		// Execute:
+		first, err := pricer.Encrypt(seed, 1.354)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		second, err := pricer.Encrypt(seed, 1.354)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		decrypted, err := pricer.Decrypt(first)
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+
+		// Verify:
+		assert.Equal(t, first, second, "Encryption should be deterministic for seed : %s", seed)
+		assert.InDelta(t, 1.354, decrypted, 0.001, "Decryption failed. Should be : %f but was : %f", 1.354, decrypted)
+	}
+}
+
 func TestEncryptWithDebug(t *testing.T) {
-	// TODO : To be implemented
+	// Setup:
+	var pricer *DoubleClickPricer
+	var err error
+	logger := &recordingLogger{}
+	pricer, err = NewDoubleClickPricerWithLogger(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		true,
+		logger,
+	)
+
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	assert.Contains(t, logger.lines, "Keys decoding mode : hexa", "Keys decoding mode should be logged")
+
+	// Execute:
+	var result string
+	result, err = pricer.Encrypt("", 1)
+
+	// Verify:
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	assert.Equal(t, result, "1B2M2Y8AsgTpgAmY7PhCfgDo9mJGcRqedwjz2g", "Encryption failed. Should be : %s but was : %s", "1B2M2Y8AsgTpgAmY7PhCfgDo9mJGcRqedwjz2g", result)
+	assert.Contains(t, logger.lines, "Micro price bytes : [0 0 0 0 0 15 66 64]", "Micro price bytes should be logged")
 }
 
 func TestEncryptDecryptWithHexaKeys(t *testing.T) {
@@ -358,11 +624,11 @@ func TestEncryptDecryptWithHexaKeys(t *testing.T) {
 		var err error
 
 		// Encrypt
-		encrypted, err = pricer.Encrypt("", price.clear, false)
+		encrypted, err = pricer.Encrypt("", price.clear)
 		assert.Nil(t, err, "Encryption failed. Error : %s", err)
 
 		// Decrypt
-		decrypted, err = pricer.Decrypt(encrypted, false)
+		decrypted, err = pricer.Decrypt(encrypted)
 		assert.Nil(t, err, "EncryDecryptionption failed. Error : %s", err)
 
 		// Verify:
@@ -408,11 +674,11 @@ func TestEncryptDecryptWithUtf8Keys(t *testing.T) {
 		var err error
 
 		// Encrypt
-		encrypted, err = pricer.Encrypt("", price.clear, false)
+		encrypted, err = pricer.Encrypt("", price.clear)
 		assert.Nil(t, err, "Encryption failed. Error : %s", err)
 
 		// Decrypt
-		decrypted, err = pricer.Decrypt(encrypted, false)
+		decrypted, err = pricer.Decrypt(encrypted)
 		assert.Nil(t, err, "EncryDecryptionption failed. Error : %s", err)
 
 		// Verify:
@@ -464,11 +730,11 @@ func TestEncryptDecryptWithSeed(t *testing.T) {
 			var err error
 
 			// Encrypt
-			encrypted, err = pricer.Encrypt(seed, price.clear, false)
+			encrypted, err = pricer.Encrypt(seed, price.clear)
 			assert.Nil(t, err, "Encryption failed. Error : %s", err)
 
 			// Decrypt
-			decrypted, err = pricer.Decrypt(encrypted, false)
+			decrypted, err = pricer.Decrypt(encrypted)
 			assert.Nil(t, err, "EncryDecryptionption failed. Error : %s", err)
 
 			// Verify:
@@ -529,11 +795,11 @@ func TestEncryptDecryptWithScaleFactor(t *testing.T) {
 			var err error
 
 			// Encrypt
-			encrypted, err = pricer.Encrypt("", price.clear, false)
+			encrypted, err = pricer.Encrypt("", price.clear)
 			assert.Nil(t, err, "Encryption failed. Error : %s", err)
 
 			// Decrypt
-			decrypted, err = pricer.Decrypt(encrypted, false)
+			decrypted, err = pricer.Decrypt(encrypted)
 			assert.Nil(t, err, "EncryDecryptionption failed. Error : %s", err)
 
 			// Verify:
@@ -542,3 +808,537 @@ func TestEncryptDecryptWithScaleFactor(t *testing.T) {
 		}
 	}
 }
+
+func TestEncryptDecryptThroughPricerInterface(t *testing.T) {
+	// Setup:
+	var pricer pricers.Pricer
+	var err error
+	pricer, err = buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Execute:
+	var encrypted string
+	var decrypted float64
+	encrypted, err = pricer.Encrypt("", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	decrypted, err = pricer.Decrypt(encrypted)
+
+	// Verify:
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.Equal(t, "1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA", encrypted)
+	assert.InDelta(t, decrypted, 1.354, 0.001, "Decryption failed. Should be : %f but was : %f", 1.354, decrypted)
+}
+
+func TestEncryptDecryptContext(t *testing.T) {
+	// Setup:
+	var pricer *DoubleClickPricer
+	var err error
+	pricer, err = buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Execute:
+	var encrypted string
+	var decrypted float64
+	encrypted, err = pricer.EncryptContext(context.Background(), "", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	decrypted, err = pricer.DecryptContext(context.Background(), encrypted)
+
+	// Verify:
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.Equal(t, "1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA", encrypted)
+	assert.InDelta(t, decrypted, 1.354, 0.001, "Decryption failed. Should be : %f but was : %f", 1.354, decrypted)
+}
+
+func TestEncryptDecryptCanceledContext(t *testing.T) {
+	// Setup:
+	var pricer *DoubleClickPricer
+	var err error
+	pricer, err = buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Execute:
+	var encrypted string
+	var decrypted float64
+	encrypted, err = pricer.EncryptContext(ctx, "", 1.354)
+
+	// Verify:
+	assert.Equal(t, context.Canceled, err)
+	assert.Empty(t, encrypted)
+
+	// Execute:
+	decrypted, err = pricer.DecryptContext(ctx, "1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA")
+
+	// Verify:
+	assert.Equal(t, context.Canceled, err)
+	assert.Zero(t, decrypted)
+}
+
+func TestEncryptDecryptRaw(t *testing.T) {
+	// Setup:
+	var pricer *DoubleClickPricer
+	var err error
+	pricer, err = buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	var seedsToTest = []string{"", "test", "azertyuiopmlkjhgfdsqwxcvbn"}
+	var pricesToTest = []float64{0, 1, 1.354, 100, 1000}
+
+	for _, seed := range seedsToTest {
+		for _, price := range pricesToTest {
+			// Execute:
+			raw, err := pricer.EncryptRaw(seed, price)
+			assert.Nil(t, err, "Encryption failed. Error : %s", err)
+			encrypted, err := pricer.Encrypt(seed, price)
+			assert.Nil(t, err, "Encryption failed. Error : %s", err)
+			decryptedRaw, err := pricer.DecryptRaw(raw)
+			assert.Nil(t, err, "Decryption failed. Error : %s", err)
+			decrypted, err := pricer.Decrypt(base64.RawURLEncoding.EncodeToString(raw))
+			assert.Nil(t, err, "Decryption failed. Error : %s", err)
+
+			// Verify:
+			// Raw and string APIs should agree
+			assert.Len(t, raw, 28)
+			assert.Equal(t, encrypted, base64.RawURLEncoding.EncodeToString(raw))
+			assert.Equal(t, decrypted, decryptedRaw)
+			assert.InDelta(t, decryptedRaw, price, 0.001, "Decryption failed. Should be : %f but was : %f", price, decryptedRaw)
+		}
+	}
+
+	// Raw API validates length as well
+	_, err = pricer.DecryptRaw(make([]byte, 27))
+	assert.EqualError(t, err, "invalid encrypted price: decoded 27 bytes, expected 28 (IV 16 + price 8 + sig 4)")
+}
+
+func TestDecryptRawAt(t *testing.T) {
+	// Setup:
+	pricer, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+		WithStrict(true),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	raw, err := base64.RawURLEncoding.DecodeString("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	assert.Nil(t, err)
+	// Encrypted price embedded within a larger binary response
+	buf := append(append([]byte("header"), raw...), []byte("trailer")...)
+	var offsetsTestCase = []struct {
+		buf    []byte
+		offset int
+	}{
+		{raw, 0},
+		{buf, 6},
+		{buf[:6+28], 6},
+	}
+
+	for _, tt := range offsetsTestCase {
+		// Execute:
+		price, err := pricer.DecryptRawAt(tt.buf, tt.offset)
+
+		// Verify:
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+		assert.InDelta(t, 1.354, price, 0.000001)
+	}
+}
+
+func TestDecryptRawAtOutOfRange(t *testing.T) {
+	// Setup:
+	pricer, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	buf := make([]byte, 40)
+	var offsetsTestCase = []struct {
+		offset       int
+		errorMessage string
+	}{
+		{-1, "invalid encrypted price: negative offset -1"},
+		{13, "invalid encrypted price: expected 28 bytes from offset 13, got 27"},
+		{40, "invalid encrypted price: expected 28 bytes from offset 40, got 0"},
+		{41, "invalid encrypted price: offset 41 past the end of 40 bytes"},
+	}
+
+	for _, tt := range offsetsTestCase {
+		// Execute:
+		price, err := pricer.DecryptRawAt(buf, tt.offset)
+
+		// Verify:
+		assert.Equal(t, float64(0), price)
+		assert.True(t, errors.Is(err, ErrInvalidCiphertextLength), "Unexpected error : %s", err)
+		assert.EqualError(t, err, tt.errorMessage)
+	}
+}
+
+func TestEncryptDecryptMicros(t *testing.T) {
+	// Setup:
+	var pricer *DoubleClickPricer
+	var err error
+	pricer, err = buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1,
+		false,
+	)
+
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Micros around 2^53, from where float64 can't represent every integer
+	var microsToTest = []uint64{0, 1354000, 1<<53 - 1, 1<<53 + 1, 1<<53 + 3, 1<<64 - 1}
+
+	for _, micros := range microsToTest {
+		// Execute:
+		var encrypted string
+		var decrypted uint64
+		encrypted, err = pricer.EncryptMicros("", micros)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		decrypted, err = pricer.DecryptMicros(encrypted)
+
+		// Verify:
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+		assert.Equal(t, micros, decrypted, "Decryption failed. Should be : %d but was : %d", micros, decrypted)
+	}
+
+	// Float path loses precision above 2^53
+	var micros uint64 = 1<<53 + 1
+	encrypted, err := pricer.EncryptMicros("", micros)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	decrypted, err := pricer.Decrypt(encrypted)
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.NotEqual(t, micros, uint64(decrypted), "Float path should lose precision for : %d", micros)
+
+	// Micros and float paths encrypt to the same bytes
+	fromFloat, err := pricer.Encrypt("", 1354000)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	fromMicros, err := pricer.EncryptMicros("", 1354000)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	assert.Equal(t, fromFloat, fromMicros)
+}
+
+func TestEncryptMicrosFloat(t *testing.T) {
+	// Setup:
+	pricer, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	microsPricer, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+		WithScaleFactor(1),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	var microsToTest = []float64{0, 1354000, 1354000.9, 1 << 53}
+
+	for _, micros := range microsToTest {
+		// Execute:
+		encrypted, err := pricer.EncryptMicrosFloat("seed", micros)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		scaledOnce, err := microsPricer.Encrypt("seed", micros)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		decrypted, err := microsPricer.Decrypt(encrypted)
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+		decryptedMicros, err := pricer.DecryptMicros(encrypted)
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+
+		// Verify:
+		// Micros are never scaled twice, and fractional micros are truncated
+		assert.Equal(t, encrypted, scaledOnce)
+		assert.Equal(t, math.Trunc(micros), decrypted)
+		assert.Equal(t, uint64(micros), decryptedMicros)
+	}
+
+	// Negative micros can't be encrypted
+	_, err = pricer.EncryptMicrosFloat("seed", -1)
+	assert.NotNil(t, err)
+}
+
+func TestEncryptDecryptConcurrently(t *testing.T) {
+	// A single pricer is shared across many goroutines,
+	// each of them encrypting / decrypting its own price.
+	// Should be run with -race.
+
+	// Setup:
+	var pricer *DoubleClickPricer
+	var err error
+	pricer, err = buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	const roundTrips = 5000
+	var wg sync.WaitGroup
+	wg.Add(roundTrips)
+
+	for i := 0; i < roundTrips; i++ {
+		go func(i int) {
+			defer wg.Done()
+
+			// Execute:
+			clear := float64(i) / 100
+			encrypted, err := pricer.Encrypt(fmt.Sprintf("seed-%d", i), clear)
+			assert.Nil(t, err, "Encryption failed. Error : %s", err)
+			decrypted, err := pricer.Decrypt(encrypted)
+
+			// Verify:
+			assert.Nil(t, err, "Decryption failed. Error : %s", err)
+			assert.InDelta(t, clear, decrypted, 0.001, "Decryption failed. Should be : %f but was : %f", clear, decrypted)
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+// tamperSignature flips one byte of the integrity signature of an encrypted
+// price, keeping the payload length unchanged.
+func tamperSignature(encrypted string, index int) string {
+	decoded, _ := base64.URLEncoding.DecodeString(helpers.AddBase64Padding(encrypted))
+	decoded[24+index] ^= 0xff
+	return base64.URLEncoding.EncodeToString(decoded)
+}
+
+func TestDecryptWithTamperedSignature(t *testing.T) {
+	// Setup:
+	var pricer *DoubleClickPricer
+	var err error
+	pricer, err = buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Every single byte of the signature is tampered in turn,
+	// the payload length stays the same
+	for i := 0; i < 4; i++ {
+		// Execute:
+		_, err = pricer.Decrypt(tamperSignature("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", i))
+
+		// Verify:
+		assert.EqualError(t, err, "Failed to decrypt", "Decryption should fail when signature byte %d is tampered", i)
+	}
+}
+
+func BenchmarkDecryptSignatureMismatch(b *testing.B) {
+	// Comparison cost should not depend on the position
+	// of the first differing signature byte.
+	pricer, err := buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+	if err != nil {
+		b.Fatal("Error creating new Pricer : ", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		tampered := tamperSignature("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", i)
+		b.Run(fmt.Sprintf("FirstDiffAtByte%d", i), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				pricer.Decrypt(tampered)
+			}
+		})
+	}
+}
+
+func TestEncryptWithIV(t *testing.T) {
+	// Setup:
+	pricer, err := buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	var ivsTestCase = []struct {
+		iv        string
+		encrypted string
+	}{
+		// md5("")
+		{"d41d8cd98f00b204e9800998ecf8427e", "1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA"},
+		// IV extracted from a known encrypted price, re-encrypting it
+		{"6a7086185240a5c7c1e9919cea68a776", "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg"},
+	}
+
+	for _, i := range ivsTestCase {
+		var iv [16]byte
+		decodedIV, err := hex.DecodeString(i.iv)
+		assert.Nil(t, err)
+		copy(iv[:], decodedIV)
+
+		// Execute:
+		encrypted, err := pricer.EncryptWithIV(iv, 1.354)
+
+		// Verify:
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		assert.Equal(t, i.encrypted, encrypted)
+	}
+}
+
+func TestEncryptWithIVMatchesEncrypt(t *testing.T) {
+	// Setup:
+	pricer, err := buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Execute:
+	fromSeed, err := pricer.Encrypt("seed", 2.5)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	fromIV, err := pricer.EncryptWithIV(md5.Sum([]byte("seed")), 2.5)
+
+	// Verify:
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	assert.Equal(t, fromSeed, fromIV)
+}
+
+func TestDecryptPriceOutOfRange(t *testing.T) {
+	var tests = []struct {
+		name      string
+		opts      []Option
+		micros    uint64
+		isInRange bool
+	}{
+		{name: "default, largest signed", micros: math.MaxInt64, isInRange: true},
+		{name: "default, top bit set", micros: 1 << 63, isInRange: false},
+		{name: "default, largest", micros: math.MaxUint64, isInRange: false},
+		{name: "custom max", opts: []Option{WithMaxMicros(1000000000)}, micros: 1000000000, isInRange: true},
+		{name: "above custom max", opts: []Option{WithMaxMicros(1000000000)}, micros: 1000000001, isInRange: false},
+		{name: "check disabled", opts: []Option{WithMaxMicros(NoMaxMicros)}, micros: math.MaxUint64, isInRange: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup:
+			pricer, err := NewPricer(append([]Option{WithKeys(
+				"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+				"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+			)}, tt.opts...)...)
+			assert.Nil(t, err, "Error creating new Pricer : ", err)
+			encrypted, err := pricer.EncryptMicros("", tt.micros)
+			assert.Nil(t, err, "Encryption failed. Error : %s", err)
+			raw, _ := base64.RawURLEncoding.DecodeString(encrypted)
+
+			// Execute:
+			micros, microsErr := pricer.DecryptMicros(encrypted)
+			_, priceErr := pricer.Decrypt(encrypted)
+			_, rawErr := pricer.DecryptRaw(raw)
+
+			// Verify:
+			if tt.isInRange {
+				assert.Nil(t, microsErr, "Unexpected error : %s", microsErr)
+				assert.Equal(t, tt.micros, micros)
+				assert.Nil(t, priceErr, "Unexpected error : %s", priceErr)
+				assert.Nil(t, rawErr, "Unexpected error : %s", rawErr)
+			} else {
+				assert.Equal(t, uint64(0), micros)
+				assert.True(t, errors.Is(microsErr, ErrPriceOutOfRange), "Unexpected error : %s", microsErr)
+				assert.True(t, errors.Is(priceErr, ErrPriceOutOfRange), "Unexpected error : %s", priceErr)
+				assert.True(t, errors.Is(rawErr, ErrPriceOutOfRange), "Unexpected error : %s", rawErr)
+			}
+		})
+	}
+}
+
+func TestDecryptMicrosUnderSeveralScaleFactors(t *testing.T) {
+	// Setup:
+	var scaledPricers []*DoubleClickPricer
+	for _, scaleFactor := range []float64{1000000, 100} {
+		pricer, err := NewPricer(
+			WithKeys(
+				"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+				"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+			),
+			WithScaleFactor(scaleFactor),
+		)
+		assert.Nil(t, err, "Error creating new Pricer : ", err)
+		scaledPricers = append(scaledPricers, pricer)
+	}
+	// Encrypted with a legacy 100 scale factor: 135 cents
+	encrypted, err := scaledPricers[1].Encrypt("", 1.35)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+
+	// Execute:
+	micros, err := scaledPricers[0].DecryptMicros(encrypted)
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	legacyMicros, err := scaledPricers[1].DecryptMicros(encrypted)
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	price, err := scaledPricers[0].Decrypt(encrypted)
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	legacyPrice, err := scaledPricers[1].Decrypt(encrypted)
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+
+	// Verify:
+	// Micros, and so signatures, don't depend on the scale factor
+	assert.Equal(t, uint64(135), micros)
+	assert.Equal(t, micros, legacyMicros)
+	assert.InDelta(t, 0.000135, helpers.MicrosToPrice(micros, 1000000), 0.0000001)
+	assert.InDelta(t, 1.35, helpers.MicrosToPrice(micros, 100), 0.0000001)
+	assert.Equal(t, helpers.MicrosToPrice(micros, 1000000), price)
+	assert.Equal(t, helpers.MicrosToPrice(micros, 100), legacyPrice)
+}
@@ -0,0 +1,106 @@
+package doubleclick
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+// appendEncoded appends the encrypted price message to dst, encoded
+// according to the pricer price encoding, and returns the extended buffer.
+// Base 64 encoded prices are not padded.
+func (dc *DoubleClickPricer) appendEncoded(dst []byte, message []byte) []byte {
+	var encodedLen int
+	if dc.priceEncoding == helpers.Hex {
+		encodedLen = hex.EncodedLen(len(message))
+	} else {
+		encodedLen = dc.rawBase64.EncodedLen(len(message))
+	}
+
+	start := len(dst)
+	if cap(dst)-start < encodedLen {
+		grown := make([]byte, start, start+encodedLen)
+		copy(grown, dst)
+		dst = grown
+	}
+	dst = dst[:start+encodedLen]
+
+	if dc.priceEncoding == helpers.Hex {
+		hex.Encode(dst[start:], message)
+	} else {
+		dc.rawBase64.Encode(dst[start:], message)
+	}
+
+	return dst
+}
+
+// decode decodes an encrypted price string according to the pricer
+// price encoding. Returned bytes are backed by state buffer.
+// Hexa encoded prices are accepted either lower or upper case, and spaces
+// around encrypted prices, e.g. copied from logs, are ignored.
+func (dc *DoubleClickPricer) decode(state *cryptoState, encryptedPrice string) ([]byte, error) {
+	encryptedPrice = strings.TrimSpace(encryptedPrice)
+	state.grow(dc.decodedLen(encryptedPrice))
+
+	decoded, err := dc.decodeInto(state.decoded, encryptedPrice)
+	if err != nil {
+		dc.observeOpen(state, err)
+	}
+
+	return decoded, err
+}
+
+// decodedLen returns the length of encryptedPrice once decoded,
+// the length of a buffer decodeInto needs at most.
+func (dc *DoubleClickPricer) decodedLen(encryptedPrice string) int {
+	if dc.priceEncoding == helpers.Hex {
+		return hex.DecodedLen(len(encryptedPrice))
+	}
+
+	return dc.rawBase64.DecodedLen(len(strings.TrimRight(encryptedPrice, "=")))
+}
+
+// decodeInto decodes an already trimmed encrypted price string into dst,
+// holding at least decodedLen bytes, and returns decoded bytes.
+// Encrypted prices are copied on the stack before being decoded, so
+// that usual ones are decoded without allocating.
+func (dc *DoubleClickPricer) decodeInto(dst []byte, encryptedPrice string) ([]byte, error) {
+	var buf [64]byte
+
+	if dc.priceEncoding == helpers.Hex {
+		n, err := hex.Decode(dst, append(buf[:0], encryptedPrice...))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformedHex, err)
+		}
+
+		return dst[:n], nil
+	}
+
+	n, err := dc.base64Encoding.Decode(dst, helpers.AppendBase64Padding(buf[:0], encryptedPrice))
+	if err != nil {
+		return nil, malformedBase64Error(encryptedPrice, err)
+	}
+
+	return dst[:n], nil
+}
+
+// malformedBase64Error returns ErrMalformedBase64 wrapping err, the error
+// decoding encryptedPrice, telling its length and where it is invalid.
+func malformedBase64Error(encryptedPrice string, err error) error {
+	var corrupt base64.CorruptInputError
+	if !errors.As(err, &corrupt) {
+		return fmt.Errorf("%w: %w", ErrMalformedBase64, err)
+	}
+
+	// Padding is only added at the end, so offsets within encryptedPrice hold.
+	unpadded := strings.TrimRight(encryptedPrice, "=")
+	if len(unpadded)%4 == 1 || int(corrupt) >= len(unpadded) {
+		return fmt.Errorf("%w: %d chars, truncated: %w", ErrMalformedBase64, len(encryptedPrice), err)
+	}
+
+	return fmt.Errorf("%w: %d chars, invalid %q at position %d: %w", ErrMalformedBase64, len(encryptedPrice), encryptedPrice[corrupt], int64(corrupt), err)
+}
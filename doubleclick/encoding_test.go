@@ -0,0 +1,181 @@
+package doubleclick
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func TestEncryptDecryptWithHexPriceEncoding(t *testing.T) {
+	// Setup:
+	var pricesTestCase = []priceTestCase{
+		newPriceTestCase("", 1.465, 1000000),
+		newPriceTestCase("", 0, 1000000),
+		newPriceTestCase("", 100, 1000000),
+		newPriceTestCase("", 1.45676, 1000000),
+		newPriceTestCase("", 13540, 100),
+		newPriceTestCase("", 1000, 10000),
+	}
+
+	for _, price := range pricesTestCase {
+		pricer := buildTestPricer(t, WithScaleFactor(price.scaleFactor), WithPriceEncoding(helpers.Hex))
+
+		// Execute:
+		encrypted, err := pricer.Encrypt("test", price.clear)
+		require.NoError(t, err, "Encryption failed. Error : %s", err)
+		decrypted, err := pricer.Decrypt(encrypted)
+
+		// Verify:
+		require.NoError(t, err, "Decryption failed. Error : %s", err)
+		assert.Len(t, encrypted, 56, "Hex encrypted price should be 56 characters long")
+		assert.InDelta(t, decrypted, price.clear, 0.001, "Decryption failed. Should be : %f but was : %f", price.clear, decrypted)
+	}
+}
+
+func TestEncryptWithHexPriceEncoding(t *testing.T) {
+	// Setup:
+	// Same encrypted price as "1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA" base 64 encoded
+	pricer := buildTestPricer(t, WithScaleFactor(1000000), WithPriceEncoding(helpers.Hex))
+
+	// Execute:
+	encrypted, err := pricer.Encrypt("", 1.354)
+
+	// Verify:
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+	assert.Equal(t, "d41d8cd98f00b204e9800998ecf8427e00e8f662466af1cebaefb648", encrypted)
+}
+
+func TestDecryptWithHexPriceEncodingCase(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithScaleFactor(1000000), WithPriceEncoding(helpers.Hex))
+	encrypted := "d41d8cd98f00b204e9800998ecf8427e00e8f662466af1cebaefb648"
+
+	for _, encryptedPrice := range []string{encrypted, strings.ToUpper(encrypted)} {
+		// Execute:
+		decrypted, err := pricer.Decrypt(encryptedPrice)
+
+		// Verify:
+		require.NoError(t, err, "Decryption failed. Error : %s", err)
+		assert.InDelta(t, decrypted, 1.354, 0.001, "Decryption failed. Should be : %f but was : %f", 1.354, decrypted)
+	}
+}
+
+func TestDecryptWithMalformedHex(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithScaleFactor(1000000), WithPriceEncoding(helpers.Hex))
+
+	// Execute:
+	_, err := pricer.Decrypt("1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA")
+
+	// Verify:
+	assert.True(t, errors.Is(err, ErrMalformedHex), "Error should be ErrMalformedHex but was : %v", err)
+}
+
+func TestDecryptWithSurroundingSpaces(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+	hexPricer := buildTestPricer(t, WithScaleFactor(1000000), WithPriceEncoding(helpers.Hex))
+
+	for _, encrypted := range []string{" anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg\n", "\t anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg==\r\n"} {
+		// Execute:
+		price, err := pricer.Decrypt(encrypted)
+
+		// Verify:
+		require.NoError(t, err, "Decryption failed. Error : %s", err)
+		assert.InDelta(t, 1.354, price, 0.000001)
+	}
+
+	encrypted, err := hexPricer.Encrypt("", 1.354)
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+
+	// Execute:
+	price, err := hexPricer.Decrypt("  " + encrypted + " \n")
+
+	// Verify:
+	require.NoError(t, err, "Decryption failed. Error : %s", err)
+	assert.InDelta(t, 1.354, price, 0.000001)
+}
+
+func TestDecryptWithMalformedBase64(t *testing.T) {
+	var tests = []struct {
+		name      string
+		encrypted string
+		err       string
+	}{
+		{
+			name:      "invalid character",
+			encrypted: "anCGGFJApcfB6ZGc6mind!pTrYXHY4ONo7lXpg",
+			err:       `malformed base64 encrypted price: 38 chars, invalid '!' at position 21: illegal base64 data at input byte 21`,
+		},
+		{
+			name:      "standard base64 character",
+			encrypted: "anCGGFJApcfB6ZGc6mind+pTrYXHY4ONo7lXpg",
+			err:       `malformed base64 encrypted price: 38 chars, invalid '+' at position 21: illegal base64 data at input byte 21`,
+		},
+		{
+			name:      "truncated",
+			encrypted: "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXp",
+			err:       `malformed base64 encrypted price: 37 chars, truncated: illegal base64 data at input byte 36`,
+		},
+		{
+			name:      "truncated with spaces",
+			encrypted: "  anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXp\n",
+			err:       `malformed base64 encrypted price: 37 chars, truncated: illegal base64 data at input byte 36`,
+		},
+		{
+			name:      "inner space",
+			encrypted: "anCGGFJApcfB6ZGc6mind hpTrYXHY4ONo7lXpg",
+			err:       `malformed base64 encrypted price: 39 chars, invalid ' ' at position 21: illegal base64 data at input byte 21`,
+		},
+	}
+
+	// Setup:
+	pricer := buildTestPricer(t)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute:
+			_, err := pricer.Decrypt(tt.encrypted)
+
+			// Verify:
+			var corrupt base64.CorruptInputError
+			assert.True(t, errors.Is(err, ErrMalformedBase64), "Unexpected error : %s", err)
+			assert.True(t, errors.As(err, &corrupt), "Unexpected error : %s", err)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestEncryptWithoutBase64Padding(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+	var pricesToTest = []float64{0, 1.354, 100, 9999.999999}
+
+	for _, price := range pricesToTest {
+		// Execute:
+		encrypted, err := pricer.Encrypt("seed", price)
+		require.NoError(t, err, "Encryption failed. Error : %s", err)
+		appended, err := pricer.AppendEncrypt(nil, "seed", price)
+		require.NoError(t, err, "Encryption failed. Error : %s", err)
+		decrypted, err := pricer.Decrypt(encrypted)
+		require.NoError(t, err, "Decryption failed. Error : %s", err)
+		// 28 bytes are 38 characters once encoded, 40 once padded
+		padded := helpers.AddBase64Padding(encrypted)
+		decryptedPadded, paddedErr := pricer.Decrypt(padded)
+
+		// Verify:
+		assert.Len(t, encrypted, 38)
+		assert.NotContains(t, encrypted, "=")
+		assert.Equal(t, encrypted, string(appended))
+		assert.Equal(t, encrypted+"==", padded)
+		assert.Nil(t, paddedErr, "Decryption failed. Error : %s", paddedErr)
+		assert.InDelta(t, price, decrypted, 0.000001)
+		assert.Equal(t, decrypted, decryptedPadded)
+	}
+}
@@ -0,0 +1,72 @@
+package doubleclick
+
+import (
+	"errors"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+var (
+	// ErrInvalidKey is returned when an encryption or integrity key cannot be decoded.
+	ErrInvalidKey = errors.New("invalid key")
+	// ErrIdenticalKeys is returned, wrapped in ErrInvalidKey, when encryption and
+	// integrity keys are identical while distinct keys are required.
+	ErrIdenticalKeys = errors.New("encryption and integrity keys are identical")
+	// ErrMalformedBase64 is returned when an encrypted price isn't valid web safe base 64.
+	ErrMalformedBase64 = helpers.ErrMalformedBase64
+	// ErrMalformedHex is returned when an encrypted price isn't a valid hexa string.
+	ErrMalformedHex = helpers.ErrMalformedHex
+	// ErrInvalidCiphertextLength is returned when a decoded encrypted price
+	// doesn't hold enough bytes to be decrypted.
+	ErrInvalidCiphertextLength = helpers.ErrInvalidCiphertextLength
+	// ErrSignatureMismatch is returned when the integrity signature
+	// of an encrypted price doesn't match. In debug mode, it is wrapped
+	// with both received and computed signatures, never with keys.
+	ErrSignatureMismatch = helpers.ErrSignatureMismatch
+	// ErrKeysSwapped is returned, wrapped in ErrSignatureMismatch, when an encrypted
+	// price signature matches once encryption and integrity keys are swapped,
+	// with WithSwappedKeysDetection.
+	ErrKeysSwapped = errors.New("keys appear swapped")
+	// ErrEncrypt is returned when a price can't be encrypted for an internal reason,
+	// such as HMAC sums too short to hold a pad or a signature.
+	ErrEncrypt = errors.New("internal encryption error")
+	// ErrDecrypt is returned when a price can't be decrypted for an internal reason,
+	// such as HMAC sums too short to hold a pad or a signature. Unlike
+	// ErrSignatureMismatch, it doesn't tell anything about the encrypted price.
+	ErrDecrypt = errors.New("internal decryption error")
+	// ErrInvalidScaleFactor is returned when a scale factor isn't positive and finite.
+	ErrInvalidScaleFactor = errors.New("invalid scale factor")
+	// ErrPriceOverflow is returned when a scaled price can't be represented on 8 bytes.
+	ErrPriceOverflow = helpers.ErrPriceOverflow
+	// ErrInvalidPrice is returned when a clear price to encrypt is NaN, infinite,
+	// or negative while negative prices aren't allowed.
+	ErrInvalidPrice = helpers.ErrInvalidPrice
+	// ErrPriceAboveMax is returned when a clear price to encrypt is above
+	// the highest price the pricer accepts.
+	ErrPriceAboveMax = helpers.ErrPriceAboveMax
+	// ErrNoMatchingKey is returned when no key pair of a KeyRing
+	// validates the integrity signature of an encrypted price.
+	ErrNoMatchingKey = errors.New("no key matches encrypted price")
+	// ErrZeroIV is returned when an encrypted price IV is all zeros while
+	// rejected with helpers.RejectZeroIV, hinting at an empty or missing seed upstream.
+	ErrZeroIV = helpers.ErrZeroIV
+	// ErrPriceOutOfRange is returned when a decrypted price is above
+	// the highest price the pricer accepts.
+	ErrPriceOutOfRange = helpers.ErrPriceOutOfRange
+	// ErrNoPlausibleScaleFactor is returned by ProbeScaleFactor when no candidate
+	// scale factor gives any sample price in the plausible range.
+	ErrNoPlausibleScaleFactor = errors.New("no plausible scale factor")
+	// ErrInvalidSeed is returned when a seed is shorter than the pricer minimum seed length.
+	ErrInvalidSeed = helpers.ErrInvalidSeed
+	// ErrShortBuffer is returned when a buffer provided to DecryptInto
+	// is too small to hold the decoded encrypted price.
+	ErrShortBuffer = errors.New("buffer too small")
+	// ErrMalformedMacro is returned when an encrypted price received through
+	// AuctionPriceMacro can't be URL unescaped.
+	ErrMalformedMacro = errors.New("malformed auction price macro")
+	// ErrCurrencyConversion is returned when a price can't be converted
+	// to the pricer's currency, wrapping the rate provider error if any.
+	ErrCurrencyConversion = errors.New("currency conversion failed")
+	// ErrClosed is returned when a pricer is used after Close.
+	ErrClosed = helpers.ErrClosed
+)
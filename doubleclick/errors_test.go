@@ -0,0 +1,213 @@
+package doubleclick
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func TestNewDoubleClickPricerWithInvalidKeys(t *testing.T) {
+	// Setup:
+	var keysTestCase = []struct {
+		encryptionKey string
+		integrityKey  string
+		errorMessage  string
+	}{
+		{"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c3913", "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5", "invalid key: encryption key: encoding/hex: odd length hex string"},
+		{"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", "zz0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5", "invalid key: integrity key: encoding/hex: invalid byte: U+007A 'z'"},
+	}
+
+	for _, keys := range keysTestCase {
+		// Execute:
+		_, err := buildNewDoubleClickPricer(keys.encryptionKey, keys.integrityKey, false, helpers.Hexa, 1000000, false)
+
+		// Verify:
+		assert.True(t, errors.Is(err, ErrInvalidKey), "Error should be ErrInvalidKey but was : %v", err)
+		assert.EqualError(t, err, keys.errorMessage)
+	}
+}
+
+func TestNewPricerWithInvalidKeyLength(t *testing.T) {
+	// Setup:
+	var keysTestCase = []struct {
+		encryptionKey string
+		integrityKey  string
+		keyLength     int
+		errorMessage  string
+	}{
+		{"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c391", "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5", DefaultKeyLength, "invalid key: encryption key: expected 32 bytes, got 31"},
+		{"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5aa", DefaultKeyLength, "invalid key: integrity key: expected 32 bytes, got 33"},
+		{"", "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5", DefaultKeyLength, "invalid key: encryption key: key is empty"},
+		{"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", "", AnyKeyLength, "invalid key: integrity key: key is empty"},
+		{"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5", 16, "invalid key: encryption key: expected 16 bytes, got 32"},
+	}
+
+	for _, keys := range keysTestCase {
+		// Execute:
+		_, err := NewPricer(
+			WithKeys(keys.encryptionKey, keys.integrityKey),
+			WithKeyLength(keys.keyLength),
+		)
+
+		// Verify:
+		assert.True(t, errors.Is(err, ErrInvalidKey), "Error should be ErrInvalidKey but was : %v", err)
+		assert.EqualError(t, err, keys.errorMessage)
+	}
+}
+
+func TestNewPricerWithEmptyLegacyKeys(t *testing.T) {
+	// Execute:
+	_, err := buildNewDoubleClickPricer("", "", false, helpers.Utf8, 1000000, false)
+
+	// Verify:
+	assert.EqualError(t, err, "invalid key: encryption key: key is empty")
+}
+
+func TestNewPricerWithBase64KeysInDebugMode(t *testing.T) {
+	// Setup:
+	logger := &recordingLogger{}
+
+	// Execute:
+	_, err := NewPricer(
+		WithKeys(
+			"ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU",
+			"vQo9-4KtlcXmPhWaYvc8asqYuiSVMiGUdZ1RLXfrK7U",
+		),
+		WithBase64Keys(true),
+		WithKeyDecodingMode(helpers.Utf8),
+		WithDebug(true),
+		WithLogger(logger),
+	)
+
+	// Verify:
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	encryptionKey, _ := hex.DecodeString(testEncryptionKey)
+	integrityKey, _ := hex.DecodeString(testIntegrityKey)
+	assert.Contains(t, logger.lines, "Encryption key fingerprint : "+helpers.KeyFingerprint(encryptionKey))
+	assert.Contains(t, logger.lines, "Integrity key fingerprint : "+helpers.KeyFingerprint(integrityKey))
+	for _, line := range logger.lines {
+		assert.NotContains(t, line, "ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU")
+		assert.NotContains(t, line, "vQo9-4KtlcXmPhWaYvc8asqYuiSVMiGUdZ1RLXfrK7U")
+		assert.NotContains(t, line, "101 47 131 173")
+		assert.NotContains(t, line, "189 10 61 251")
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	// Setup:
+	var pricer *DoubleClickPricer
+	var err error
+	pricer, err = buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	var errorsTestCase = []struct {
+		encrypted string
+		expected  error
+	}{
+		{"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lX!!", ErrMalformedBase64},
+		{"anCGGFJApcfB6ZGc6mindhpT", ErrInvalidCiphertextLength},
+		{tamperSignature("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", 0), ErrSignatureMismatch},
+	}
+
+	for _, errorTestCase := range errorsTestCase {
+		// Execute:
+		_, err = pricer.Decrypt(errorTestCase.encrypted)
+
+		// Verify:
+		assert.True(t, errors.Is(err, errorTestCase.expected), "Error should be %v but was : %v", errorTestCase.expected, err)
+	}
+
+	// Underlying base 64 error is kept
+	_, err = pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lX!!")
+	var corruptInputError base64.CorruptInputError
+	assert.True(t, errors.As(err, &corruptInputError), "Underlying base64 error should be wrapped but was : %v", err)
+}
+
+func TestDecryptLengthErrorDetails(t *testing.T) {
+	var tests = []struct {
+		name    string
+		opts    []Option
+		decoded int
+		err     string
+	}{
+		{name: "missing signature", decoded: 24, err: "invalid encrypted price: decoded 24 bytes, expected 28 (IV 16 + price 8 + sig 4)"},
+		{name: "missing price and signature", decoded: 20, err: "invalid encrypted price: decoded 20 bytes, expected 28 (IV 16 + price 8 + sig 4)"},
+		{name: "iv only", decoded: 16, err: "invalid encrypted price: decoded 16 bytes, expected 28 (IV 16 + price 8 + sig 4)"},
+		{name: "trailing bytes in strict mode", opts: []Option{WithStrict(true)}, decoded: 30, err: "invalid encrypted price: decoded 30 bytes, expected exactly 28 (IV 16 + price 8 + sig 4)"},
+		{name: "longer signatures", opts: []Option{WithSignatureLength(8)}, decoded: 28, err: "invalid encrypted price: decoded 28 bytes, expected 32 (IV 16 + price 8 + sig 8)"},
+		{name: "unsigned", opts: []Option{WithUnsigned(true)}, decoded: 20, err: "invalid encrypted price: decoded 20 bytes, expected 24 (IV 16 + price 8)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup:
+			pricer := buildTestPricer(t, tt.opts...)
+			decoded := make([]byte, tt.decoded)
+
+			// Execute:
+			_, err := pricer.Decrypt(base64.RawURLEncoding.EncodeToString(decoded))
+			_, rawErr := pricer.DecryptRaw(decoded)
+
+			// Verify:
+			assert.True(t, errors.Is(err, ErrInvalidCiphertextLength), "Unexpected error : %s", err)
+			assert.EqualError(t, err, tt.err)
+			assert.EqualError(t, rawErr, tt.err)
+		})
+	}
+}
+
+func TestDecryptSignatureMismatchDetails(t *testing.T) {
+	// Setup:
+	var testCases = []struct {
+		isDebugMode bool
+	}{
+		{true},
+		{false},
+	}
+	encrypted := "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg"
+	decoded, _ := base64.RawURLEncoding.DecodeString(encrypted)
+	computed := hex.EncodeToString(decoded[24:])
+	tampered := tamperSignature(encrypted, 0)
+	tamperedDecoded, _ := base64.URLEncoding.DecodeString(tampered)
+	received := hex.EncodeToString(tamperedDecoded[24:])
+
+	for _, testCase := range testCases {
+		logger := &recordingLogger{}
+		pricer, err := NewPricer(
+			WithKeys(
+				"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+				"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+			),
+			WithDebug(testCase.isDebugMode),
+			WithLogger(logger),
+		)
+		assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+		// Execute:
+		_, err = pricer.Decrypt(tampered)
+
+		// Verify:
+		assert.True(t, errors.Is(err, ErrSignatureMismatch), "Unexpected error : %s", err)
+		if testCase.isDebugMode {
+			assert.EqualError(t, err, "Failed to decrypt: received signature "+received+", computed "+computed)
+			assert.Contains(t, logger.lines, "Signature : "+received)
+			assert.Contains(t, logger.lines, "Computed signature : "+computed)
+		} else {
+			assert.EqualError(t, err, "Failed to decrypt")
+			assert.Empty(t, logger.lines)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package doubleclick_test
+
+import (
+	"fmt"
+
+	"github.com/benjaminch/pricers/doubleclick"
+	"github.com/benjaminch/pricers/helpers"
+)
+
+// Keys below are test keys, real keys are provided by the exchange
+// and should be kept out of source, see helpers.LoadKeysFromEnv.
+const (
+	exampleEncryptionKey = "652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135"
+	exampleIntegrityKey  = "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5"
+)
+
+func ExampleNewPricer() {
+	pricer, err := doubleclick.NewPricer(
+		doubleclick.WithKeys(exampleEncryptionKey, exampleIntegrityKey),
+		doubleclick.WithKeyDecodingMode(helpers.Hexa),
+		doubleclick.WithScaleFactor(doubleclick.DefaultScaleFactor),
+	)
+	if err != nil {
+		fmt.Println("Error creating new Pricer :", err)
+		return
+	}
+
+	encrypted, err := pricer.Encrypt("example-seed", 1.354)
+	if err != nil {
+		fmt.Println("Encryption failed :", err)
+		return
+	}
+	price, err := pricer.Decrypt(encrypted)
+	if err != nil {
+		fmt.Println("Decryption failed :", err)
+		return
+	}
+	fmt.Println(price)
+	// Output:
+	// 1.354
+}
+
+func ExampleDoubleClickPricer_Encrypt() {
+	pricer, err := doubleclick.NewDoubleClickPricer(
+		exampleEncryptionKey,
+		exampleIntegrityKey,
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+	if err != nil {
+		fmt.Println("Error creating new Pricer :", err)
+		return
+	}
+
+	// The seed, e.g. a request ID, should be unique per price.
+	// A fixed seed makes the encrypted price deterministic.
+	encrypted, err := pricer.Encrypt("example-seed", 1.354)
+	if err != nil {
+		fmt.Println("Encryption failed :", err)
+		return
+	}
+	fmt.Println(encrypted)
+	// Output:
+	// j95iza11osBRQVt3kNCUl2SNmxfrb1SShcEu5w
+}
+
+func ExampleDoubleClickPricer_Decrypt() {
+	pricer, err := doubleclick.NewDoubleClickPricer(
+		exampleEncryptionKey,
+		exampleIntegrityKey,
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+	if err != nil {
+		fmt.Println("Error creating new Pricer :", err)
+		return
+	}
+
+	price, err := pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	if err != nil {
+		fmt.Println("Decryption failed :", err)
+		return
+	}
+	fmt.Println(price)
+
+	// Tampered encrypted prices fail their integrity check.
+	_, err = pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpA")
+	fmt.Println(err)
+	// Output:
+	// 1.354
+	// Failed to decrypt
+}
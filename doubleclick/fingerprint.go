@@ -0,0 +1,14 @@
+package doubleclick
+
+import "github.com/benjaminch/pricers/helpers"
+
+// KeyFingerprint returns fingerprints of the pricer encryption and integrity keys,
+// as "encryption:<fingerprint> integrity:<fingerprint>", for operators to check
+// that deployments share the same keys without logging them.
+// Fingerprints are computed over decoded keys, see helpers.KeyFingerprint, so that
+// hexa and base 64 representations of the same keys have the same fingerprint.
+func (dc *DoubleClickPricer) KeyFingerprint() string {
+	keys := dc.keys.Load()
+
+	return "encryption:" + helpers.KeyFingerprint(keys.encryptionKey) + " integrity:" + helpers.KeyFingerprint(keys.integrityKey)
+}
@@ -0,0 +1,40 @@
+package doubleclick
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func TestKeyFingerprint(t *testing.T) {
+	// Setup:
+	encryptionKey := "652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135"
+	integrityKey := "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5"
+	encryptionKeyBytes, _ := hex.DecodeString(encryptionKey)
+	integrityKeyBytes, _ := hex.DecodeString(integrityKey)
+
+	hexPricer, err := NewPricer(WithKeys(encryptionKey, integrityKey))
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	base64Pricer, err := NewPricer(
+		WithKeys(base64.URLEncoding.EncodeToString(encryptionKeyBytes), base64.URLEncoding.EncodeToString(integrityKeyBytes)),
+		WithKeyDecodingMode(helpers.WebSafeBase64),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	swappedPricer, err := NewPricer(WithKeys(integrityKey, encryptionKey))
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Execute:
+	fingerprint := hexPricer.KeyFingerprint()
+
+	// Verify:
+	assert.Equal(t, "encryption:"+helpers.KeyFingerprint(encryptionKeyBytes)+" integrity:"+helpers.KeyFingerprint(integrityKeyBytes), fingerprint)
+	assert.Equal(t, fingerprint, base64Pricer.KeyFingerprint())
+	assert.Equal(t, fingerprint, hexPricer.KeyFingerprint(), "Fingerprint should be stable")
+	assert.NotEqual(t, fingerprint, swappedPricer.KeyFingerprint())
+	assert.NotContains(t, fingerprint, encryptionKey[:16])
+	assert.NotContains(t, fingerprint, integrityKey[:16])
+}
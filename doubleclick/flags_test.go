@@ -0,0 +1,51 @@
+package doubleclick_test
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/doubleclick"
+	"github.com/benjaminch/pricers/helpers"
+)
+
+// importerFlags are flags glog used to register on import, which collided
+// with the ones of binaries importing pricers.
+var importerFlags = []string{"v", "vmodule", "log_dir", "logtostderr", "alsologtostderr", "stderrthreshold", "log_backtrace_at"}
+
+// importerValues are registered on the command line as an importing binary would,
+// once doubleclick is initialized. The test binary panics if any of them was already.
+var importerValues = registerImporterFlags()
+
+func registerImporterFlags() []*string {
+	var values []*string
+	for _, name := range importerFlags {
+		values = append(values, flag.String(name, "", "importer own flag"))
+	}
+
+	return values
+}
+
+func TestImportRegistersNoFlags(t *testing.T) {
+	// Setup:
+	values := importerValues
+
+	// Execute:
+	err := flag.CommandLine.Parse([]string{"-v=2", "-log_dir=/tmp/importer"})
+	pricer, pricerErr := doubleclick.NewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		true,
+	)
+
+	// Verify:
+	assert.Nil(t, err, "Parsing flags failed. Error : %s", err)
+	assert.Equal(t, "2", *values[0])
+	assert.Equal(t, "/tmp/importer", *values[2])
+	assert.Nil(t, pricerErr, "Error creating new Pricer : ", pricerErr)
+	assert.NotNil(t, pricer)
+}
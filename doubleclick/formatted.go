@@ -0,0 +1,37 @@
+package doubleclick
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// DecryptFormatted decrypts an encrypted price and returns it as a decimal
+// string with decimals digits after the decimal point. The price is computed
+// exactly from its micros, so that it holds no float noise, the last digit being
+// rounded half away from zero. Like DecryptMicros, rounding and clamping
+// policies aren't applied. With WithNegativePrices, micros are read as a signed
+// 64 bits integer, so that negative prices are formatted with a leading minus sign.
+func (dc *DoubleClickPricer) DecryptFormatted(encryptedPrice string, decimals int) (string, error) {
+	if decimals < 0 {
+		return "", fmt.Errorf("decimals should be positive, got %d", decimals)
+	}
+
+	scaleFactor := new(big.Rat).SetInt64(dc.integerScale)
+	if dc.integerScale == 0 {
+		if scaleFactor.SetFloat64(dc.scaleFactor) == nil || scaleFactor.Sign() <= 0 {
+			return "", fmt.Errorf("scale factor %g can't be used to format prices", dc.scaleFactor)
+		}
+	}
+
+	micros, err := dc.DecryptMicros(encryptedPrice)
+	if err != nil {
+		return "", err
+	}
+
+	price := new(big.Rat).SetInt(new(big.Int).SetUint64(micros))
+	if dc.allowNegativePrices && int64(micros) < 0 {
+		price.SetInt64(int64(micros))
+	}
+
+	return price.Quo(price, scaleFactor).FloatString(decimals), err
+}
@@ -0,0 +1,131 @@
+package doubleclick
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecryptFormatted(t *testing.T) {
+	var tests = []struct {
+		name      string
+		opts      []Option
+		micros    uint64
+		decimals  int
+		formatted string
+	}{
+		{name: "micros", micros: 1354000, decimals: 6, formatted: "1.354000"},
+		{name: "cents", micros: 1354000, decimals: 2, formatted: "1.35"},
+		{name: "no decimals", micros: 1500000, decimals: 0, formatted: "2"},
+		{name: "zero", micros: 0, decimals: 2, formatted: "0.00"},
+		{name: "half is rounded away from zero", micros: 1355000, decimals: 2, formatted: "1.36"},
+		{name: "above 2^53", micros: 1<<53 + 1, decimals: 6, formatted: "9007199254.740993"},
+		{name: "large", micros: 123456789012345678, decimals: 6, formatted: "123456789012.345678"},
+		{name: "integer scale factor", opts: []Option{WithIntegerScaleFactor(1000)}, micros: 1005, decimals: 3, formatted: "1.005"},
+		{name: "float scale factor", opts: []Option{WithScaleFactor(3)}, micros: 1000, decimals: 4, formatted: "333.3333"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup:
+			pricer, err := NewPricer(append([]Option{WithKeys(
+				"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+				"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+			)}, tt.opts...)...)
+			assert.Nil(t, err, "Error creating new Pricer : ", err)
+			encrypted, err := pricer.EncryptMicros("", tt.micros)
+			assert.Nil(t, err, "Encryption failed. Error : %s", err)
+
+			// Execute:
+			formatted, err := pricer.DecryptFormatted(encrypted, tt.decimals)
+
+			// Verify:
+			assert.Nil(t, err, "Unexpected error : %s", err)
+			assert.Equal(t, tt.formatted, formatted)
+		})
+	}
+}
+
+func TestDecryptFormattedNegativePrices(t *testing.T) {
+	var tests = []struct {
+		name      string
+		price     float64
+		decimals  int
+		formatted string
+	}{
+		{name: "negative", price: -1.5, decimals: 2, formatted: "-1.50"},
+		{name: "half is rounded away from zero", price: -0.25, decimals: 1, formatted: "-0.3"},
+		{name: "positive", price: 1.354, decimals: 3, formatted: "1.354"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup:
+			pricer := buildTestPricer(t, WithNegativePrices(true))
+			encrypted, err := pricer.Encrypt("seed", tt.price)
+			require.NoError(t, err, "Encryption failed. Error : %s", err)
+
+			// Execute:
+			formatted, err := pricer.DecryptFormatted(encrypted, tt.decimals)
+
+			// Verify:
+			assert.Nil(t, err, "Unexpected error : %s", err)
+			assert.Equal(t, tt.formatted, formatted)
+		})
+	}
+}
+
+func TestDecryptFormattedAvoidsFloatNoise(t *testing.T) {
+	var tests = []struct {
+		micros   uint64
+		decimals int
+	}{
+		{micros: 1355000, decimals: 2},
+		{micros: 1<<53 + 1, decimals: 6},
+		{micros: 123456789012345678, decimals: 6},
+	}
+
+	// Setup:
+	pricer, err := NewPricer(WithKeys(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+	))
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	for _, tt := range tests {
+		encrypted, err := pricer.EncryptMicros("", tt.micros)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+
+		// Execute:
+		formatted, err := pricer.DecryptFormatted(encrypted, tt.decimals)
+		assert.Nil(t, err, "Unexpected error : %s", err)
+		price, err := pricer.Decrypt(encrypted)
+		assert.Nil(t, err, "Unexpected error : %s", err)
+
+		// Verify:
+		// The float price is off in its last formatted digit, the formatted one isn't.
+		assert.NotEqual(t, formatted, strconv.FormatFloat(price, 'f', tt.decimals, 64), "Float price %g should show noise", price)
+		parsed, err := strconv.ParseFloat(formatted, 64)
+		assert.Nil(t, err, "Unexpected error : %s", err)
+		assert.InDelta(t, price, parsed, 0.01)
+	}
+}
+
+func TestDecryptFormattedErrors(t *testing.T) {
+	// Setup:
+	pricer, err := NewPricer(WithKeys(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+	))
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Execute:
+	_, decimalsErr := pricer.DecryptFormatted("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", -1)
+	_, lengthErr := pricer.DecryptFormatted("anCGGFJApcfB6ZGc6mindhpTrYXHY4", 2)
+
+	// Verify:
+	assert.EqualError(t, decimalsErr, "decimals should be positive, got -1")
+	assert.EqualError(t, lengthErr, "invalid encrypted price: decoded 22 bytes, expected 28 (IV 16 + price 8 + sig 4)")
+}
@@ -0,0 +1,67 @@
+package doubleclick
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func buildFuzzPricer(f *testing.F) *DoubleClickPricer {
+	return buildTestPricer(f)
+}
+
+func FuzzDecrypt(f *testing.F) {
+	// Setup:
+	pricer := buildFuzzPricer(f)
+
+	// Valid encrypted prices
+	for _, encryptedPrice := range benchmarkEncryptedPrices {
+		f.Add(encryptedPrice)
+	}
+	f.Add("1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA")
+	// Truncated / malformed ones
+	f.Add("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lX")
+	f.Add("anCGGFJApcfB6ZGc")
+	f.Add("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg==")
+	f.Add("a")
+	f.Add("")
+	f.Add("====")
+
+	f.Fuzz(func(t *testing.T, encryptedPrice string) {
+		// Execute:
+		price, err := pricer.Decrypt(encryptedPrice)
+
+		// Verify:
+		if err != nil && price != 0 {
+			t.Errorf("Decrypt(%q) returned both price %f and error %v", encryptedPrice, price, err)
+		}
+	})
+}
+
+func FuzzDecryptBytes(f *testing.F) {
+	// Arbitrary bytes are fed as a well formed base 64 encrypted price,
+	// reaching decryption whatever their length.
+
+	// Setup:
+	pricer := buildFuzzPricer(f)
+
+	for _, encryptedPrice := range benchmarkEncryptedPrices {
+		decoded, _ := base64.RawURLEncoding.DecodeString(encryptedPrice)
+		f.Add(decoded)
+		f.Add(decoded[:20])
+	}
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		// Execute:
+		price, err := pricer.Decrypt(base64.RawURLEncoding.EncodeToString(raw))
+		rawPrice, rawErr := pricer.DecryptRaw(raw)
+
+		// Verify:
+		if err != nil && price != 0 {
+			t.Errorf("Decrypt(%x) returned both price %f and error %v", raw, price, err)
+		}
+		if (err == nil) != (rawErr == nil) || price != rawPrice {
+			t.Errorf("Decrypt(%x) and DecryptRaw disagree: %f, %v / %f, %v", raw, price, err, rawPrice, rawErr)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package doubleclick
+
+import (
+	"crypto/sha1"
+	"errors"
+	"hash"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benjaminch/pricers/helpers"
+	"github.com/benjaminch/pricers/internal/core"
+)
+
+func TestHashAlgorithmRoundTrip(t *testing.T) {
+	for _, algorithm := range []helpers.HashAlgorithm{helpers.SHA1, helpers.SHA256} {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			// Setup:
+			pricer := buildTestPricer(t, WithHashAlgorithm(algorithm))
+
+			// Execute:
+			encrypted, err := pricer.Encrypt("seed", 1.354)
+			require.NoError(t, err, "Encryption failed. Error : %s", err)
+			price, err := pricer.Decrypt(encrypted)
+
+			// Verify:
+			require.NoError(t, err, "Decryption failed. Error : %s", err)
+			assert.InDelta(t, 1.354, price, 0.000001)
+		})
+	}
+}
+
+func TestHashAlgorithmDefault(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithHashAlgorithm(helpers.SHA1))
+
+	// Execute:
+	encrypted, err := pricer.Encrypt("", 1.354)
+
+	// Verify:
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+	assert.Equal(t, "1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA", encrypted)
+}
+
+func TestHashAlgorithmMismatch(t *testing.T) {
+	// Setup:
+	sha1Pricer := buildTestPricer(t, WithHashAlgorithm(helpers.SHA1))
+	sha256Pricer := buildTestPricer(t, WithHashAlgorithm(helpers.SHA256))
+	encrypted, err := sha256Pricer.Encrypt("seed", 1.354)
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+
+	// Execute:
+	_, decryptErr := sha1Pricer.Decrypt(encrypted)
+
+	// Verify:
+	assert.Equal(t, ErrSignatureMismatch, decryptErr)
+}
+
+func TestNewPricerUnknownHashAlgorithm(t *testing.T) {
+	// Execute:
+	pricer, err := NewPricer(
+		WithKeys(testEncryptionKey, testIntegrityKey),
+		WithHashAlgorithm("md5"),
+	)
+
+	// Verify:
+	assert.Nil(t, pricer)
+	assert.EqualError(t, err, "unknown hash algorithm: md5")
+}
+
+// truncatedHash is a hash whose sums are cut to size bytes, as a misconfigured hash would be.
+type truncatedHash struct {
+	hash.Hash
+	size int
+}
+
+func (h truncatedHash) Size() int {
+	return h.size
+}
+
+func (h truncatedHash) Sum(b []byte) []byte {
+	return append(b, h.Hash.Sum(nil)[:h.size]...)
+}
+
+func TestMisSizedHash(t *testing.T) {
+	// Setup:
+	withTruncatedHash := func(c *config) {
+		c.newHash = func() hash.Hash { return truncatedHash{Hash: sha1.New(), size: 4} }
+	}
+	pricer := buildTestPricer(t, withTruncatedHash)
+
+	// Execute:
+	encrypted, errEncrypt := pricer.Encrypt("seed", 1.354)
+	dst, errAppend := pricer.AppendEncrypt(nil, "seed", 1.354)
+	price, errDecrypt := pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	_, errDetailed := pricer.DecryptDetailed("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	_, errVerify := pricer.Verify("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+
+	// Verify:
+	assert.True(t, errors.Is(errEncrypt, ErrEncrypt), "Unexpected error : %s", errEncrypt)
+	assert.True(t, errors.Is(errEncrypt, core.ErrShortSum), "Unexpected error : %s", errEncrypt)
+	assert.Equal(t, "", encrypted)
+	assert.True(t, errors.Is(errAppend, ErrEncrypt), "Unexpected error : %s", errAppend)
+	assert.Empty(t, dst)
+	assert.True(t, errors.Is(errDecrypt, ErrDecrypt), "Unexpected error : %s", errDecrypt)
+	assert.False(t, errors.Is(errDecrypt, ErrSignatureMismatch), "Unexpected error : %s", errDecrypt)
+	assert.Equal(t, float64(0), price)
+	assert.True(t, errors.Is(errDetailed, ErrDecrypt), "Unexpected error : %s", errDetailed)
+	assert.True(t, errors.Is(errVerify, ErrDecrypt), "Unexpected error : %s", errVerify)
+}
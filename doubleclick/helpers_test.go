@@ -0,0 +1,24 @@
+package doubleclick
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Keys most tests are run with, the hexa keys from specs examples.
+const (
+	testEncryptionKey = "652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135"
+	testIntegrityKey  = "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5"
+)
+
+// buildTestPricer returns a pricer keyed with the test keys and configured with
+// opts, which may override them. The test stops right away if it can't be built,
+// rather than panicking later on a nil pricer.
+func buildTestPricer(t testing.TB, opts ...Option) *DoubleClickPricer {
+	t.Helper()
+	pricer, err := NewPricer(append([]Option{WithKeys(testEncryptionKey, testIntegrityKey)}, opts...)...)
+	require.NoError(t, err, "Error creating new Pricer")
+
+	return pricer
+}
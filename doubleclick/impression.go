@@ -0,0 +1,47 @@
+package doubleclick
+
+import (
+	"fmt"
+	"time"
+)
+
+// ImpressionSeedPrefix is prepended to impression IDs to derive the seeds
+// used by EncryptForImpression, so that their IVs don't collide with the IVs
+// of the same IDs given as is to Encrypt.
+const ImpressionSeedPrefix = "imp:"
+
+// ImpressionSeed returns the seed EncryptForImpression derives from impID,
+// ImpressionSeedPrefix followed by impID. Its IV is then derived as for any seed,
+// md5(seed) unless set with WithIVDeriver.
+func ImpressionSeed(impID string) string {
+	return ImpressionSeedPrefix + impID
+}
+
+// EncryptForImpression encrypts a clear price with a seed derived from
+// an impression ID, see ImpressionSeed, rather than from a counter, so that
+// win notices can be correlated with the impressions they were encrypted for.
+// Encryption is deterministic: the same impression ID and price always give
+// the same encrypted price, and prices encrypted for the same impression ID
+// share an IV, leaking whether they are equal and the XOR of their micros.
+// Impression IDs should then be unique per price encrypted. Empty impression IDs,
+// or IDs shorter than the pricer minimum seed length, return ErrInvalidSeed.
+func (dc *DoubleClickPricer) EncryptForImpression(impID string, price float64) (string, error) {
+	if err := dc.validateImpressionID(impID); err != nil {
+		if dc.observer != nil {
+			dc.observeEncrypt(time.Now(), &err)
+		}
+		return "", err
+	}
+
+	return dc.Encrypt(ImpressionSeed(impID), price)
+}
+
+// validateImpressionID returns ErrInvalidSeed if impID is empty or shorter
+// than the pricer minimum seed length.
+func (dc *DoubleClickPricer) validateImpressionID(impID string) error {
+	if impID == "" {
+		return fmt.Errorf("%w: impression ID is empty", ErrInvalidSeed)
+	}
+
+	return dc.validateSeed(impID)
+}
@@ -0,0 +1,70 @@
+package doubleclick
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptForImpressionDeterministic(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+
+	// Execute:
+	first, err := pricer.EncryptForImpression("imp-1", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	second, err := pricer.EncryptForImpression("imp-1", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	other, err := pricer.EncryptForImpression("imp-2", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	firstDetails, err := pricer.DecryptDetailed(first)
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	otherDetails, err := pricer.DecryptDetailed(other)
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+
+	// Verify:
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, first, other)
+	assert.NotEqual(t, firstDetails.IV, otherDetails.IV)
+	assert.Equal(t, uint64(1354000), firstDetails.PriceMicros)
+	assert.Equal(t, uint64(1354000), otherDetails.PriceMicros)
+}
+
+func TestEncryptForImpressionSeed(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+
+	// Execute:
+	encrypted, err := pricer.EncryptForImpression("imp-1", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	derived, err := pricer.Encrypt("imp:imp-1", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	asIs, err := pricer.Encrypt("imp-1", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+
+	// Verify:
+	assert.Equal(t, "imp:imp-1", ImpressionSeed("imp-1"))
+	assert.Equal(t, derived, encrypted)
+	assert.NotEqual(t, asIs, encrypted)
+}
+
+func TestEncryptForImpressionInvalidID(t *testing.T) {
+	// Setup:
+	observer := &recordingObserver{}
+	pricer := buildTestPricer(t, WithMinSeedLength(8), WithObserver(observer))
+
+	// Execute:
+	_, errEmpty := pricer.EncryptForImpression("", 1.354)
+	_, errShort := pricer.EncryptForImpression("imp-1", 1.354)
+	_, err := pricer.EncryptForImpression("imp-0001", 1.354)
+
+	// Verify:
+	assert.True(t, errors.Is(errEmpty, ErrInvalidSeed), "Unexpected error : %s", errEmpty)
+	assert.EqualError(t, errEmpty, "invalid seed: impression ID is empty")
+	assert.True(t, errors.Is(errShort, ErrInvalidSeed), "Unexpected error : %s", errShort)
+	assert.EqualError(t, errShort, "invalid seed: expected at least 8 bytes, got 5")
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	assert.Equal(t, 2, observer.encryptFailures)
+	assert.Equal(t, 1, observer.encryptSuccesses)
+}
@@ -0,0 +1,63 @@
+package doubleclick
+
+import (
+	"crypto/md5"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// identityIV uses seeds, expected to be 16 bytes long, as IVs.
+func identityIV(seed string) [16]byte {
+	var iv [16]byte
+	copy(iv[:], seed)
+
+	return iv
+}
+
+func TestEncryptWithIVDeriver(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithIVDeriver(identityIV))
+	seed := "abc123def456ghi7"
+
+	// Execute:
+	encrypted, err := pricer.Encrypt(seed, 1.354)
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+	withIV, err := pricer.EncryptWithIV(identityIV(seed), 1.354)
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+	result, err := pricer.DecryptDetailed(encrypted)
+
+	// Verify:
+	require.NoError(t, err, "Decryption failed. Error : %s", err)
+	assert.Equal(t, seed, string(result.IV[:]))
+	assert.Equal(t, withIV, encrypted)
+	assert.InDelta(t, 1.354, result.Price, 0.000001)
+}
+
+func TestEncryptDefaultIVDeriver(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+
+	// Execute:
+	encrypted, err := pricer.Encrypt("seed", 1.354)
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+	result, err := pricer.DecryptDetailed(encrypted)
+
+	// Verify:
+	require.NoError(t, err, "Decryption failed. Error : %s", err)
+	assert.Equal(t, md5.Sum([]byte("seed")), result.IV)
+	assert.Equal(t, MD5IVDeriver("seed"), result.IV)
+}
+
+func TestNewPricerNilIVDeriver(t *testing.T) {
+	// Execute:
+	pricer, err := NewPricer(
+		WithKeys(testEncryptionKey, testIntegrityKey),
+		WithIVDeriver(nil),
+	)
+
+	// Verify:
+	assert.Nil(t, pricer)
+	assert.EqualError(t, err, "IV deriver is nil")
+}
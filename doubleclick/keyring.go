@@ -0,0 +1,49 @@
+package doubleclick
+
+import "errors"
+
+// KeyRing holds an ordered set of pricers, one per key pair, so that
+// encrypted prices can be decrypted during a key rotation window.
+// Key pairs should all be added before the KeyRing is used by several goroutines.
+type KeyRing struct {
+	entries []keyRingEntry
+}
+
+// keyRingEntry is a pricer identified by its key pair ID.
+type keyRingEntry struct {
+	keyID  string
+	pricer *DoubleClickPricer
+}
+
+// NewKeyRing returns an empty KeyRing.
+func NewKeyRing() *KeyRing {
+	return &KeyRing{}
+}
+
+// Add appends the pricer built with a key pair identified by keyID.
+// Key pairs are tried in the order they were added.
+func (kr *KeyRing) Add(keyID string, pricer *DoubleClickPricer) *KeyRing {
+	kr.entries = append(kr.entries, keyRingEntry{keyID: keyID, pricer: pricer})
+	return kr
+}
+
+// DecryptAny decrypts an encrypted price trying every key pair in order.
+// A price is only returned, along with the ID of the key pair it was
+// decrypted with, once its integrity signature validates.
+// ErrNoMatchingKey is returned when no key pair validates the signature.
+func (kr *KeyRing) DecryptAny(encryptedPrice string) (float64, string, error) {
+	var errPrice float64
+
+	for _, entry := range kr.entries {
+		price, err := entry.pricer.Decrypt(encryptedPrice)
+		if err == nil {
+			return price, entry.keyID, nil
+		}
+		// Malformed prices would fail the same way with any key pair
+		if !errors.Is(err, ErrSignatureMismatch) {
+			return errPrice, "", err
+		}
+	}
+
+	return errPrice, "", ErrNoMatchingKey
+}
@@ -0,0 +1,94 @@
+package doubleclick
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func buildKeyRing(t *testing.T) *KeyRing {
+	oldPricer, err := buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	newPricer, err := buildNewDoubleClickPricer(
+		"6356770B3C111C07F778AFD69F16643E9110090FD4C479D91181EED2523788F1",
+		"3588BF6D387E8AEAD4EEC66798255369AF47BFD48B056E8934CEFEF3609C469E",
+		false, // Keys are not base64
+		helpers.Utf8,
+		1000000,
+		false,
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	return NewKeyRing().Add("old", oldPricer).Add("new", newPricer)
+}
+
+func TestKeyRingDecryptAny(t *testing.T) {
+	// Setup:
+	keyRing := buildKeyRing(t)
+
+	var pricesTestCase = []struct {
+		encrypted string
+		clear     float64
+		keyID     string
+	}{
+		{"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", 1.354, "old"},
+		{"u7iq5XwQTNpAyThDrV5tuJXw-Y_IXQgkMA3RFA", 1.465, "new"},
+	}
+
+	for _, price := range pricesTestCase {
+		// Execute:
+		result, keyID, err := keyRing.DecryptAny(price.encrypted)
+
+		// Verify:
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+		assert.Equal(t, price.keyID, keyID)
+		assert.InDelta(t, result, price.clear, 0.001, "Decryption failed. Should be : %f but was : %f", price.clear, result)
+	}
+}
+
+func TestKeyRingDecryptAnyUnknownKey(t *testing.T) {
+	// Setup:
+	keyRing := buildKeyRing(t)
+	unknownPricer, err := buildNewDoubleClickPricer(
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	encrypted, err := unknownPricer.Encrypt("", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+
+	// Execute:
+	result, keyID, err := keyRing.DecryptAny(encrypted)
+
+	// Verify:
+	assert.Equal(t, ErrNoMatchingKey, err)
+	assert.Empty(t, keyID)
+	assert.Zero(t, result)
+}
+
+func TestKeyRingDecryptAnyMalformed(t *testing.T) {
+	// Setup:
+	keyRing := buildKeyRing(t)
+
+	// Execute:
+	_, keyID, err := keyRing.DecryptAny("anCGGFJApcfB6ZGc")
+
+	// Verify:
+	assert.True(t, errors.Is(err, ErrInvalidCiphertextLength), "Error should be ErrInvalidCiphertextLength but was : %v", err)
+	assert.Empty(t, keyID)
+}
@@ -0,0 +1,114 @@
+package doubleclick
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+// pricerKeys holds a pair of keys along with the decrypt cache of prices
+// decrypted with them. Keys are never modified once set, SetKeys swaps
+// them as a whole so that a single encryption / decryption always sees
+// a consistent pair.
+type pricerKeys struct {
+	encryptionKeyRaw string
+	integrityKeyRaw  string
+	encryptionKey    []byte
+	integrityKey     []byte
+	keyDecodingMode  helpers.KeyDecodingMode
+	cache            *decryptCache
+}
+
+// decodeKeys decodes and validates a pair of keys, expecting decoded keys
+// to be keyLength bytes long. With a positive cacheSize, returned keys hold
+// an empty decrypt cache.
+func decodeKeys(encryptionKey string, integrityKey string, isBase64Keys bool, keyDecodingMode helpers.KeyDecodingMode, keyLength int, cacheSize int) (*pricerKeys, error) {
+	encryptionKeyBytes, err := helpers.DecodeKey(encryptionKey, isBase64Keys, keyDecodingMode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: encryption key: %w", ErrInvalidKey, err)
+	}
+	integrityKeyBytes, err := helpers.DecodeKey(integrityKey, isBase64Keys, keyDecodingMode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: integrity key: %w", ErrInvalidKey, err)
+	}
+	keys, err := newKeys(encryptionKeyBytes, integrityKeyBytes, keyLength, cacheSize)
+	if err != nil {
+		return nil, err
+	}
+	keys.encryptionKeyRaw = encryptionKey
+	keys.integrityKeyRaw = integrityKey
+	keys.keyDecodingMode = keyDecodingMode
+
+	return keys, nil
+}
+
+// newKeys validates a pair of already decoded keys, expecting them to be
+// keyLength bytes long. Keys are copied, so that callers can't change them
+// afterwards. With a positive cacheSize, returned keys hold an empty decrypt cache.
+func newKeys(encryptionKey []byte, integrityKey []byte, keyLength int, cacheSize int) (*pricerKeys, error) {
+	if err := validateKeyLength(encryptionKey, keyLength); err != nil {
+		return nil, fmt.Errorf("%w: encryption key: %w", ErrInvalidKey, err)
+	}
+	if err := validateKeyLength(integrityKey, keyLength); err != nil {
+		return nil, fmt.Errorf("%w: integrity key: %w", ErrInvalidKey, err)
+	}
+
+	keys := &pricerKeys{
+		encryptionKey: bytes.Clone(encryptionKey),
+		integrityKey:  bytes.Clone(integrityKey),
+	}
+	if cacheSize > 0 {
+		keys.cache = newDecryptCache(cacheSize)
+	}
+
+	return keys, nil
+}
+
+// IdenticalKeysWarning is the line emitted through the pricer logger, whether
+// debug mode is enabled or not, when encryption and integrity keys are identical.
+const IdenticalKeysWarning = "Warning : encryption and integrity keys are identical"
+
+// checkDistinctKeys returns ErrIdenticalKeys if keys are byte identical and
+// requireDistinctKeys is set, or only warns through logger otherwise.
+func checkDistinctKeys(keys *pricerKeys, requireDistinctKeys bool, logger helpers.Logger) error {
+	if !bytes.Equal(keys.encryptionKey, keys.integrityKey) {
+		return nil
+	}
+	if requireDistinctKeys {
+		return fmt.Errorf("%w: %w", ErrInvalidKey, ErrIdenticalKeys)
+	}
+	logger.Debugf(IdenticalKeysWarning)
+
+	return nil
+}
+
+// SetKeys replaces the pricer keys, e.g. to rotate them on a long running server
+// without rebuilding the pricer other goroutines hold. New keys are decoded and
+// validated as NewPricer does, pricer keys being left untouched on error.
+// Keys are swapped atomically: encryptions and decryptions in flight complete
+// with the previous pair, later ones use the new pair. Prices cached with the
+// previous pair are dropped. ErrClosed is returned once the pricer is closed.
+func (dc *DoubleClickPricer) SetKeys(encryptionKey string, integrityKey string, isBase64Keys bool, keyDecodingMode helpers.KeyDecodingMode) error {
+	if dc.closed.Load() {
+		return ErrClosed
+	}
+	keys, err := decodeKeys(encryptionKey, integrityKey, isBase64Keys, keyDecodingMode, dc.keyLength, dc.decryptCacheSize)
+	if err != nil {
+		return err
+	}
+	if err = checkDistinctKeys(keys, dc.requireDistinctKeys, dc.logger); err != nil {
+		return err
+	}
+
+	dc.keys.Store(keys)
+	if dc.closed.Load() {
+		// Close ran since the check above, its cache must not outlive it.
+		dc.dropCache()
+	}
+	if dc.isDebugMode == true {
+		dc.logger.Debugf("Keys rotated : %s", dc.KeyFingerprint())
+	}
+
+	return nil
+}
@@ -0,0 +1,356 @@
+package doubleclick
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+const (
+	rotatedEncryptionKey = "skU7Ax_NL5pPAFyKdkfZjZz2-VhIN8bjj1rVFOaJ_5o="
+	rotatedIntegrityKey  = "arO23ykdNqUQ5LEoQ0FVmPkBd7xB5CO89PDZlSjpFxo="
+)
+
+func buildRotatedPricer(t testing.TB) *DoubleClickPricer {
+	pricer, err := NewPricer(
+		WithKeys(rotatedEncryptionKey, rotatedIntegrityKey),
+		WithKeyDecodingMode(helpers.WebSafeBase64),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	return pricer
+}
+
+func TestSetKeys(t *testing.T) {
+	// Setup:
+	pricer, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+		WithDecryptCache(8),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	rotated := buildRotatedPricer(t)
+	// Cached with previous keys
+	_, err = pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+
+	// Execute:
+	err = pricer.SetKeys(rotatedEncryptionKey, rotatedIntegrityKey, false, helpers.WebSafeBase64)
+
+	// Verify:
+	assert.Nil(t, err, "Setting keys failed. Error : %s", err)
+	assert.Equal(t, rotated.KeyFingerprint(), pricer.KeyFingerprint())
+	encrypted, err := pricer.Encrypt("seed", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	expected, err := rotated.Encrypt("seed", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	assert.Equal(t, expected, encrypted)
+	// Prices encrypted with previous keys aren't decrypted from cache anymore
+	_, err = pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	assert.Equal(t, ErrSignatureMismatch, err)
+}
+
+func TestSetKeysInvalid(t *testing.T) {
+	// Setup:
+	pricer := buildRotatedPricer(t)
+	fingerprint := pricer.KeyFingerprint()
+	var keysTestCase = []struct {
+		encryptionKey string
+		integrityKey  string
+		errorMessage  string
+	}{
+		{"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c3913", "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5", "invalid key: encryption key: encoding/hex: odd length hex string"},
+		{"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", "bd0a3dfb", "invalid key: integrity key: expected 32 bytes, got 4"},
+	}
+
+	for _, keys := range keysTestCase {
+		// Execute:
+		err := pricer.SetKeys(keys.encryptionKey, keys.integrityKey, false, helpers.Hexa)
+
+		// Verify:
+		assert.True(t, errors.Is(err, ErrInvalidKey), "Unexpected error : %s", err)
+		assert.EqualError(t, err, keys.errorMessage)
+		assert.Equal(t, fingerprint, pricer.KeyFingerprint(), "Keys shouldn't be replaced on error")
+	}
+}
+
+func TestSetKeysConcurrently(t *testing.T) {
+	// Keys are rotated back and forth while many goroutines encrypt and
+	// decrypt with the same pricer. Every encrypted price has to be the one
+	// of either key pair, and every decryption either succeeds with the right
+	// price or fails the signature check, never returning a corrupt price.
+	// Should be run with -race.
+
+	// Setup:
+	original, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	rotated := buildRotatedPricer(t)
+	pricer, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+		WithDecryptCache(16),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	const goroutines = 16
+	const iterations = 500
+	done := make(chan struct{})
+	var rotator sync.WaitGroup
+	rotator.Add(1)
+	go func() {
+		defer rotator.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			var err error
+			if i%2 == 0 {
+				err = pricer.SetKeys(rotatedEncryptionKey, rotatedIntegrityKey, false, helpers.WebSafeBase64)
+			} else {
+				err = pricer.SetKeys("652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5", false, helpers.Hexa)
+			}
+			if err != nil {
+				t.Errorf("Setting keys failed. Error : %s", err)
+				return
+			}
+		}
+	}()
+
+	// Execute:
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				seed := fmt.Sprintf("seed-%d-%d", g, i)
+				micros := uint64(g*iterations+i) * 10000
+
+				encrypted, err := pricer.EncryptMicros(seed, micros)
+				if err != nil {
+					t.Errorf("Encryption failed. Error : %s", err)
+					return
+				}
+				fromOriginal, _ := original.EncryptMicros(seed, micros)
+				fromRotated, _ := rotated.EncryptMicros(seed, micros)
+				if encrypted != fromOriginal && encrypted != fromRotated {
+					t.Errorf("Price %d encrypted with a mixed key pair : %s", micros, encrypted)
+					return
+				}
+
+				decrypted, err := pricer.DecryptMicros(encrypted)
+				if err != nil && !errors.Is(err, ErrSignatureMismatch) {
+					t.Errorf("Unexpected error : %s", err)
+					return
+				}
+				if err == nil && decrypted != micros {
+					t.Errorf("Decryption corrupted. Should be : %d but was : %d", micros, decrypted)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(done)
+	rotator.Wait()
+
+	// Verify:
+	fingerprint := pricer.KeyFingerprint()
+	assert.True(t, fingerprint == original.KeyFingerprint() || fingerprint == rotated.KeyFingerprint(), "Unexpected keys : %s", fingerprint)
+}
+
+func TestNewPricerWithIdenticalKeys(t *testing.T) {
+	// Setup:
+	var keysTestCase = []struct {
+		name                string
+		integrityKey        string
+		requireDistinctKeys bool
+		isWarned            bool
+		err                 error
+	}{
+		{"identical keys are warned about", "652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", false, true, nil},
+		{"identical keys are rejected", "652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", true, false, ErrIdenticalKeys},
+		{"distinct keys", "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5", false, false, nil},
+		{"distinct keys are required", "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5", true, false, nil},
+		// Keys are compared once decoded
+		{"identical keys in another encoding", "ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU", true, false, ErrIdenticalKeys},
+	}
+
+	for _, k := range keysTestCase {
+		t.Run(k.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+
+			// Execute:
+			pricer, err := NewPricer(
+				WithKeys("652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", k.integrityKey),
+				WithKeyDecodingMode(helpers.Auto),
+				WithDistinctKeys(k.requireDistinctKeys),
+				WithLogger(logger),
+			)
+
+			// Verify:
+			if k.err != nil {
+				assert.Nil(t, pricer)
+				assert.True(t, errors.Is(err, ErrInvalidKey), "Unexpected error : %s", err)
+				assert.True(t, errors.Is(err, k.err), "Unexpected error : %s", err)
+			} else {
+				assert.Nil(t, err, "Error creating new Pricer : ", err)
+			}
+			if k.isWarned {
+				assert.Equal(t, []string{IdenticalKeysWarning}, logger.lines)
+			} else {
+				assert.Empty(t, logger.lines)
+			}
+		})
+	}
+}
+
+func TestSetKeysIdentical(t *testing.T) {
+	// Setup:
+	logger := &recordingLogger{}
+	pricer, err := NewPricer(
+		WithKeys(rotatedEncryptionKey, rotatedIntegrityKey),
+		WithKeyDecodingMode(helpers.WebSafeBase64),
+		WithDistinctKeys(true),
+		WithLogger(logger),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	fingerprint := pricer.KeyFingerprint()
+
+	// Execute:
+	err = pricer.SetKeys(rotatedEncryptionKey, rotatedEncryptionKey, false, helpers.WebSafeBase64)
+
+	// Verify:
+	assert.True(t, errors.Is(err, ErrIdenticalKeys), "Unexpected error : %s", err)
+	assert.Equal(t, fingerprint, pricer.KeyFingerprint(), "Keys shouldn't be replaced on error")
+	assert.Empty(t, logger.lines)
+}
+
+func TestNewPricerFromBytes(t *testing.T) {
+	// Setup:
+	encryptionKey, _ := hex.DecodeString("652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135")
+	integrityKey, _ := hex.DecodeString("bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5")
+	fromHex, err := NewPricer(
+		WithKeys(hex.EncodeToString(encryptionKey), hex.EncodeToString(integrityKey)),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Execute:
+	fromBytes, err := NewPricerFromBytes(encryptionKey, integrityKey, DefaultScaleFactor)
+
+	// Verify:
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	assert.Equal(t, fromHex.keys.Load().encryptionKey, fromBytes.keys.Load().encryptionKey)
+	assert.Equal(t, fromHex.keys.Load().integrityKey, fromBytes.keys.Load().integrityKey)
+	assert.Equal(t, fromHex.KeyFingerprint(), fromBytes.KeyFingerprint())
+	for _, price := range []float64{0, 1.354, 100} {
+		fromBytesEncrypted, err := fromBytes.Encrypt("seed", price)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		fromHexEncrypted, err := fromHex.Encrypt("seed", price)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		assert.Equal(t, fromHexEncrypted, fromBytesEncrypted)
+	}
+	decrypted, err := fromBytes.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.InDelta(t, 1.354, decrypted, 0.000001)
+}
+
+func TestNewPricerFromBytesCopiesKeys(t *testing.T) {
+	// Setup:
+	encryptionKey, _ := hex.DecodeString("652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135")
+	integrityKey, _ := hex.DecodeString("bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5")
+	pricer, err := NewPricerFromBytes(encryptionKey, integrityKey, DefaultScaleFactor)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Execute:
+	for i := range encryptionKey {
+		encryptionKey[i] = 0
+		integrityKey[i] = 0
+	}
+	decrypted, err := pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+
+	// Verify:
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.InDelta(t, 1.354, decrypted, 0.000001)
+}
+
+func TestNewGoogleLayoutPricer(t *testing.T) {
+	// Setup:
+	fromHex, err := NewPricer(
+		WithKeys("652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5"),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Execute:
+	pricer, err := NewGoogleLayoutPricer("ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU", "vQo9-4KtlcXmPhWaYvc8asqYuiSVMiGUdZ1RLXfrK7U")
+	_, errHexKeys := NewGoogleLayoutPricer("652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5")
+
+	// Verify:
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	assert.Equal(t, fromHex.KeyFingerprint(), pricer.KeyFingerprint())
+	encrypted, err := pricer.Encrypt("", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	assert.Equal(t, "1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA", encrypted)
+	assert.True(t, errors.Is(errHexKeys, ErrInvalidKey), "Unexpected error : %s", errHexKeys)
+}
+
+func TestNewGoogleLayoutPricerWithOptions(t *testing.T) {
+	// Execute:
+	pricer, err := NewGoogleLayoutPricer("ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU", "vQo9-4KtlcXmPhWaYvc8asqYuiSVMiGUdZ1RLXfrK7U",
+		WithScaleFactor(1000))
+
+	// Verify:
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	micros, err := pricer.DecryptMicros("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	price, err := pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.Equal(t, uint64(1354000), micros)
+	assert.InDelta(t, 1354, price, 0.000001)
+}
+
+func TestNewPricerFromBytesInvalidKeys(t *testing.T) {
+	// Setup:
+	validKey := make([]byte, DefaultKeyLength)
+	validKey[0] = 1
+	var tests = []struct {
+		name          string
+		encryptionKey []byte
+		integrityKey  []byte
+	}{
+		{name: "nil encryption key", encryptionKey: nil, integrityKey: validKey},
+		{name: "empty integrity key", encryptionKey: validKey, integrityKey: []byte{}},
+		{name: "short encryption key", encryptionKey: validKey[:16], integrityKey: validKey},
+		{name: "long integrity key", encryptionKey: validKey, integrityKey: append(validKey, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute:
+			pricer, err := NewPricerFromBytes(tt.encryptionKey, tt.integrityKey, DefaultScaleFactor)
+
+			// Verify:
+			assert.Nil(t, pricer)
+			assert.True(t, errors.Is(err, ErrInvalidKey), "Unexpected error : %s", err)
+		})
+	}
+}
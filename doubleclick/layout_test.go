@@ -0,0 +1,76 @@
+package doubleclick
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func TestSignatureLayoutRoundTrip(t *testing.T) {
+	for _, layout := range []helpers.SignatureLayout{helpers.PriceIV, helpers.IVPrice} {
+		t.Run(layout.String(), func(t *testing.T) {
+			// Setup:
+			pricer := buildTestPricer(t, WithSignatureLayout(layout))
+
+			// Execute:
+			encrypted, err := pricer.Encrypt("seed", 1.354)
+			require.NoError(t, err, "Encryption failed. Error : %s", err)
+			price, err := pricer.Decrypt(encrypted)
+
+			// Verify:
+			require.NoError(t, err, "Decryption failed. Error : %s", err)
+			assert.InDelta(t, 1.354, price, 0.000001)
+		})
+	}
+}
+
+func TestSignatureLayoutDefault(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithSignatureLayout(helpers.PriceIV))
+
+	// Execute:
+	encrypted, err := pricer.Encrypt("", 1.354)
+
+	// Verify:
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+	assert.Equal(t, "1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA", encrypted)
+}
+
+func TestSignatureLayoutMismatch(t *testing.T) {
+	// Setup:
+	priceIV := buildTestPricer(t, WithSignatureLayout(helpers.PriceIV))
+	ivPrice := buildTestPricer(t, WithSignatureLayout(helpers.IVPrice))
+	signedPriceIV, err := priceIV.Encrypt("seed", 1.354)
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+	signedIVPrice, err := ivPrice.Encrypt("seed", 1.354)
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+
+	// Execute:
+	_, priceIVErr := ivPrice.Decrypt(signedPriceIV)
+	_, ivPriceErr := priceIV.Decrypt(signedIVPrice)
+	isValid, verifyErr := ivPrice.Verify(signedPriceIV)
+
+	// Verify:
+	// Only signatures differ, price bytes being encrypted the same way.
+	assert.Equal(t, signedPriceIV[:32], signedIVPrice[:32])
+	assert.NotEqual(t, signedPriceIV, signedIVPrice)
+	assert.Equal(t, ErrSignatureMismatch, priceIVErr)
+	assert.Equal(t, ErrSignatureMismatch, ivPriceErr)
+	assert.Nil(t, verifyErr)
+	assert.False(t, isValid)
+}
+
+func TestNewPricerUnknownSignatureLayout(t *testing.T) {
+	// Execute:
+	pricer, err := NewPricer(
+		WithKeys(testEncryptionKey, testIntegrityKey),
+		WithSignatureLayout("price-price"),
+	)
+
+	// Verify:
+	assert.Nil(t, pricer)
+	assert.EqualError(t, err, "unknown signature layout: price-price")
+}
@@ -0,0 +1,60 @@
+package doubleclick
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func TestCiphertextLen(t *testing.T) {
+	var tests = []struct {
+		name          string
+		opts          []Option
+		ciphertextLen int
+		encodedLen    int
+	}{
+		{name: "default", ciphertextLen: 28, encodedLen: 38},
+		{name: "unsigned", opts: []Option{WithUnsigned(true)}, ciphertextLen: 24, encodedLen: 32},
+		{name: "8 bytes signatures", opts: []Option{WithSignatureLength(8)}, ciphertextLen: 32, encodedLen: 43},
+		{name: "SHA-256 sums", opts: []Option{WithHashAlgorithm(helpers.SHA256), WithSignatureLength(32)}, ciphertextLen: 56, encodedLen: 75},
+		{name: "standard base 64", opts: []Option{WithBase64Variant(helpers.Standard)}, ciphertextLen: 28, encodedLen: 38},
+		{name: "hexa", opts: []Option{WithPriceEncoding(helpers.Hex)}, ciphertextLen: 28, encodedLen: 56},
+		{name: "unsigned hexa", opts: []Option{WithPriceEncoding(helpers.Hex), WithUnsigned(true)}, ciphertextLen: 24, encodedLen: 48},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup:
+			pricer := buildTestPricer(t, tt.opts...)
+
+			// Execute:
+			encrypted, err := pricer.Encrypt("seed", 1.354)
+			assert.Nil(t, err, "Encryption failed. Error : %s", err)
+			raw, err := pricer.EncryptRaw("seed", 1.354)
+			assert.Nil(t, err, "Encryption failed. Error : %s", err)
+
+			// Verify:
+			assert.Equal(t, tt.ciphertextLen, pricer.CiphertextLen())
+			assert.Equal(t, tt.encodedLen, pricer.EncodedLen())
+			assert.Len(t, raw, pricer.CiphertextLen())
+			assert.Len(t, encrypted, pricer.EncodedLen())
+		})
+	}
+}
+
+func TestCiphertextLenDecryptInto(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithSignatureLength(8))
+	encrypted, err := pricer.Encrypt("seed", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	buf := make([]byte, pricer.CiphertextLen())
+
+	// Execute:
+	price, err := pricer.DecryptInto(buf, encrypted)
+
+	// Verify:
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.InDelta(t, 1.354, price, 0.000001)
+}
@@ -0,0 +1,49 @@
+package doubleclick
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// AuctionPriceMacro is the OpenRTB macro win notice (nurl) and billing (burl)
+// URLs carry the clearing price with, substituted by the exchange.
+const AuctionPriceMacro = "${AUCTION_PRICE}"
+
+// maxMacroUnescapes bounds how many times DecodeFromMacro unescapes an
+// encrypted price, prices being escaped at most once per hop in practice.
+const maxMacroUnescapes = 3
+
+// EncodeForMacro encrypts a clear price and a given seed, returning the encrypted
+// price query escaped so that it can be substituted as is to AuctionPriceMacro in a URL:
+//
+//	nurl = strings.ReplaceAll(nurl, doubleclick.AuctionPriceMacro, encrypted)
+//
+// Unpadded web safe base 64 and hexa encrypted prices don't need escaping, and are
+// returned as Encrypt returns them.
+func (dc *DoubleClickPricer) EncodeForMacro(seed string, price float64) (string, error) {
+	encrypted, err := dc.Encrypt(seed, price)
+	if err != nil {
+		return "", err
+	}
+
+	return url.QueryEscape(encrypted), nil
+}
+
+// DecodeFromMacro decrypts an encrypted price received on a win notice URL through
+// AuctionPriceMacro, e.g. a query parameter value. Encrypted prices URL escaped once
+// or several times in transit, such as padding becoming %3D or %253D, are unescaped
+// before being decrypted. '+' is kept as is, being part of the standard base 64 alphabet.
+func (dc *DoubleClickPricer) DecodeFromMacro(value string) (float64, error) {
+	var errPrice float64
+
+	for i := 0; i < maxMacroUnescapes && strings.Contains(value, "%"); i++ {
+		unescaped, err := url.PathUnescape(value)
+		if err != nil {
+			return errPrice, fmt.Errorf("%w: %w", ErrMalformedMacro, err)
+		}
+		value = unescaped
+	}
+
+	return dc.Decrypt(value)
+}
@@ -0,0 +1,90 @@
+package doubleclick
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func TestEncodeForMacro(t *testing.T) {
+	// Setup:
+	var variantsTestCase = []helpers.Base64Variant{helpers.URLSafe, helpers.Standard}
+	seeds := []string{"", "seed", "azertyuiopmlkjhgfdsqwxcvbn", "request-42"}
+	nurl := "https://bidder.example/win?id=42&price=" + AuctionPriceMacro + "&cur=USD"
+
+	for _, variant := range variantsTestCase {
+		pricer, err := NewPricer(
+			WithKeys(
+				"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+				"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+			),
+			WithBase64Variant(variant),
+		)
+		assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+		for _, seed := range seeds {
+			// Execute:
+			value, err := pricer.EncodeForMacro(seed, 1.354)
+			assert.Nil(t, err, "Encoding failed. Error : %s", err)
+			encrypted, err := pricer.Encrypt(seed, 1.354)
+			assert.Nil(t, err, "Encryption failed. Error : %s", err)
+			// Win notice receiver side
+			notice, err := url.Parse(strings.ReplaceAll(nurl, AuctionPriceMacro, value))
+			assert.Nil(t, err)
+			received := notice.Query().Get("price")
+			price, err := pricer.DecodeFromMacro(received)
+
+			// Verify:
+			assert.Nil(t, err, "Decoding failed. Error : %s", err)
+			assert.InDelta(t, 1.354, price, 0.000001)
+			assert.Equal(t, encrypted, received)
+			assert.Equal(t, "42", notice.Query().Get("id"))
+			if variant == helpers.URLSafe {
+				assert.Equal(t, encrypted, value, "Web safe encrypted prices shouldn't be escaped")
+			}
+		}
+	}
+}
+
+func TestDecodeFromMacro(t *testing.T) {
+	// Setup:
+	pricer, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	var valuesTestCase = []struct {
+		name  string
+		value string
+	}{
+		{"as is", "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg"},
+		{"padded", "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg=="},
+		{"escaped once", url.QueryEscape("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg==")},
+		{"escaped twice", url.QueryEscape(url.QueryEscape("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg=="))},
+		{"every char escaped", "%61%6E%43%47%47%46%4A%41%70%63%66%42%36%5A%47%63%36%6D%69%6E%64%68%70%54%72%59%58%48%59%34%4F%4E%6F%37%6C%58%70%67"},
+	}
+
+	for _, tt := range valuesTestCase {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute:
+			price, err := pricer.DecodeFromMacro(tt.value)
+
+			// Verify:
+			assert.Nil(t, err, "Decoding failed. Error : %s", err)
+			assert.InDelta(t, 1.354, price, 0.000001)
+		})
+	}
+
+	// Execute:
+	_, err = pricer.DecodeFromMacro("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg%3")
+
+	// Verify:
+	assert.True(t, errors.Is(err, ErrMalformedMacro), "Unexpected error : %s", err)
+}
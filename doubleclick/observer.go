@@ -0,0 +1,32 @@
+package doubleclick
+
+import (
+	"time"
+)
+
+// observeEncrypt reports an encryption started at start to the pricer observer.
+// err is read once the encryption is done, hence the pointer.
+func (dc *DoubleClickPricer) observeEncrypt(start time.Time, err *error) {
+	dc.observer.ObserveEncrypt(time.Since(start), *err)
+}
+
+// startDecrypt starts timing the decryption state is used for, so that
+// observeOpen reports it, if the pricer has an observer.
+func (dc *DoubleClickPricer) startDecrypt(state *cryptoState) {
+	if dc.observer != nil {
+		state.started = time.Now()
+	}
+}
+
+// observeOpen reports the decryption state is used for to the pricer observer,
+// once the encrypted price is opened or fails to be. err is the error Decrypt
+// returns for it, even when the calling method doesn't treat it as an error,
+// e.g. a signature mismatch for Verify. Decryptions are reported once, and only
+// if started with startDecrypt, so that self tests aren't.
+func (dc *DoubleClickPricer) observeOpen(state *cryptoState, err error) {
+	if dc.observer == nil || state.started.IsZero() {
+		return
+	}
+	dc.observer.ObserveDecrypt(time.Since(state.started), err)
+	state.started = time.Time{}
+}
@@ -0,0 +1,151 @@
+package doubleclick
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingObserver counts every outcome it receives.
+type recordingObserver struct {
+	mu                sync.Mutex
+	encryptSuccesses  int
+	encryptFailures   int
+	decryptSuccesses  int
+	decryptFailures   int
+	signatureFailures int
+}
+
+func (o *recordingObserver) ObserveEncrypt(d time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err != nil {
+		o.encryptFailures++
+		return
+	}
+	o.encryptSuccesses++
+}
+
+func (o *recordingObserver) ObserveDecrypt(d time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err != nil {
+		o.decryptFailures++
+		if errors.Is(err, ErrSignatureMismatch) {
+			o.signatureFailures++
+		}
+		return
+	}
+	o.decryptSuccesses++
+}
+
+func TestObserver(t *testing.T) {
+	// Setup:
+	observer := &recordingObserver{}
+	pricer, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+		WithObserver(observer),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Execute:
+	pricer.Encrypt("", 1.354)
+	pricer.EncryptMicros("", 1354000)
+	pricer.Encrypt("", 1<<64)
+	pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	pricer.DecryptMicros("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	pricer.Decrypt(tamperSignature("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", 0))
+	pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lX!!")
+	pricer.DecryptBatch([]string{
+		"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg",
+		tamperSignature("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", 1),
+	})
+
+	// Verify:
+	assert.Equal(t, 2, observer.encryptSuccesses)
+	assert.Equal(t, 1, observer.encryptFailures)
+	assert.Equal(t, 3, observer.decryptSuccesses)
+	assert.Equal(t, 3, observer.decryptFailures)
+	assert.Equal(t, 2, observer.signatureFailures)
+}
+
+func TestObserverSignatureTolerantMethods(t *testing.T) {
+	// Setup:
+	observer := &recordingObserver{}
+	pricer, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+		WithObserver(observer),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	tampered := tamperSignature("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", 0)
+
+	// Execute:
+	for _, encryptedPrice := range []string{"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", tampered} {
+		pricer.Verify(encryptedPrice)
+		pricer.VerifyPriceEquals(encryptedPrice, 1354000)
+		pricer.DecryptDetailed(encryptedPrice)
+		pricer.DecryptRawMicros(encryptedPrice)
+		pricer.DecryptWithTrace(encryptedPrice)
+		pricer.DecryptCents(encryptedPrice)
+		pricer.DecryptFormatted(encryptedPrice, 2)
+	}
+	pricer.Verify("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lX!!")
+	pricer.SelfTest()
+	pricer.SelfTestWith(tampered, 1.354)
+
+	// Verify:
+	assert.Equal(t, 7, observer.decryptSuccesses)
+	assert.Equal(t, 8, observer.decryptFailures)
+	assert.Equal(t, 7, observer.signatureFailures)
+}
+
+func TestObserverCachedDecryptions(t *testing.T) {
+	// Setup:
+	observer := &recordingObserver{}
+	pricer, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+		WithObserver(observer),
+		WithDecryptCache(8),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Execute:
+	for i := 0; i < 3; i++ {
+		pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	}
+
+	// Verify:
+	assert.Equal(t, 3, observer.decryptSuccesses)
+	assert.Equal(t, 0, observer.decryptFailures)
+}
+
+func BenchmarkDecryptWithObserver(b *testing.B) {
+	pricer, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+		WithObserver(&recordingObserver{}),
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	}
+}
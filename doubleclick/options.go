@@ -0,0 +1,421 @@
+package doubleclick
+
+import (
+	"crypto/rand"
+	"hash"
+	"io"
+	"math"
+
+	"github.com/benjaminch/pricers/helpers"
+	"github.com/benjaminch/pricers/internal/core"
+)
+
+const (
+	// DefaultScaleFactor is the scale factor from specs, prices are encrypted as micros.
+	DefaultScaleFactor float64 = 1000000
+	// DefaultKeyLength is the decoded keys length from specs, in bytes.
+	DefaultKeyLength int = 32
+	// AnyKeyLength accepts decoded keys of any non empty length.
+	AnyKeyLength int = 0
+	// DefaultMaxMicros is the highest price decryption accepts by default, in micros.
+	// Prices with the top bit set would be negative as signed 64 bits integers.
+	DefaultMaxMicros uint64 = math.MaxInt64
+	// NoMaxMicros accepts any decrypted price.
+	NoMaxMicros uint64 = math.MaxUint64
+)
+
+// IVDeriver derives the 16 bytes Initialization Vector of a price from its seed.
+type IVDeriver func(seed string) [16]byte
+
+// MD5IVDeriver is the IV deriver from specs, and the default one: md5(seed).
+var MD5IVDeriver IVDeriver = core.IV
+
+// config holds every setting a DoubleClickPricer is built from.
+type config struct {
+	encryptionKey       string
+	integrityKey        string
+	encryptionKeyBytes  []byte
+	integrityKeyBytes   []byte
+	isBase64Keys        bool
+	keyDecodingMode     helpers.KeyDecodingMode
+	keyLength           int
+	requireDistinctKeys bool
+	scaleFactor         float64
+	// integerScaleFactor, when not zero, is used to scale prices
+	// with integer arithmetic. scaleFactor then holds the same value.
+	integerScaleFactor  int64
+	base64Variant       helpers.Base64Variant
+	priceEncoding       helpers.PriceEncoding
+	signatureLayout     helpers.SignatureLayout
+	signatureLength     int
+	padOffset           int
+	hashAlgorithm       helpers.HashAlgorithm
+	isDebugMode         bool
+	logger              helpers.Logger
+	observer            helpers.Observer
+	roundingMode        helpers.RoundingMode
+	zeroIVPolicy        helpers.ZeroIVPolicy
+	roundingDecimals    int
+	priceFloor          *float64
+	priceCeiling        *float64
+	maxPrice            *float64
+	allowNegativePrices bool
+	isStrict            bool
+	isUnsigned          bool
+	detectSwappedKeys   bool
+	maxMicros           uint64
+	decryptCacheSize    int
+	minSeedLength       int
+	ivDeriver           IVDeriver
+	randomSource        io.Reader
+	currency            string
+	rateProvider        helpers.RateProvider
+	// newHash, when not nil, overrides hashAlgorithm. It is only set by
+	// tests, to check sums of misconfigured hash functions are caught.
+	newHash func() hash.Hash
+}
+
+// Option configures a DoubleClickPricer built with NewPricer.
+type Option func(*config)
+
+// newConfig returns the default config with opts applied.
+func newConfig(opts ...Option) *config {
+	c := &config{
+		keyDecodingMode: helpers.Hexa,
+		keyLength:       DefaultKeyLength,
+		scaleFactor:     DefaultScaleFactor,
+		base64Variant:   helpers.URLSafe,
+		priceEncoding:   helpers.Base64,
+		signatureLayout: helpers.PriceIV,
+		signatureLength: core.SignatureLength,
+		hashAlgorithm:   helpers.SHA1,
+		maxMicros:       DefaultMaxMicros,
+		ivDeriver:       MD5IVDeriver,
+		randomSource:    rand.Reader,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// WithKeys sets the encryption and integrity keys.
+func WithKeys(encryptionKey string, integrityKey string) Option {
+	return func(c *config) {
+		c.encryptionKey = encryptionKey
+		c.integrityKey = integrityKey
+	}
+}
+
+// WithKeyBytes sets already decoded encryption and integrity keys, e.g. returned as raw
+// bytes by a KMS, which are then used as is instead of keys set with WithKeys, without
+// being decoded. Keys are copied, and their length still checked against WithKeyLength.
+func WithKeyBytes(encryptionKey []byte, integrityKey []byte) Option {
+	return func(c *config) {
+		c.encryptionKeyBytes = encryptionKey
+		c.integrityKeyBytes = integrityKey
+	}
+}
+
+// WithKeyConfig sets keys, key decoding and, if not zero, scale factor
+// from keys loaded with helpers.LoadKeysFromEnv or helpers.LoadKeysFromFile.
+func WithKeyConfig(keyConfig *helpers.KeyConfig) Option {
+	return func(c *config) {
+		c.encryptionKey = keyConfig.EncryptionKey
+		c.integrityKey = keyConfig.IntegrityKey
+		c.isBase64Keys = keyConfig.IsBase64
+		c.keyDecodingMode = keyConfig.KeyDecodingMode
+		c.keyLength = helpers.KeyLength
+		if keyConfig.ScaleFactor != 0 {
+			c.scaleFactor = keyConfig.ScaleFactor
+			c.integerScaleFactor = 0
+		}
+	}
+}
+
+// WithBase64Keys sets whether keys are base 64 websafe encoded.
+func WithBase64Keys(isBase64Keys bool) Option {
+	return func(c *config) {
+		c.isBase64Keys = isBase64Keys
+	}
+}
+
+// WithKeyDecodingMode sets how keys should be decoded.
+func WithKeyDecodingMode(keyDecodingMode helpers.KeyDecodingMode) Option {
+	return func(c *config) {
+		c.keyDecodingMode = keyDecodingMode
+	}
+}
+
+// WithKeyLength sets the expected length of decoded keys, in bytes.
+// AnyKeyLength accepts keys of any non empty length.
+func WithKeyLength(keyLength int) Option {
+	return func(c *config) {
+		c.keyLength = keyLength
+	}
+}
+
+// WithDistinctKeys sets whether keys are required to differ once decoded, NewPricer and
+// SetKeys returning ErrIdenticalKeys otherwise. Identical keys still round-trip, but
+// don't follow specs and weaken the scheme, so by default they're warned about,
+// IdenticalKeysWarning being emitted through the logger even if debug mode is disabled.
+func WithDistinctKeys(requireDistinctKeys bool) Option {
+	return func(c *config) {
+		c.requireDistinctKeys = requireDistinctKeys
+	}
+}
+
+// WithScaleFactor sets the factor the clear price will be multiplied by before encryption.
+// The encrypted integer is in whatever unit the scale factor converts prices to, micros of
+// the account currency as described by specs, or e.g. 10000 for accounts whose smallest unit
+// isn't a millionth. Only that conversion depends on the scale factor: the same integer gives
+// the same encrypted bytes and signature whatever the scale factor, EncryptMicros and
+// DecryptMicros handling it as is. WithScaleFactor(1) lets callers holding micros as floats
+// encrypt and decrypt them as is, see also EncryptMicrosFloat.
+func WithScaleFactor(scaleFactor float64) Option {
+	return func(c *config) {
+		c.scaleFactor = scaleFactor
+		c.integerScaleFactor = 0
+	}
+}
+
+// WithIntegerScaleFactor sets an integer factor the clear price will be multiplied by
+// before encryption. Prices are scaled from their decimal representation with integer
+// arithmetic, e.g. 1.005 gives 1005000 micros while the float scale factor gives
+// 1004999. Digits beyond the scale factor precision are still truncated, and decrypted
+// prices are still divided as floats.
+func WithIntegerScaleFactor(scaleFactor int64) Option {
+	return func(c *config) {
+		c.scaleFactor = float64(scaleFactor)
+		c.integerScaleFactor = scaleFactor
+	}
+}
+
+// WithBase64Variant sets the base 64 alphabet encrypted prices are encoded with.
+// Decrypt only accepts encrypted prices encoded with that very alphabet.
+func WithBase64Variant(base64Variant helpers.Base64Variant) Option {
+	return func(c *config) {
+		c.base64Variant = base64Variant
+	}
+}
+
+// WithPriceEncoding sets how encrypted prices are encoded as strings,
+// either base 64 (using the Base64Variant alphabet) or hexa.
+func WithPriceEncoding(priceEncoding helpers.PriceEncoding) Option {
+	return func(c *config) {
+		c.priceEncoding = priceEncoding
+	}
+}
+
+// WithSignatureLayout sets how price and IV are concatenated before being signed,
+// helpers.PriceIV by default as described by specs, helpers.IVPrice for exchanges
+// signing iv || price. Prices signed with another layout fail to decrypt.
+func WithSignatureLayout(signatureLayout helpers.SignatureLayout) Option {
+	return func(c *config) {
+		c.signatureLayout = signatureLayout
+	}
+}
+
+// WithSignatureLength sets the length, in bytes, integrity signatures are truncated to,
+// 4 by default as described by specs, e.g. 8 for exchanges truncating HMACs to 8 bytes.
+// Encrypted prices are then 16 + 8 + signatureLength bytes long once decoded. It can't
+// exceed the hash algorithm sum length. Unsigned pricers aren't affected.
+func WithSignatureLength(signatureLength int) Option {
+	return func(c *config) {
+		c.signatureLength = signatureLength
+	}
+}
+
+// WithPadOffset sets where, in bytes, the 8 bytes pad starts in hmac(e_key, iv),
+// 0 by default as described by specs. It is only meant to decrypt and encrypt prices
+// of legacy integrations slicing pads at another offset, not part of the public
+// specs: prices encrypted with another offset decrypt to garbage and fail signature
+// checks. It can't exceed the hash algorithm sum length minus 8.
+func WithPadOffset(padOffset int) Option {
+	return func(c *config) {
+		c.padOffset = padOffset
+	}
+}
+
+// WithHashAlgorithm sets the hash function pads and signatures HMACs are computed with,
+// helpers.SHA1 by default as described by specs, helpers.SHA256 for exchanges using it.
+func WithHashAlgorithm(hashAlgorithm helpers.HashAlgorithm) Option {
+	return func(c *config) {
+		c.hashAlgorithm = hashAlgorithm
+	}
+}
+
+// WithDebug sets whether debug lines are emitted.
+func WithDebug(isDebugMode bool) Option {
+	return func(c *config) {
+		c.isDebugMode = isDebugMode
+	}
+}
+
+// WithLogger sets the logger debug lines and warnings are emitted through.
+// A nil logger discards every debug line.
+func WithLogger(logger helpers.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// WithObserver sets the observer encrypt / decrypt outcomes and latencies are
+// reported to. A nil observer, the default, reports nothing and costs nothing.
+// Decryptions are reported from the path every decrypting method shares, once
+// the encrypted price is decoded and opened or fails to be, with the error Decrypt
+// would return: signature mismatches are reported even by methods such as
+// Verify or DecryptDetailed, which don't return them. Calls failing before,
+// e.g. on a canceled context or a closed pricer, and self tests aren't reported.
+func WithObserver(observer helpers.Observer) Option {
+	return func(c *config) {
+		c.observer = observer
+	}
+}
+
+// WithRounding sets how decrypted prices are rounded, to decimals decimal places.
+// Prices aren't rounded by default. DecryptMicros isn't affected.
+func WithRounding(mode helpers.RoundingMode, decimals int) Option {
+	return func(c *config) {
+		c.roundingMode = mode
+		c.roundingDecimals = decimals
+	}
+}
+
+// WithPriceFloor sets the lowest price decryption returns, lower prices being
+// raised to floor once rounded. Prices aren't clamped by default.
+func WithPriceFloor(floor float64) Option {
+	return func(c *config) {
+		c.priceFloor = &floor
+	}
+}
+
+// WithPriceCeiling sets the highest price decryption returns, higher prices being
+// lowered to ceiling once rounded. Prices aren't clamped by default.
+func WithPriceCeiling(ceiling float64) Option {
+	return func(c *config) {
+		c.priceCeiling = &ceiling
+	}
+}
+
+// WithMaxPrice sets the highest clear price encryption accepts, before the scale factor
+// is applied, higher prices returning ErrPriceAboveMax, e.g. to catch a runaway bid
+// multiplier. EncryptMicros and EncryptMicrosFloat aren't affected. Prices are unlimited
+// by default.
+func WithMaxPrice(maxPrice float64) Option {
+	return func(c *config) {
+		c.maxPrice = &maxPrice
+	}
+}
+
+// WithNegativePrices sets whether negative clear prices are encrypted, as two's complement
+// micros, rather than rejected with ErrInvalidPrice. Decrypted micros with the top bit set are
+// then read as negative prices, DefaultMaxMicros only bounding positive ones, and DecryptMicros
+// returns them as is. Negative prices are rejected by default, NaN and infinite prices always are.
+func WithNegativePrices(allowNegativePrices bool) Option {
+	return func(c *config) {
+		c.allowNegativePrices = allowNegativePrices
+	}
+}
+
+// WithStrict sets whether decryption rejects encrypted prices whose decoded length
+// isn't exactly the IV (16 bytes) and price (8 bytes) followed by the configured
+// signature length, 28 bytes by default, or IV and price only, 24 bytes, with
+// WithUnsigned. See CiphertextLen. By default trailing bytes are ignored.
+func WithStrict(isStrict bool) Option {
+	return func(c *config) {
+		c.isStrict = isStrict
+	}
+}
+
+// WithUnsigned sets whether encrypted prices are 24 bytes long once decoded, iv || enc_price,
+// without integrity signature, for exchanges skipping it. Unsigned prices can't be checked
+// for tampering: Verify and DecryptDetailed report them as not integrity valid.
+// By default prices are signed and 28 bytes long.
+func WithUnsigned(isUnsigned bool) Option {
+	return func(c *config) {
+		c.isUnsigned = isUnsigned
+	}
+}
+
+// WithZeroIVPolicy sets how decryption treats encrypted prices whose IV is all zeros,
+// likely encrypted with an empty or missing seed upstream. helpers.RejectZeroIV returns
+// ErrZeroIV, helpers.WarnZeroIV emits ZeroIVWarning through the logger, whether debug mode
+// is enabled or not. By default, helpers.AllowZeroIV, they are decrypted as any other.
+// Integrity signatures are checked first, so that tampered prices aren't reported as such.
+func WithZeroIVPolicy(zeroIVPolicy helpers.ZeroIVPolicy) Option {
+	return func(c *config) {
+		c.zeroIVPolicy = zeroIVPolicy
+	}
+}
+
+// WithSwappedKeysDetection sets whether decryption, on signature mismatch, checks
+// the signature again with encryption and integrity keys swapped, returning
+// ErrKeysSwapped wrapped in ErrSignatureMismatch if it then matches. Mismatches
+// cost twice as much, and allocate, so that it is meant for diagnosing a new
+// integration rather than for production. It is disabled by default.
+func WithSwappedKeysDetection(detectSwappedKeys bool) Option {
+	return func(c *config) {
+		c.detectSwappedKeys = detectSwappedKeys
+	}
+}
+
+// WithMaxMicros sets the highest price decryption accepts, in micros, before the
+// scale factor is applied. Higher prices, likely corrupted, return ErrPriceOutOfRange.
+// It defaults to DefaultMaxMicros, NoMaxMicros disables the check.
+func WithMaxMicros(maxMicros uint64) Option {
+	return func(c *config) {
+		c.maxMicros = maxMicros
+	}
+}
+
+// WithDecryptCache sets how many decrypted prices are cached, keyed on their
+// encrypted price, so that decrypting again the same encrypted price is free.
+// Prices failing to decrypt are never cached. Cache hits emit ZeroIVWarning again
+// with helpers.WarnZeroIV, as decryptions do. Prices aren't cached by default.
+func WithDecryptCache(size int) Option {
+	return func(c *config) {
+		c.decryptCacheSize = size
+	}
+}
+
+// WithMinSeedLength sets the minimum length of seeds, in bytes, encryption accepts,
+// shorter seeds returning ErrInvalidSeed. WithMinSeedLength(1) rejects empty seeds.
+// Any seed is accepted by default.
+// The IV is md5(seed), so that a constant seed means a constant IV, and so a constant
+// pad: the same price is then always encrypted the same way, and XORing two encrypted
+// prices reveals the XOR of clear prices. Seeds should be unique per price, such as
+// the ones from helpers.NewSeed.
+func WithMinSeedLength(minSeedLength int) Option {
+	return func(c *config) {
+		c.minSeedLength = minSeedLength
+	}
+}
+
+// WithIVDeriver sets how Initialization Vectors are derived from seeds, e.g. for
+// exchanges not hashing seeds with md5. It defaults to MD5IVDeriver.
+// EncryptWithIV isn't affected, taking its IV as is. ivDeriver must not be nil.
+func WithIVDeriver(ivDeriver IVDeriver) Option {
+	return func(c *config) {
+		c.ivDeriver = ivDeriver
+	}
+}
+
+// WithRandomSource sets the reader EncryptRandom reads 16 random bytes IVs from.
+// It defaults to crypto/rand.Reader. randomSource must not be nil, and must be
+// safe for concurrent use if the pricer is shared by several goroutines.
+func WithRandomSource(randomSource io.Reader) Option {
+	return func(c *config) {
+		c.randomSource = randomSource
+	}
+}
+
+// WithCurrency sets the currency, an ISO 4217 code, prices are encrypted in
+// and the provider EncryptCurrency looks conversion rates up with.
+func WithCurrency(currency string, rateProvider helpers.RateProvider) Option {
+	return func(c *config) {
+		c.currency = currency
+		c.rateProvider = rateProvider
+	}
+}
@@ -0,0 +1,618 @@
+package doubleclick
+
+import (
+	"encoding/base64"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func TestNewPricerDefaults(t *testing.T) {
+	// Setup:
+	var pricer *DoubleClickPricer
+	var err error
+
+	// Execute:
+	pricer, err = NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+	)
+
+	// Verify:
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	assert.Equal(t, helpers.Hexa, pricer.keys.Load().keyDecodingMode)
+	assert.Equal(t, DefaultScaleFactor, pricer.scaleFactor)
+	assert.False(t, pricer.isDebugMode)
+
+	var result float64
+	result, err = pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.InDelta(t, result, 1.354, 0.001, "Decryption failed. Should be : %f but was : %f", 1.354, result)
+}
+
+func TestNewPricerMatchesNewDoubleClickPricer(t *testing.T) {
+	// Setup:
+	var pricersTestCase = []struct {
+		encryptionKey   string
+		integrityKey    string
+		isBase64Keys    bool
+		keyDecodingMode helpers.KeyDecodingMode
+		scaleFactor     float64
+		isDebugMode     bool
+	}{
+		{"ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU", "vQo9-4KtlcXmPhWaYvc8asqYuiSVMiGUdZ1RLXfrK7U", true, helpers.Utf8, 1000000, false},
+		{"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5", false, helpers.Hexa, 100, true},
+		{"6356770B3C111C07F778AFD69F16643E9110090FD4C479D91181EED2523788F1", "3588BF6D387E8AEAD4EEC66798255369AF47BFD48B056E8934CEFEF3609C469E", false, helpers.Utf8, 1000000, false},
+	}
+
+	for _, p := range pricersTestCase {
+		// Execute:
+		legacy, err := NewDoubleClickPricer(p.encryptionKey, p.integrityKey, p.isBase64Keys, p.keyDecodingMode, p.scaleFactor, p.isDebugMode)
+		assert.Nil(t, err, "Error creating new Pricer : ", err)
+		pricer, err := NewPricer(
+			WithKeys(p.encryptionKey, p.integrityKey),
+			WithBase64Keys(p.isBase64Keys),
+			WithKeyDecodingMode(p.keyDecodingMode),
+			WithScaleFactor(p.scaleFactor),
+			WithDebug(p.isDebugMode),
+			WithKeyLength(AnyKeyLength),
+		)
+		assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+		// Verify:
+		assert.Equal(t, legacy.keys.Load().encryptionKeyRaw, pricer.keys.Load().encryptionKeyRaw, "Pricers should be equivalent")
+		assert.Equal(t, legacy.keys.Load().integrityKeyRaw, pricer.keys.Load().integrityKeyRaw, "Pricers should be equivalent")
+		assert.Equal(t, legacy.keys.Load().encryptionKey, pricer.keys.Load().encryptionKey, "Pricers should be equivalent")
+		assert.Equal(t, legacy.keys.Load().integrityKey, pricer.keys.Load().integrityKey, "Pricers should be equivalent")
+		assert.Equal(t, legacy.keys.Load().keyDecodingMode, pricer.keys.Load().keyDecodingMode, "Pricers should be equivalent")
+		assert.Equal(t, legacy.scaleFactor, pricer.scaleFactor, "Pricers should be equivalent")
+		assert.Equal(t, legacy.base64Encoding, pricer.base64Encoding, "Pricers should be equivalent")
+		assert.Equal(t, legacy.priceEncoding, pricer.priceEncoding, "Pricers should be equivalent")
+		assert.Equal(t, legacy.isDebugMode, pricer.isDebugMode, "Pricers should be equivalent")
+	}
+}
+
+func TestEncryptDecryptWithBase64Variants(t *testing.T) {
+	for _, base64Variant := range []helpers.Base64Variant{helpers.URLSafe, helpers.Standard} {
+		// Setup:
+		pricer, err := NewPricer(
+			WithKeys(
+				"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+				"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+			),
+			WithBase64Variant(base64Variant),
+		)
+		assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+		var seedsToTest = []string{"", "test", "a", "b", "azertyuiopmlkjhgfdsqwxcvbn"}
+
+		for _, seed := range seedsToTest {
+			// Execute:
+			encrypted, err := pricer.Encrypt(seed, 1.354)
+			assert.Nil(t, err, "Encryption failed. Error : %s", err)
+			decrypted, err := pricer.Decrypt(encrypted)
+
+			// Verify:
+			assert.Nil(t, err, "Decryption failed. Error : %s", err)
+			assert.InDelta(t, decrypted, 1.354, 0.001, "Decryption failed. Should be : %f but was : %f (variant: %s)", 1.354, decrypted, base64Variant)
+		}
+	}
+}
+
+func TestDecryptRejectsOtherBase64Variant(t *testing.T) {
+	// Setup:
+	// Same encrypted price in both alphabets, holding '_' once URL safe encoded
+	urlSafeEncrypted := "ce131TRp7waIZI2qOiRr2DMm2sSIeGh_wIAwVQ"
+	standardEncrypted := strings.Replace(urlSafeEncrypted, "_", "/", -1)
+
+	var variantsTestCase = []struct {
+		base64Variant helpers.Base64Variant
+		accepted      string
+		rejected      string
+	}{
+		{helpers.URLSafe, urlSafeEncrypted, standardEncrypted},
+		{helpers.Standard, standardEncrypted, urlSafeEncrypted},
+	}
+
+	for _, variant := range variantsTestCase {
+		pricer, err := NewPricer(
+			WithKeys(
+				"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+				"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+			),
+			WithBase64Variant(variant.base64Variant),
+		)
+		assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+		// Execute:
+		decrypted, err := pricer.Decrypt(variant.accepted)
+
+		// Verify:
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+		assert.InDelta(t, decrypted, 3.24, 0.001, "Decryption failed. Should be : %f but was : %f", 3.24, decrypted)
+
+		// Execute:
+		_, err = pricer.Decrypt(variant.rejected)
+
+		// Verify:
+		assert.True(t, errors.Is(err, ErrMalformedBase64), "Error should be ErrMalformedBase64 but was : %v", err)
+	}
+}
+
+func TestNewPricerWithUnknownBase64Variant(t *testing.T) {
+	// Execute:
+	_, err := NewPricer(WithBase64Variant("unknown"))
+
+	// Verify:
+	assert.EqualError(t, err, "unknown base64 variant: unknown")
+}
+
+func TestNewPricerWithKeyConfig(t *testing.T) {
+	// Setup:
+	var keyConfigsTestCase = []struct {
+		keyConfig   helpers.KeyConfig
+		scaleFactor float64
+	}{
+		{helpers.KeyConfig{
+			EncryptionKey:   "652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			IntegrityKey:    "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+			KeyDecodingMode: helpers.Hexa,
+		}, DefaultScaleFactor},
+		{helpers.KeyConfig{
+			EncryptionKey:   "ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU",
+			IntegrityKey:    "vQo9-4KtlcXmPhWaYvc8asqYuiSVMiGUdZ1RLXfrK7U",
+			IsBase64:        true,
+			KeyDecodingMode: helpers.Utf8,
+			ScaleFactor:     1000,
+		}, 1000},
+	}
+
+	for _, k := range keyConfigsTestCase {
+		// Execute:
+		pricer, err := NewPricer(WithKeyConfig(&k.keyConfig))
+
+		// Verify:
+		assert.Nil(t, err, "Error creating new Pricer : ", err)
+		assert.Equal(t, k.scaleFactor, pricer.scaleFactor)
+		encrypted, err := pricer.Encrypt("", 1.354)
+		assert.Nil(t, err)
+		decrypted, err := pricer.Decrypt(encrypted)
+		assert.Nil(t, err)
+		assert.InDelta(t, 1.354, decrypted, 0.001)
+	}
+}
+
+func TestNewPricerWithIntegerScaleFactor(t *testing.T) {
+	// Setup:
+	var scaleFactorsTestCase = []struct {
+		option Option
+		micros uint64
+	}{
+		{WithScaleFactor(1000000), 1004999},
+		{WithIntegerScaleFactor(1000000), 1005000},
+		// Last scale factor option wins
+		{func(c *config) { WithIntegerScaleFactor(1000000)(c); WithScaleFactor(1000000)(c) }, 1004999},
+	}
+
+	for _, s := range scaleFactorsTestCase {
+		pricer, err := NewPricer(
+			WithKeys(
+				"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+				"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+			),
+			s.option,
+		)
+		assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+		// Execute:
+		encrypted, err := pricer.Encrypt("", 1.005)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		micros, err := pricer.DecryptMicros(encrypted)
+
+		// Verify:
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+		assert.Equal(t, s.micros, micros)
+	}
+}
+
+func TestNewPricerWithNegativeIntegerScaleFactor(t *testing.T) {
+	// Execute:
+	pricer, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+		WithIntegerScaleFactor(-1000),
+	)
+
+	// Verify:
+	assert.Nil(t, pricer)
+	assert.True(t, errors.Is(err, ErrInvalidScaleFactor), "Unexpected error : %s", err)
+	assert.EqualError(t, err, "invalid scale factor: integer scale factor should be positive, got -1000")
+}
+
+func TestNewPricerWithInvalidScaleFactor(t *testing.T) {
+	// Setup:
+	var scaleFactorsTestCase = []struct {
+		scaleFactor float64
+		isValid     bool
+	}{
+		{0, false},
+		{-1000000, false},
+		{math.Copysign(0, -1), false},
+		{math.NaN(), false},
+		{math.Inf(1), false},
+		{math.Inf(-1), false},
+		{1000000, true},
+		{0.001, true},
+	}
+
+	for _, tt := range scaleFactorsTestCase {
+		// Execute:
+		pricer, err := NewPricer(
+			WithKeys(
+				"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+				"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+			),
+			WithScaleFactor(tt.scaleFactor),
+		)
+		_, legacyErr := buildNewDoubleClickPricer(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+			false,
+			helpers.Hexa,
+			tt.scaleFactor,
+			false,
+		)
+
+		// Verify:
+		if tt.isValid {
+			assert.Nil(t, err, "Error creating new Pricer : ", err)
+			assert.Nil(t, legacyErr, "Error creating new Pricer : ", legacyErr)
+		} else {
+			assert.Nil(t, pricer)
+			assert.True(t, errors.Is(err, ErrInvalidScaleFactor), "Unexpected error : %s", err)
+			assert.True(t, errors.Is(legacyErr, ErrInvalidScaleFactor), "Unexpected error : %s", legacyErr)
+		}
+	}
+
+	// Execute:
+	_, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+		WithScaleFactor(0),
+	)
+
+	// Verify:
+	assert.EqualError(t, err, "invalid scale factor: scale factor should be positive and finite, got 0")
+}
+
+func TestDecryptWithRoundingAndClamping(t *testing.T) {
+	// Setup:
+	var policiesTestCase = []struct {
+		options []Option
+		micros  uint64
+		price   float64
+	}{
+		// No policy by default
+		{nil, 1234567, 1.234567},
+		{[]Option{WithRounding(helpers.Nearest, 2)}, 1234567, 1.23},
+		{[]Option{WithRounding(helpers.Nearest, 2)}, 1235000, 1.24},
+		{[]Option{WithRounding(helpers.Floor, 2)}, 1239999, 1.23},
+		{[]Option{WithRounding(helpers.Ceil, 2)}, 1230001, 1.24},
+		{[]Option{WithRounding(helpers.Floor, 3)}, 1354000, 1.354},
+		// Floor
+		{[]Option{WithPriceFloor(0.5)}, 499999, 0.5},
+		{[]Option{WithPriceFloor(0.5)}, 500000, 0.5},
+		{[]Option{WithPriceFloor(0.5)}, 500001, 0.500001},
+		// Ceiling
+		{[]Option{WithPriceCeiling(10)}, 10000001, 10},
+		{[]Option{WithPriceCeiling(10)}, 10000000, 10},
+		{[]Option{WithPriceCeiling(10)}, 9999999, 9.999999},
+		// Clamping happens once rounded
+		{[]Option{WithRounding(helpers.Ceil, 1), WithPriceCeiling(1.25)}, 1210000, 1.25},
+		{[]Option{WithRounding(helpers.Floor, 1), WithPriceFloor(1.25)}, 1290000, 1.25},
+		{[]Option{WithPriceFloor(1), WithPriceCeiling(1)}, 3000000, 1},
+	}
+
+	for _, policy := range policiesTestCase {
+		pricer, err := NewPricer(append([]Option{
+			WithKeys(
+				"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+				"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+			),
+		}, policy.options...)...)
+		assert.Nil(t, err, "Error creating new Pricer : ", err)
+		encrypted, err := pricer.EncryptMicros("", policy.micros)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+
+		// Execute:
+		price, err := pricer.Decrypt(encrypted)
+		micros, microsErr := pricer.DecryptMicros(encrypted)
+
+		// Verify:
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+		assert.Equal(t, policy.price, price, "Decrypting %d micros", policy.micros)
+		assert.Nil(t, microsErr)
+		assert.Equal(t, policy.micros, micros, "Micros shouldn't be rounded nor clamped")
+	}
+}
+
+func TestNewPricerWithInvalidPolicies(t *testing.T) {
+	// Setup:
+	var policiesTestCase = []struct {
+		options []Option
+		err     string
+	}{
+		{[]Option{WithRounding("half-even", 2)}, "unknown rounding mode: half-even"},
+		{[]Option{WithRounding(helpers.Nearest, -1)}, "rounding decimals should be positive, got -1"},
+		{[]Option{WithPriceFloor(2), WithPriceCeiling(1)}, "price floor 2 is above price ceiling 1"},
+		{[]Option{WithMaxPrice(-1)}, "max price should be positive, got -1"},
+		{[]Option{WithMaxPrice(math.NaN())}, "max price should be positive, got NaN"},
+	}
+
+	for _, policy := range policiesTestCase {
+		// Execute:
+		pricer, err := NewPricer(append([]Option{
+			WithKeys(
+				"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+				"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+			),
+		}, policy.options...)...)
+
+		// Verify:
+		assert.Nil(t, pricer)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, policy.err, err.Error())
+		}
+	}
+}
+
+func TestEncryptWithMaxPrice(t *testing.T) {
+	// Setup:
+	pricer, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+		WithMaxPrice(100),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	var pricesTestCase = []struct {
+		price   float64
+		isValid bool
+	}{
+		{math.Nextafter(100, 0), true},
+		{100, true},
+		{math.Nextafter(100, 200), false},
+		{1e12, false},
+	}
+
+	for _, price := range pricesTestCase {
+		// Execute:
+		encrypted, err := pricer.Encrypt("seed", price.price)
+		_, appendErr := pricer.AppendEncrypt(nil, "seed", price.price)
+		_, rawErr := pricer.EncryptRaw("seed", price.price)
+		_, ivErr := pricer.EncryptWithIV([16]byte{}, price.price)
+
+		// Verify:
+		if price.isValid {
+			assert.Nil(t, err, "Encryption failed. Error : %s", err)
+			assert.Nil(t, appendErr, "Encryption failed. Error : %s", appendErr)
+			assert.Nil(t, rawErr, "Encryption failed. Error : %s", rawErr)
+			assert.Nil(t, ivErr, "Encryption failed. Error : %s", ivErr)
+			decrypted, err := pricer.Decrypt(encrypted)
+			assert.Nil(t, err, "Decryption failed. Error : %s", err)
+			assert.InDelta(t, price.price, decrypted, 0.000001)
+		} else {
+			assert.Equal(t, "", encrypted)
+			assert.True(t, errors.Is(err, ErrPriceAboveMax), "Unexpected error : %s", err)
+			assert.True(t, errors.Is(appendErr, ErrPriceAboveMax), "Unexpected error : %s", appendErr)
+			assert.True(t, errors.Is(rawErr, ErrPriceAboveMax), "Unexpected error : %s", rawErr)
+			assert.True(t, errors.Is(ivErr, ErrPriceAboveMax), "Unexpected error : %s", ivErr)
+		}
+	}
+
+	// Max price doesn't apply to micros, nor to self tests
+	_, err = pricer.EncryptMicros("seed", 1e12)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	lowMaxPricer, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+		WithMaxPrice(1),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	assert.Nil(t, lowMaxPricer.SelfTest())
+	_, err = lowMaxPricer.Encrypt("seed", 1.354)
+	assert.EqualError(t, err, "price above max price: 1.354, expected at most 1")
+}
+
+func TestNewPricerWithAutoKeyDecodingMode(t *testing.T) {
+	// Setup:
+	var keysTestCase = []struct {
+		encryptionKey string
+		integrityKey  string
+	}{
+		{"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5"},
+		{"ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU", "vQo9-4KtlcXmPhWaYvc8asqYuiSVMiGUdZ1RLXfrK7U"},
+		// Each key is detected on its own
+		{"ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU", "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5"},
+	}
+
+	for _, k := range keysTestCase {
+		pricer, err := NewPricer(
+			WithKeys(k.encryptionKey, k.integrityKey),
+			WithKeyDecodingMode(helpers.Auto),
+		)
+		assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+		// Execute:
+		result, err := pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+
+		// Verify:
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+		assert.InDelta(t, 1.354, result, 0.000001)
+	}
+
+	// Ambiguous keys
+	_, err := NewPricer(WithKeys("deadbeef", "deadbeef"), WithKeyDecodingMode(helpers.Auto))
+	assert.True(t, errors.Is(err, ErrInvalidKey), "Unexpected error : %s", err)
+	assert.True(t, errors.Is(err, helpers.ErrAmbiguousKey), "Unexpected error : %s", err)
+}
+
+func TestNewPricerWithKeyEncodings(t *testing.T) {
+	// Setup:
+	// The same keys in every supported representation
+	var keysTestCase = []struct {
+		encryptionKey string
+		integrityKey  string
+		mode          helpers.KeyDecodingMode
+	}{
+		{"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5", helpers.Hexa},
+		{"ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU", "vQo9-4KtlcXmPhWaYvc8asqYuiSVMiGUdZ1RLXfrK7U", helpers.WebSafeBase64},
+		{"ZS+DraBUUVeht/sMDgn1nnM3My/nq9TrEESbjubDkTU=", "vQo9+4KtlcXmPhWaYvc8asqYuiSVMiGUdZ1RLXfrK7U=", helpers.StdBase64},
+		{
+			"-----BEGIN ENCRYPTION KEY-----\nZS+DraBUUVeht/sMDgn1nnM3My/nq9TrEESbjubDkTU=\n-----END ENCRYPTION KEY-----\n",
+			"-----BEGIN INTEGRITY KEY-----\nvQo9+4KtlcXmPhWaYvc8asqYuiSVMiGUdZ1RLXfrK7U=\n-----END INTEGRITY KEY-----\n",
+			helpers.PEM,
+		},
+	}
+
+	for _, k := range keysTestCase {
+		pricer, err := NewPricer(
+			WithKeys(k.encryptionKey, k.integrityKey),
+			WithKeyDecodingMode(k.mode),
+		)
+		assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+		// Execute:
+		encrypted, err := pricer.Encrypt("", 1.354)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		result, err := pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+
+		// Verify:
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+		assert.InDelta(t, 1.354, result, 0.000001)
+		assert.Equal(t, "1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA", encrypted)
+	}
+
+	// PEM block holding a key of the wrong length
+	_, err := NewPricer(
+		WithKeys(
+			"-----BEGIN ENCRYPTION KEY-----\nZS+DraBUUVeht/sMDgn1nnM3My/nq9Tr\n-----END ENCRYPTION KEY-----\n",
+			"-----BEGIN INTEGRITY KEY-----\nvQo9+4KtlcXmPhWaYvc8asqYuiSVMiGUdZ1RLXfrK7U=\n-----END INTEGRITY KEY-----\n",
+		),
+		WithKeyDecodingMode(helpers.PEM),
+	)
+	assert.EqualError(t, err, "invalid key: encryption key: expected 32 bytes, got 24")
+}
+
+func TestNewPricerWithSurroundingWhitespaceKeys(t *testing.T) {
+	// Setup:
+	// Keys as read from files or secret managers
+	pricer, err := NewPricer(
+		WithKeys(
+			"  652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135\n",
+			"\tbd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5\r\n",
+		),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Execute:
+	encrypted, err := pricer.Encrypt("", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	result, err := pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+
+	// Verify:
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.InDelta(t, 1.354, result, 0.000001)
+	assert.Equal(t, "1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA", encrypted)
+}
+
+func TestDecryptStrict(t *testing.T) {
+	// Setup:
+	valid, err := base64.RawURLEncoding.DecodeString("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	assert.Nil(t, err)
+	withTrailingBytes := func(n int) []byte {
+		return append(append([]byte{}, valid...), make([]byte, n)...)
+	}
+
+	var pricesTestCase = []struct {
+		encrypted       string
+		acceptedLenient bool
+		acceptedStrict  bool
+	}{
+		// Exactly 28 bytes, padded or not
+		{"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", true, true},
+		{"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg==", true, true},
+		// 29+ bytes, padded or not
+		{base64.RawURLEncoding.EncodeToString(withTrailingBytes(1)), true, false},
+		{base64.URLEncoding.EncodeToString(withTrailingBytes(1)), true, false},
+		{base64.RawURLEncoding.EncodeToString(withTrailingBytes(2)), true, false},
+		{base64.RawURLEncoding.EncodeToString(withTrailingBytes(28)), true, false},
+		// Shorter ones are always rejected
+		{base64.RawURLEncoding.EncodeToString(valid[:27]), false, false},
+	}
+
+	for _, isStrict := range []bool{false, true} {
+		pricer, err := NewPricer(
+			WithKeys(
+				"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+				"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+			),
+			WithStrict(isStrict),
+		)
+		assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+		for _, price := range pricesTestCase {
+			accepted := price.acceptedLenient
+			if isStrict {
+				accepted = price.acceptedStrict
+			}
+
+			// Execute:
+			result, err := pricer.Decrypt(price.encrypted)
+
+			// Verify:
+			if accepted {
+				assert.Nil(t, err, "Decrypting %s (strict: %t) failed. Error : %s", price.encrypted, isStrict, err)
+				assert.InDelta(t, 1.354, result, 0.000001)
+			} else {
+				assert.True(t, errors.Is(err, ErrInvalidCiphertextLength), "Decrypting %s (strict: %t), unexpected error : %s", price.encrypted, isStrict, err)
+			}
+		}
+	}
+}
+
+func TestDecryptRawStrict(t *testing.T) {
+	// Setup:
+	valid, err := base64.RawURLEncoding.DecodeString("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	assert.Nil(t, err)
+	pricer, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+		WithStrict(true),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Execute:
+	_, validErr := pricer.DecryptRaw(valid)
+	_, trailingErr := pricer.DecryptRaw(append(valid, 0))
+
+	// Verify:
+	assert.Nil(t, validErr)
+	assert.True(t, errors.Is(trailingErr, ErrInvalidCiphertextLength), "Unexpected error : %s", trailingErr)
+}
@@ -0,0 +1,101 @@
+package doubleclick
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func TestPadOffsetRoundTrip(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithPadOffset(4))
+
+	// Execute:
+	encrypted, err := pricer.Encrypt("seed", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	decrypted, err := pricer.Decrypt(encrypted)
+
+	// Verify:
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.InDelta(t, 1.354, decrypted, 0.000001)
+	assert.Equal(t, 4, pricer.Config().PadOffset)
+}
+
+func TestPadOffsetDefault(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+	explicit := buildTestPricer(t, WithPadOffset(0))
+
+	// Execute:
+	encrypted, err := pricer.Encrypt("seed", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	explicitEncrypted, err := explicit.Encrypt("seed", 1.354)
+
+	// Verify:
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	assert.Equal(t, encrypted, explicitEncrypted)
+}
+
+func TestPadOffsetMismatch(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+	legacyPricer := buildTestPricer(t, WithPadOffset(4))
+	encrypted, err := pricer.Encrypt("seed", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	legacyEncrypted, err := legacyPricer.Encrypt("seed", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+
+	// Execute:
+	_, errLegacy := legacyPricer.Decrypt(encrypted)
+	_, errDefault := pricer.Decrypt(legacyEncrypted)
+
+	// Verify:
+	assert.NotEqual(t, encrypted, legacyEncrypted)
+	assert.True(t, errors.Is(errLegacy, ErrSignatureMismatch), "Unexpected error : %s", errLegacy)
+	assert.True(t, errors.Is(errDefault, ErrSignatureMismatch), "Unexpected error : %s", errDefault)
+}
+
+func TestNewPricerInvalidPadOffset(t *testing.T) {
+	var tests = []struct {
+		name          string
+		padOffset     int
+		hashAlgorithm helpers.HashAlgorithm
+		err           string
+	}{
+		{name: "negative", padOffset: -1, hashAlgorithm: helpers.SHA1, err: "pad offset should be between 0 and 12 bytes, got -1"},
+		{name: "past SHA-1 sums", padOffset: 13, hashAlgorithm: helpers.SHA1, err: "pad offset should be between 0 and 12 bytes, got 13"},
+		{name: "end of SHA-1 sums", padOffset: 12, hashAlgorithm: helpers.SHA1},
+		{name: "past SHA-256 sums", padOffset: 25, hashAlgorithm: helpers.SHA256, err: "pad offset should be between 0 and 24 bytes, got 25"},
+		{name: "end of SHA-256 sums", padOffset: 24, hashAlgorithm: helpers.SHA256},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute:
+			pricer, err := NewPricer(
+				WithKeys(
+					"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+					"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+				),
+				WithHashAlgorithm(tt.hashAlgorithm),
+				WithPadOffset(tt.padOffset),
+			)
+
+			// Verify:
+			if tt.err != "" {
+				assert.Nil(t, pricer)
+				assert.Equal(t, tt.err, err.Error())
+				return
+			}
+			assert.Nil(t, err, "Error creating new Pricer : ", err)
+			encrypted, err := pricer.Encrypt("seed", 1.354)
+			assert.Nil(t, err, "Encryption failed. Error : %s", err)
+			price, err := pricer.Decrypt(encrypted)
+			assert.Nil(t, err, "Decryption failed. Error : %s", err)
+			assert.InDelta(t, 1.354, price, 0.000001)
+		})
+	}
+}
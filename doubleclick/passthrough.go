@@ -0,0 +1,38 @@
+package doubleclick
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DecryptOrPassThrough decrypts an encrypted price as Decrypt does, tolerating
+// prices already decrypted, e.g. by an earlier partial run of a pipeline.
+// If s can't be decoded into an encrypted price message but parses as a finite,
+// non negative float, that float is returned as is and passedThrough is true.
+// Strings as long as an encoded message are never passed through, so that a
+// tampered encrypted price made of digits still returns ErrSignatureMismatch.
+func (dc *DoubleClickPricer) DecryptOrPassThrough(s string) (price float64, passedThrough bool, err error) {
+	price, err = dc.Decrypt(s)
+	if err == nil || !isUndecodable(err) {
+		return price, false, err
+	}
+
+	trimmed := strings.TrimSpace(s)
+	if len(trimmed) >= dc.EncodedLen() {
+		return price, false, err
+	}
+	plain, parseErr := strconv.ParseFloat(trimmed, 64)
+	if parseErr != nil || !(plain >= 0) || math.IsInf(plain, 1) {
+		return price, false, err
+	}
+
+	return plain, true, nil
+}
+
+// isUndecodable tells whether err is returned for strings which aren't
+// encrypted prices at all, rather than for encrypted prices failing to decrypt.
+func isUndecodable(err error) bool {
+	return errors.Is(err, ErrMalformedBase64) || errors.Is(err, ErrMalformedHex) || errors.Is(err, ErrInvalidCiphertextLength)
+}
@@ -0,0 +1,84 @@
+package doubleclick
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func TestDecryptOrPassThrough(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+
+	var tests = []struct {
+		name          string
+		input         string
+		price         float64
+		passedThrough bool
+		err           error
+	}{
+		{name: "encrypted price", input: "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", price: 1.354},
+		{name: "plain price", input: "1.23", price: 1.23, passedThrough: true},
+		{name: "plain integer price", input: "12", price: 12, passedThrough: true},
+		{name: "plain price with spaces", input: " 0.5\n", price: 0.5, passedThrough: true},
+		{name: "garbage", input: "not a price!", err: ErrMalformedBase64},
+		{name: "short garbage", input: "abcd", err: ErrInvalidCiphertextLength},
+		{name: "negative plain price", input: "-1.23", err: ErrMalformedBase64},
+		{name: "infinite plain price", input: "Inf", err: ErrInvalidCiphertextLength},
+		{name: "NaN", input: "NaN", err: ErrInvalidCiphertextLength},
+		{name: "tampered encrypted price", input: tamperSignature("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", 0), err: ErrSignatureMismatch},
+		// 38 digits decode into a whole message, whose signature doesn't match
+		{name: "digits as long as an encrypted price", input: "12345678901234567890123456789012345678", err: ErrSignatureMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute:
+			price, passedThrough, err := pricer.DecryptOrPassThrough(tt.input)
+
+			// Verify:
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err), "Unexpected error : %s", err)
+				assert.False(t, passedThrough)
+				return
+			}
+			assert.Nil(t, err, "Decryption failed. Error : %s", err)
+			assert.InDelta(t, tt.price, price, 0.000001)
+			assert.Equal(t, tt.passedThrough, passedThrough)
+		})
+	}
+}
+
+func TestDecryptOrPassThroughStrict(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithStrict(true))
+
+	// Execute:
+	_, passedThrough, err := pricer.DecryptOrPassThrough("1234567890123456789012345678901234567890")
+
+	// Verify:
+	assert.True(t, errors.Is(err, ErrInvalidCiphertextLength), "Unexpected error : %s", err)
+	assert.False(t, passedThrough)
+}
+
+func TestDecryptOrPassThroughHex(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithPriceEncoding(helpers.Hex))
+	encrypted, err := pricer.Encrypt("seed", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+
+	// Execute:
+	price, passedThrough, err := pricer.DecryptOrPassThrough(encrypted)
+	plain, plainPassedThrough, plainErr := pricer.DecryptOrPassThrough("1234")
+
+	// Verify:
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.InDelta(t, 1.354, price, 0.000001)
+	assert.False(t, passedThrough)
+	assert.Nil(t, plainErr, "Decryption failed. Error : %s", plainErr)
+	assert.Equal(t, float64(1234), plain)
+	assert.True(t, plainPassedThrough)
+}
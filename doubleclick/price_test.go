@@ -0,0 +1,95 @@
+package doubleclick
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptInvalidPrices(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+	negativePricer := buildTestPricer(t, WithNegativePrices(true))
+
+	var tests = []struct {
+		name           string
+		price          float64
+		negativeTooErr bool
+	}{
+		{name: "NaN", price: math.NaN(), negativeTooErr: true},
+		{name: "+Inf", price: math.Inf(1), negativeTooErr: true},
+		{name: "-Inf", price: math.Inf(-1), negativeTooErr: true},
+		{name: "negative", price: -1.354},
+		{name: "smallest negative", price: -math.SmallestNonzeroFloat64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute:
+			encrypted, err := pricer.Encrypt("seed", tt.price)
+			_, errAppend := pricer.AppendEncrypt(nil, "seed", tt.price)
+			_, errRaw := pricer.EncryptRaw("seed", tt.price)
+			_, errIV := pricer.EncryptWithIV([16]byte{}, tt.price)
+			_, errNegative := negativePricer.Encrypt("seed", tt.price)
+
+			// Verify:
+			assert.Equal(t, "", encrypted)
+			for _, err := range []error{err, errAppend, errRaw, errIV} {
+				assert.True(t, errors.Is(err, ErrInvalidPrice), "Unexpected error : %s", err)
+			}
+			assert.Equal(t, tt.negativeTooErr, errors.Is(errNegative, ErrInvalidPrice), "Unexpected error : %s", errNegative)
+		})
+	}
+}
+
+func TestEncryptDecryptNegativePrices(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithNegativePrices(true))
+
+	for _, price := range []float64{-1.354, -0.000001, -1000, 0, 1.354} {
+		// Execute:
+		encrypted, err := pricer.Encrypt("seed", price)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		decrypted, err := pricer.Decrypt(encrypted)
+
+		// Verify:
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+		assert.InDelta(t, price, decrypted, 0.000001)
+	}
+}
+
+func TestEncryptNegativePriceMicros(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithNegativePrices(true))
+	unsignedPricer := buildTestPricer(t)
+
+	// Execute:
+	encrypted, err := pricer.Encrypt("seed", -1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	micros, err := pricer.DecryptMicros(encrypted)
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	_, errUnsigned := unsignedPricer.Decrypt(encrypted)
+
+	// Verify:
+	assert.Equal(t, int64(-1354000), int64(micros))
+	// Without negative prices, the top bit being set means a corrupted price
+	assert.True(t, errors.Is(errUnsigned, ErrPriceOutOfRange), "Unexpected error : %s", errUnsigned)
+}
+
+func TestEncryptNegativePricesOverflow(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithNegativePrices(true), WithScaleFactor(1))
+
+	// Execute:
+	_, errMin := pricer.Encrypt("seed", math.MinInt64)
+	_, errMax := pricer.Encrypt("seed", math.MaxInt64)
+	_, errBelowMin := pricer.Encrypt("seed", -(1 << 64))
+
+	// Verify:
+	assert.Nil(t, errMin, "Unexpected error : %s", errMin)
+	// 2^63 - 1 isn't a float, math.MaxInt64 rounds to 2^63
+	assert.True(t, errors.Is(errMax, ErrPriceOverflow), "Unexpected error : %s", errMax)
+	assert.True(t, errors.Is(errBelowMin, ErrPriceOverflow), "Unexpected error : %s", errBelowMin)
+}
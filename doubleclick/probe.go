@@ -0,0 +1,81 @@
+package doubleclick
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+// Plausible range of prices ProbeScaleFactor looks for, e.g. CPMs in dollars.
+const (
+	ProbeMinPrice = 0.01
+	ProbeMaxPrice = 100
+)
+
+// ProbeScaleFactor guesses which of candidates is the scale factor encrypted
+// prices of samples were encrypted with, e.g. for an undocumented data feed.
+// Samples are decrypted to micros once, then converted under each candidate.
+// The candidate giving the most prices between ProbeMinPrice and ProbeMaxPrice
+// is returned, ties going to the candidate whose prices are closest to the middle
+// of that range on a log scale, then to the first one.
+//
+// This is a heuristic, meant for diagnostics rather than for configuring
+// production pricers: nothing in an encrypted price tells its scale factor,
+// and candidates a power of ten apart often both look plausible.
+// Samples failing to decrypt return their error, since they hint at wrong
+// keys rather than at a wrong scale factor. ErrNoPlausibleScaleFactor is returned
+// if no candidate gives any price in range.
+func (dc *DoubleClickPricer) ProbeScaleFactor(samples []string, candidates []float64) (float64, error) {
+	if len(samples) == 0 {
+		return 0, errors.New("no samples to probe")
+	}
+	for _, candidate := range candidates {
+		if !(candidate > 0) || math.IsInf(candidate, 1) {
+			return 0, fmt.Errorf("%w: candidate %v", ErrInvalidScaleFactor, candidate)
+		}
+	}
+
+	micros := make([]uint64, len(samples))
+	for i, sample := range samples {
+		var err error
+		if micros[i], err = dc.DecryptMicros(sample); err != nil {
+			return 0, fmt.Errorf("sample %d: %w", i, err)
+		}
+	}
+
+	best, bestInRange, bestDistance := 0.0, 0, math.Inf(1)
+	for _, candidate := range candidates {
+		inRange, distance := probe(micros, candidate)
+		if inRange > bestInRange || (inRange == bestInRange && inRange > 0 && distance < bestDistance) {
+			best, bestInRange, bestDistance = candidate, inRange, distance
+		}
+	}
+	if bestInRange == 0 {
+		return 0, ErrNoPlausibleScaleFactor
+	}
+
+	return best, nil
+}
+
+// probe returns how many of micros, converted under scaleFactor, are plausible prices,
+// and how far they are on average from the middle of the plausible range, on a log scale.
+func probe(micros []uint64, scaleFactor float64) (inRange int, distance float64) {
+	middle := math.Log10(math.Sqrt(ProbeMinPrice * ProbeMaxPrice))
+
+	var total float64
+	for _, m := range micros {
+		price := helpers.MicrosToPrice(m, scaleFactor)
+		if price < ProbeMinPrice || price > ProbeMaxPrice {
+			continue
+		}
+		inRange++
+		total += math.Abs(math.Log10(price) - middle)
+	}
+	if inRange == 0 {
+		return 0, math.Inf(1)
+	}
+
+	return inRange, total / float64(inRange)
+}
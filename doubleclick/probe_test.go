@@ -0,0 +1,76 @@
+package doubleclick
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// encryptProbeSamples returns micros encrypted as is, whatever the scale factor.
+func encryptProbeSamples(t *testing.T, pricer *DoubleClickPricer, micros ...uint64) []string {
+	samples := make([]string, len(micros))
+	for i, m := range micros {
+		var err error
+		samples[i], err = pricer.EncryptMicros("seed", m)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	}
+
+	return samples
+}
+
+func TestProbeScaleFactor(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+	candidates := []float64{1, 100, 10000, 1000000, 1000000000}
+
+	var tests = []struct {
+		name        string
+		micros      []uint64
+		scaleFactor float64
+	}{
+		// 0.5, 1.354, 2.5 and 12.75 CPMs
+		{name: "micros", micros: []uint64{500000, 1354000, 2500000, 12750000}, scaleFactor: 1000000},
+		{name: "ten thousandths", micros: []uint64{5000, 13540, 25000, 127500}, scaleFactor: 10000},
+		{name: "cents", micros: []uint64{50, 135, 250, 1275}, scaleFactor: 100},
+		// Both 10000 and 1000000 give these prices in range, 1000000 giving prices closer to 1
+		{name: "tie broken by middle", micros: []uint64{1354000, 2500000}, scaleFactor: 1000000},
+		// A corrupted outlier doesn't change the majority
+		{name: "outlier", micros: []uint64{500000, 1354000, 2500000, 1 << 40}, scaleFactor: 1000000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute:
+			scaleFactor, err := pricer.ProbeScaleFactor(encryptProbeSamples(t, pricer, tt.micros...), candidates)
+
+			// Verify:
+			assert.Nil(t, err, "Probing failed. Error : %s", err)
+			assert.Equal(t, tt.scaleFactor, scaleFactor)
+		})
+	}
+}
+
+func TestProbeScaleFactorErrors(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+	samples := encryptProbeSamples(t, pricer, 1354000)
+
+	// Execute:
+	_, errNoSamples := pricer.ProbeScaleFactor(nil, []float64{1000000})
+	_, errCandidate := pricer.ProbeScaleFactor(samples, []float64{1000000, 0})
+	_, errInfinite := pricer.ProbeScaleFactor(samples, []float64{math.Inf(1)})
+	_, errTampered := pricer.ProbeScaleFactor([]string{samples[0], tamperSignature(samples[0], 0)}, []float64{1000000})
+	_, errNoCandidate := pricer.ProbeScaleFactor(samples, nil)
+	_, errImplausible := pricer.ProbeScaleFactor(samples, []float64{1, 1000000000})
+
+	// Verify:
+	assert.EqualError(t, errNoSamples, "no samples to probe")
+	assert.True(t, errors.Is(errCandidate, ErrInvalidScaleFactor), "Unexpected error : %s", errCandidate)
+	assert.True(t, errors.Is(errInfinite, ErrInvalidScaleFactor), "Unexpected error : %s", errInfinite)
+	assert.True(t, errors.Is(errTampered, ErrSignatureMismatch), "Unexpected error : %s", errTampered)
+	assert.EqualError(t, errTampered, "sample 1: Failed to decrypt")
+	assert.True(t, errors.Is(errNoCandidate, ErrNoPlausibleScaleFactor), "Unexpected error : %s", errNoCandidate)
+	assert.True(t, errors.Is(errImplausible, ErrNoPlausibleScaleFactor), "Unexpected error : %s", errImplausible)
+}
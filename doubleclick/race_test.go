@@ -0,0 +1,7 @@
+//go:build race
+
+package doubleclick
+
+// raceEnabled tells whether tests run with the race detector, which makes
+// sync.Pool drop items at random, so pooled paths allocate.
+const raceEnabled = true
@@ -0,0 +1,87 @@
+package doubleclick
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptRandom(t *testing.T) {
+	// Setup:
+	randomBytes := []byte("0123456789abcdefFEDCBA9876543210")
+	pricer := buildTestPricer(t, WithRandomSource(bytes.NewReader(randomBytes)))
+
+	for _, iv := range [][]byte{randomBytes[:16], randomBytes[16:]} {
+		// Execute:
+		encrypted, err := pricer.EncryptRandom(1.354)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		result, err := pricer.DecryptDetailed(encrypted)
+
+		// Verify:
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+		assert.Equal(t, iv, result.IV[:])
+		assert.True(t, result.IntegrityValid)
+		assert.Equal(t, uint64(1354000), result.PriceMicros)
+	}
+}
+
+func TestEncryptRandomMatchesEncryptWithIV(t *testing.T) {
+	// Setup:
+	var iv [16]byte
+	copy(iv[:], "0123456789abcdef")
+	pricer := buildTestPricer(t, WithRandomSource(bytes.NewReader(iv[:])))
+
+	// Execute:
+	encrypted, err := pricer.EncryptRandom(1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	expected, err := pricer.EncryptWithIV(iv, 1.354)
+
+	// Verify:
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	assert.Equal(t, expected, encrypted)
+}
+
+func TestEncryptRandomDefaultSource(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+
+	// Execute:
+	first, err := pricer.EncryptRandom(1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	second, err := pricer.EncryptRandom(1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+
+	// Verify:
+	assert.NotEqual(t, first, second)
+}
+
+func TestEncryptRandomShortSource(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithRandomSource(bytes.NewReader([]byte("short"))))
+
+	// Execute:
+	encrypted, err := pricer.EncryptRandom(1.354)
+
+	// Verify:
+	assert.NotNil(t, err)
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF), "Unexpected error : %s", err)
+	assert.Equal(t, "", encrypted)
+}
+
+func TestNewPricerNilRandomSource(t *testing.T) {
+	// Execute:
+	pricer, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+		WithRandomSource(nil),
+	)
+
+	// Verify:
+	assert.Nil(t, pricer)
+	assert.Equal(t, "random source is nil", err.Error())
+}
@@ -0,0 +1,121 @@
+package doubleclick
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// referenceEncrypt encrypts micros following the specs algorithm step by step,
+// independently from the pricer and its helpers:
+//
+//	iv = md5(seed)
+//	pad = hmac(e_key, iv), first 8 bytes
+//	enc_price = pad <xor> price
+//	signature = hmac(i_key, price || iv), first 4 bytes
+//	final_message = WebSafeBase64Encode(iv || enc_price || signature)
+func referenceEncrypt(encryptionKey []byte, integrityKey []byte, seed string, micros uint64) string {
+	iv := md5.Sum([]byte(seed))
+
+	price := make([]byte, 8)
+	binary.BigEndian.PutUint64(price, micros)
+
+	padHmac := hmac.New(sha1.New, encryptionKey)
+	padHmac.Write(iv[:])
+	pad := padHmac.Sum(nil)[:8]
+
+	encPrice := make([]byte, 8)
+	for i := range price {
+		encPrice[i] = pad[i] ^ price[i]
+	}
+
+	signatureHmac := hmac.New(sha1.New, integrityKey)
+	signatureHmac.Write(price)
+	signatureHmac.Write(iv[:])
+	signature := signatureHmac.Sum(nil)[:4]
+
+	message := append(append(append([]byte{}, iv[:]...), encPrice...), signature...)
+
+	return base64.URLEncoding.WithPadding(base64.NoPadding).EncodeToString(message)
+}
+
+// referenceMicros returns micros spanning the whole price range: zero, byte
+// boundaries where price bytes roll over, float precision limits and largest.
+func referenceMicros() []uint64 {
+	micros := []uint64{0, 1, 10, 100, 1000, 10000, 1354000, 1000000, 999999, 123456789}
+	for shift := uint(8); shift < 64; shift += 8 {
+		micros = append(micros, 1<<shift-1, 1<<shift, 1<<shift+1)
+	}
+	micros = append(micros, 1<<53-1, 1<<53, 1<<53+1, math.MaxInt64, 1<<63, math.MaxUint64-1, math.MaxUint64)
+
+	return micros
+}
+
+func TestReferenceImplementation(t *testing.T) {
+	// Setup:
+	encryptionKeyHex := "652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135"
+	integrityKeyHex := "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5"
+	encryptionKey, _ := hex.DecodeString(encryptionKeyHex)
+	integrityKey, _ := hex.DecodeString(integrityKeyHex)
+	pricer, err := NewPricer(WithKeys(encryptionKeyHex, integrityKeyHex), WithMaxMicros(NoMaxMicros))
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	// The reference itself matches a known vector
+	assert.Equal(t, "1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA", referenceEncrypt(encryptionKey, integrityKey, "", 1354000))
+
+	seeds := []string{"", "seed", "pricers-reference", "0123456789abcdef0123456789abcdef"}
+	var cases int
+	for _, micros := range referenceMicros() {
+		for _, seed := range seeds {
+			cases++
+			expected := referenceEncrypt(encryptionKey, integrityKey, seed, micros)
+
+			// Execute:
+			encrypted, err := pricer.EncryptMicros(seed, micros)
+			decrypted, decryptErr := pricer.DecryptMicros(expected)
+
+			// Verify:
+			description := fmt.Sprintf("micros %d, seed %q", micros, seed)
+			assert.Nil(t, err, "Encryption failed (%s). Error : %s", description, err)
+			assert.Equal(t, expected, encrypted, "Encrypted price diverges from reference (%s)", description)
+			assert.Nil(t, decryptErr, "Decryption failed (%s). Error : %s", description, decryptErr)
+			assert.Equal(t, micros, decrypted, "Decrypted price diverges from reference (%s)", description)
+		}
+	}
+	assert.True(t, cases >= 50, "Only %d reference cases", cases)
+}
+
+func TestReferenceImplementationPrices(t *testing.T) {
+	// Setup:
+	encryptionKeyHex := "652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135"
+	integrityKeyHex := "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5"
+	encryptionKey, _ := hex.DecodeString(encryptionKeyHex)
+	integrityKey, _ := hex.DecodeString(integrityKeyHex)
+	pricer, err := NewPricer(WithKeys(encryptionKeyHex, integrityKeyHex))
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	for i := 0; i <= 100; i++ {
+		// Prices from 0 to 10,000, spread on a log scale
+		price := math.Floor(math.Pow(10, float64(i)/25)*1000000) / 1000000
+		if i == 0 {
+			price = 0
+		}
+		seed := fmt.Sprintf("reference-%d", i)
+		// Specs scaling: micros = price * 1,000,000, truncated
+		expected := referenceEncrypt(encryptionKey, integrityKey, seed, uint64(price*1000000))
+
+		// Execute:
+		encrypted, err := pricer.Encrypt(seed, price)
+
+		// Verify:
+		assert.Nil(t, err, "Encryption failed (price %g). Error : %s", price, err)
+		assert.Equal(t, expected, encrypted, "Encrypted price diverges from reference (price %g)", price)
+	}
+}
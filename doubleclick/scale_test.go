@@ -0,0 +1,40 @@
+package doubleclick
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaleFactorsPerAccount(t *testing.T) {
+	// Setup:
+	// Both accounts share keys, one counting micros, the other ten thousandths of its currency.
+	microsAccount := buildTestPricer(t, WithScaleFactor(1000000))
+	tenThousandthsAccount := buildTestPricer(t, WithScaleFactor(10000))
+
+	// Execute:
+	fromMicrosAccount, trace, err := microsAccount.EncryptWithTrace("seed", 1.5)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	fromTenThousandthsAccount, otherTrace, err := tenThousandthsAccount.EncryptWithTrace("seed", 150)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	fromMicros, err := tenThousandthsAccount.EncryptMicros("seed", 1500000)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+
+	// Verify:
+	// Same raw integer, same bytes and signature, whatever the scale factor
+	assert.Equal(t, fromMicrosAccount, fromTenThousandthsAccount)
+	assert.Equal(t, fromMicrosAccount, fromMicros)
+	assert.Equal(t, trace.Signature, otherTrace.Signature)
+	assert.Equal(t, uint64(1500000), trace.Micros)
+	for _, pricer := range []*DoubleClickPricer{microsAccount, tenThousandthsAccount} {
+		micros, err := pricer.DecryptMicros(fromMicrosAccount)
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+		assert.Equal(t, uint64(1500000), micros)
+	}
+	price, err := microsAccount.Decrypt(fromMicrosAccount)
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.Equal(t, 1.5, price)
+	price, err = tenThousandthsAccount.Decrypt(fromMicrosAccount)
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.Equal(t, 150.0, price)
+}
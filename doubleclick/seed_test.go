@@ -0,0 +1,87 @@
+package doubleclick
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func TestEncryptSeedValidation(t *testing.T) {
+	var tests = []struct {
+		name          string
+		minSeedLength int
+		seed          string
+		err           string
+	}{
+		{name: "empty seed accepted by default", minSeedLength: 0, seed: ""},
+		{name: "empty seed rejected", minSeedLength: 1, seed: "", err: "invalid seed: seed is empty"},
+		{name: "seed accepted", minSeedLength: 1, seed: "a"},
+		{name: "short seed rejected", minSeedLength: 16, seed: "short", err: "invalid seed: expected at least 16 bytes, got 5"},
+		{name: "long enough seed accepted", minSeedLength: 16, seed: "0123456789abcdef"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup:
+			pricer := buildTestPricer(t, WithMinSeedLength(tt.minSeedLength))
+
+			// Execute:
+			encrypted, encryptErr := pricer.Encrypt(tt.seed, 1.354)
+			_, appendErr := pricer.AppendEncrypt(nil, tt.seed, 1.354)
+			_, rawErr := pricer.EncryptRaw(tt.seed, 1.354)
+			_, microsErr := pricer.EncryptMicros(tt.seed, 1354000)
+
+			// Verify:
+			if tt.err == "" {
+				assert.Nil(t, encryptErr, "Unexpected error : %s", encryptErr)
+				assert.NotEmpty(t, encrypted)
+				assert.Nil(t, appendErr, "Unexpected error : %s", appendErr)
+				assert.Nil(t, rawErr, "Unexpected error : %s", rawErr)
+				assert.Nil(t, microsErr, "Unexpected error : %s", microsErr)
+				return
+			}
+			assert.Empty(t, encrypted)
+			for _, err := range []error{encryptErr, appendErr, rawErr, microsErr} {
+				assert.True(t, errors.Is(err, ErrInvalidSeed), "Unexpected error : %s", err)
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestEncryptDistinctSeedsDistinctIVs(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithMinSeedLength(1))
+	ivs := make(map[[16]byte]string)
+
+	for i := 0; i < 100; i++ {
+		seed := helpers.NewSeed()
+
+		// Execute:
+		encrypted, err := pricer.Encrypt(seed, 1.354)
+		require.NoError(t, err, "Encryption failed. Error : %s", err)
+		result, err := pricer.DecryptDetailed(encrypted)
+		require.NoError(t, err, "Decryption failed. Error : %s", err)
+
+		// Verify:
+		previous, isDuplicate := ivs[result.IV]
+		assert.False(t, isDuplicate, "Seeds %s and %s share the same IV", previous, seed)
+		ivs[result.IV] = seed
+	}
+}
+
+func TestNewPricerInvalidMinSeedLength(t *testing.T) {
+	// Execute:
+	pricer, err := NewPricer(
+		WithKeys(testEncryptionKey, testIntegrityKey),
+		WithMinSeedLength(-1),
+	)
+
+	// Verify:
+	assert.Nil(t, pricer)
+	assert.EqualError(t, err, "minimum seed length should be positive, got -1")
+}
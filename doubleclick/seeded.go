@@ -0,0 +1,86 @@
+package doubleclick
+
+import (
+	"fmt"
+	"time"
+)
+
+// SeededEncryptor encrypts prices with a single seed, its pad hmac(e_key, iv)
+// being computed once rather than for every price.
+//
+// Reusing a seed reuses its Initialization Vector: every price encrypted with
+// a SeededEncryptor is XORed with the very same pad, so that anyone holding two
+// of its encrypted prices learns whether their prices are equal, and the XOR of
+// their micros. A SeededEncryptor should then only be used where IVs are fixed
+// anyway, such as test harnesses or exchanges mandating a fixed IV, and never
+// to encrypt prices sent to third parties otherwise.
+//
+// A SeededEncryptor encrypts exactly as its pricer Encrypt does with the same seed.
+// Keys replaced by SetKeys since it was built are used as well, the pad being
+// computed again for each price until a new SeededEncryptor is built.
+// It is safe for concurrent use by multiple goroutines.
+type SeededEncryptor struct {
+	pricer *DoubleClickPricer
+	iv     [16]byte
+	pad    [8]byte
+	// keys are the pricer keys pad was computed with.
+	keys *pricerKeys
+}
+
+// NewSeededEncryptor returns a SeededEncryptor encrypting prices with seed,
+// see SeededEncryptor for the risks of reusing a seed. seed is checked
+// against the pricer minimum seed length, returning ErrInvalidSeed.
+func (dc *DoubleClickPricer) NewSeededEncryptor(seed string) (*SeededEncryptor, error) {
+	if err := dc.validateSeed(seed); err != nil {
+		return nil, err
+	}
+
+	state, err := dc.acquireState()
+	if err != nil {
+		return nil, err
+	}
+	defer dc.releaseState(state)
+
+	iv := dc.seedIV(seed)
+	pad, err := state.core.Pad(iv)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEncrypt, err)
+	}
+
+	return &SeededEncryptor{pricer: dc, iv: iv, pad: pad, keys: state.keys}, nil
+}
+
+// Encrypt encrypts a clear price with the encryptor seed.
+func (e *SeededEncryptor) Encrypt(price float64) (encrypted string, err error) {
+	dc := e.pricer
+	if dc.observer != nil {
+		defer dc.observeEncrypt(time.Now(), &err)
+	}
+
+	data, err := dc.scalePrice(price)
+	if err != nil {
+		return "", err
+	}
+	if dc.isDebugMode == true {
+		dc.logger.Debugf("Micro price bytes : %v", data)
+	}
+
+	state, err := dc.acquireState()
+	if err != nil {
+		return "", err
+	}
+	defer dc.releaseState(state)
+
+	if state.keys != e.keys {
+		return dc.encryptWith(state, e.iv, data)
+	}
+
+	sealed, err := state.core.SealWithPad(e.iv, e.pad, data)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrEncrypt, err)
+	}
+
+	// final_message = WebSafeBase64Encode( iv || enc_price || signature )
+	state.encoded = dc.appendEncoded(state.encoded[:0], sealed.Message[:dc.messageLength()])
+	return string(state.encoded), nil
+}
@@ -0,0 +1,90 @@
+package doubleclick
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func TestSeededEncryptorMatchesEncrypt(t *testing.T) {
+	for _, opts := range [][]Option{
+		nil,
+		{WithSignatureLength(8)},
+		{WithUnsigned(true)},
+	} {
+		// Setup:
+		pricer := buildTestPricer(t, opts...)
+		encryptor, err := pricer.NewSeededEncryptor("seed")
+		assert.Nil(t, err, "Error creating new SeededEncryptor : ", err)
+
+		for _, price := range []float64{0, 0.01, 1.354, 12.75, 1000} {
+			// Execute:
+			fromEncryptor, err := encryptor.Encrypt(price)
+			assert.Nil(t, err, "Encryption failed. Error : %s", err)
+			fromPricer, err := pricer.Encrypt("seed", price)
+			assert.Nil(t, err, "Encryption failed. Error : %s", err)
+			decrypted, err := pricer.Decrypt(fromEncryptor)
+
+			// Verify:
+			assert.Nil(t, err, "Decryption failed. Error : %s", err)
+			assert.Equal(t, fromPricer, fromEncryptor)
+			assert.InDelta(t, price, decrypted, 0.000001)
+		}
+	}
+}
+
+func TestSeededEncryptorAfterSetKeys(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+	encryptor, err := pricer.NewSeededEncryptor("seed")
+	assert.Nil(t, err, "Error creating new SeededEncryptor : ", err)
+	err = pricer.SetKeys(
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		false, // Keys are not base64
+		helpers.Hexa,
+	)
+	assert.Nil(t, err, "Error setting keys : ", err)
+
+	// Execute:
+	fromEncryptor, err := encryptor.Encrypt(1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	fromPricer, err := pricer.Encrypt("seed", 1.354)
+
+	// Verify:
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	assert.Equal(t, fromPricer, fromEncryptor)
+}
+
+func TestSeededEncryptorErrors(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithMinSeedLength(8), WithMaxPrice(10))
+
+	// Execute:
+	encryptor, errSeed := pricer.NewSeededEncryptor("seed")
+	longEncryptor, err := pricer.NewSeededEncryptor("long seed")
+	assert.Nil(t, err, "Error creating new SeededEncryptor : ", err)
+	_, errAboveMax := longEncryptor.Encrypt(50)
+
+	// Verify:
+	assert.Nil(t, encryptor)
+	assert.True(t, errors.Is(errSeed, ErrInvalidSeed), "Unexpected error : %s", errSeed)
+	assert.True(t, errors.Is(errAboveMax, ErrPriceAboveMax), "Unexpected error : %s", errAboveMax)
+}
+
+func BenchmarkSeededEncryptor(b *testing.B) {
+	pricer := buildBenchmarkPricer(b)
+	encryptor, err := pricer.NewSeededEncryptor("")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		encryptor.Encrypt(1.354)
+	}
+}
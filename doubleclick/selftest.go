@@ -0,0 +1,83 @@
+package doubleclick
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	// selfTestSeed and selfTestPrice are the seed and sentinel price
+	// SelfTest round-trips.
+	selfTestSeed  = "pricers-self-test"
+	selfTestPrice = 1.354
+)
+
+// ErrSelfTest is returned when a pricer self test fails.
+var ErrSelfTest = errors.New("self test failed")
+
+// SelfTest encrypts a sentinel price with a fixed seed and decrypts it back,
+// returning ErrSelfTest if the round-trip doesn't match, e.g. because the
+// scale factor loses the price precision. Rounding and clamping policies
+// aren't applied. It is cheap enough to be called from a health check.
+// Encrypting and decrypting with the same keys, it can't tell swapped or
+// wrongly decoded keys apart, see SelfTestWith.
+// Errors never hold key material.
+func (dc *DoubleClickPricer) SelfTest() error {
+	// The sentinel price is scaled whatever the pricer max price.
+	data, err := dc.scale(selfTestPrice)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSelfTest, err)
+	}
+
+	state, err := dc.acquireState()
+	if err != nil {
+		return err
+	}
+	defer dc.releaseState(state)
+
+	message, err := dc.encryptRawWith(state, dc.seedIV(selfTestSeed), data)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSelfTest, err)
+	}
+	opened, _, err := dc.openRawWith(state, message[:dc.messageLength()])
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSelfTest, err)
+	}
+	if !opened.IsIntegrityValid && !dc.isUnsigned {
+		return fmt.Errorf("%w: %w", ErrSelfTest, ErrSignatureMismatch)
+	}
+
+	return checkSelfTestPrice(dc.rawPrice(opened.Price), selfTestPrice)
+}
+
+// SelfTestWith decrypts a reference encrypted price, e.g. one provided by the
+// exchange along with the keys, and checks it matches price. Unlike SelfTest,
+// it catches swapped keys, wrong key decoding mode or wrong scale factor.
+// Rounding and clamping policies aren't applied.
+// Errors never hold key material.
+func (dc *DoubleClickPricer) SelfTestWith(encryptedPrice string, price float64) error {
+	// Self tests aren't reported to the pricer observer, hence no decrypt state.
+	state, err := dc.acquireState()
+	if err != nil {
+		return err
+	}
+	defer dc.releaseState(state)
+	priceMicro, err := dc.decryptWith(state, encryptedPrice)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSelfTest, err)
+	}
+
+	return checkSelfTestPrice(dc.rawPrice(priceMicro), price)
+}
+
+// checkSelfTestPrice returns ErrSelfTest if decrypted isn't expected,
+// float noise from the scale factor division aside. NaN, e.g. from a zero
+// scale factor, never matches.
+func checkSelfTestPrice(decrypted float64, expected float64) error {
+	if !(math.Abs(decrypted-expected) <= 1e-9*math.Max(1, math.Abs(expected))) {
+		return fmt.Errorf("%w: expected price %g, got %g", ErrSelfTest, expected, decrypted)
+	}
+
+	return nil
+}
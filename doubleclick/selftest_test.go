@@ -0,0 +1,86 @@
+package doubleclick
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func TestSelfTest(t *testing.T) {
+	// Setup:
+	var pricersTestCase = []struct {
+		options []Option
+		isValid bool
+	}{
+		{[]Option{}, true},
+		{[]Option{WithIntegerScaleFactor(1000)}, true},
+		// Policies aren't applied
+		{[]Option{WithRounding(helpers.Floor, 0), WithPriceCeiling(1)}, true},
+		// Scale factor loses the price precision
+		{[]Option{WithScaleFactor(1)}, false},
+		{[]Option{WithScaleFactor(0.001)}, false},
+	}
+
+	for _, p := range pricersTestCase {
+		pricer, err := NewPricer(append([]Option{
+			WithKeys(
+				"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+				"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+			),
+		}, p.options...)...)
+		assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+		// Execute:
+		err = pricer.SelfTest()
+
+		// Verify:
+		if p.isValid {
+			assert.Nil(t, err, "Self test failed. Error : %s", err)
+		} else {
+			assert.True(t, errors.Is(err, ErrSelfTest), "Unexpected error : %s", err)
+		}
+	}
+}
+
+func TestSelfTestWith(t *testing.T) {
+	// Setup:
+	var pricersTestCase = []struct {
+		encryptionKey   string
+		integrityKey    string
+		isBase64Keys    bool
+		keyDecodingMode helpers.KeyDecodingMode
+		scaleFactor     float64
+		isValid         bool
+	}{
+		{"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5", false, helpers.Hexa, 1000000, true},
+		{"ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU", "vQo9-4KtlcXmPhWaYvc8asqYuiSVMiGUdZ1RLXfrK7U", true, helpers.Utf8, 1000000, true},
+		// Swapped keys
+		{"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5", "652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", false, helpers.Hexa, 1000000, false},
+		// Wrong key decoding mode
+		{"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5", false, helpers.Utf8, 1000000, false},
+		// Wrong scale factor
+		{"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5", false, helpers.Hexa, 1000, false},
+	}
+
+	for _, p := range pricersTestCase {
+		pricer, err := buildNewDoubleClickPricer(p.encryptionKey, p.integrityKey, p.isBase64Keys, p.keyDecodingMode, p.scaleFactor, false)
+		assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+		// Execute:
+		err = pricer.SelfTestWith("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", 1.354)
+
+		// Verify:
+		if p.isValid {
+			assert.Nil(t, err, "Self test failed. Error : %s", err)
+			continue
+		}
+		if assert.True(t, errors.Is(err, ErrSelfTest), "Unexpected error : %s", err) {
+			assert.False(t, strings.Contains(err.Error(), p.encryptionKey), "Error shouldn't hold keys")
+			assert.False(t, strings.Contains(err.Error(), p.integrityKey), "Error shouldn't hold keys")
+		}
+	}
+}
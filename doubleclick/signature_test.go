@@ -0,0 +1,110 @@
+package doubleclick
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func TestSignatureLengthRoundTrip(t *testing.T) {
+	for _, signatureLength := range []int{4, 8} {
+		// Setup:
+		pricer := buildTestPricer(t, WithSignatureLength(signatureLength))
+
+		// Execute:
+		encrypted, trace, err := pricer.EncryptWithTrace("seed", 1.354)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		decoded, decodeErr := base64.RawURLEncoding.DecodeString(encrypted)
+		decrypted, err := pricer.Decrypt(encrypted)
+
+		// Verify:
+		assert.Nil(t, decodeErr, "Unexpected error : %s", decodeErr)
+		assert.Len(t, decoded, 24+signatureLength)
+		assert.Equal(t, decoded[24:], trace.Signature)
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+		assert.InDelta(t, 1.354, decrypted, 0.000001)
+	}
+}
+
+func TestSignatureLengthDefault(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+	explicit := buildTestPricer(t, WithSignatureLength(4))
+
+	// Execute:
+	encrypted, err := pricer.Encrypt("", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	explicitEncrypted, err := explicit.Encrypt("", 1.354)
+
+	// Verify:
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	assert.Len(t, encrypted, 38)
+	assert.Equal(t, encrypted, explicitEncrypted)
+}
+
+func TestSignatureLengthMismatch(t *testing.T) {
+	// Setup:
+	longPricer := buildTestPricer(t, WithSignatureLength(8))
+	strictPricer := buildTestPricer(t, WithStrict(true))
+	encrypted, err := longPricer.Encrypt("seed", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	shortEncrypted, err := strictPricer.Encrypt("seed", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+
+	// Execute:
+	_, errStrict := strictPricer.Decrypt(encrypted)
+	_, errLong := longPricer.Decrypt(shortEncrypted)
+	_, errTampered := longPricer.Decrypt(tamperSignature(encrypted, 7))
+
+	// Verify:
+	assert.True(t, errors.Is(errStrict, ErrInvalidCiphertextLength), "Unexpected error : %s", errStrict)
+	assert.True(t, errors.Is(errLong, ErrInvalidCiphertextLength), "Unexpected error : %s", errLong)
+	assert.True(t, errors.Is(errTampered, ErrSignatureMismatch), "Unexpected error : %s", errTampered)
+}
+
+func TestNewPricerInvalidSignatureLength(t *testing.T) {
+	var tests = []struct {
+		name            string
+		signatureLength int
+		hashAlgorithm   helpers.HashAlgorithm
+		err             string
+	}{
+		{name: "zero", signatureLength: 0, hashAlgorithm: helpers.SHA1, err: "signature length should be between 1 and 20 bytes, got 0"},
+		{name: "negative", signatureLength: -4, hashAlgorithm: helpers.SHA1, err: "signature length should be between 1 and 20 bytes, got -4"},
+		{name: "above SHA-1 sums", signatureLength: 21, hashAlgorithm: helpers.SHA1, err: "signature length should be between 1 and 20 bytes, got 21"},
+		{name: "whole SHA-1 sums", signatureLength: 20, hashAlgorithm: helpers.SHA1},
+		{name: "above SHA-256 sums", signatureLength: 33, hashAlgorithm: helpers.SHA256, err: "signature length should be between 1 and 32 bytes, got 33"},
+		{name: "whole SHA-256 sums", signatureLength: 32, hashAlgorithm: helpers.SHA256},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute:
+			pricer, err := NewPricer(
+				WithKeys(
+					"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+					"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+				),
+				WithHashAlgorithm(tt.hashAlgorithm),
+				WithSignatureLength(tt.signatureLength),
+			)
+
+			// Verify:
+			if tt.err != "" {
+				assert.Nil(t, pricer)
+				assert.Equal(t, tt.err, err.Error())
+				return
+			}
+			assert.Nil(t, err, "Error creating new Pricer : ", err)
+			encrypted, err := pricer.Encrypt("seed", 1.354)
+			assert.Nil(t, err, "Encryption failed. Error : %s", err)
+			price, err := pricer.Decrypt(encrypted)
+			assert.Nil(t, err, "Decryption failed. Error : %s", err)
+			assert.InDelta(t, 1.354, price, 0.000001)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package doubleclick
+
+import (
+	"time"
+
+	"github.com/benjaminch/pricers/internal/core"
+)
+
+// cryptoState holds HMACs and buffers which can be reused across
+// several encryptions / decryptions by a single goroutine.
+type cryptoState struct {
+	core *core.State
+	// keys are the pricer keys core HMACs are keyed with.
+	keys    *pricerKeys
+	decoded []byte
+	encoded []byte
+	// started is when the decryption state is used for started,
+	// zero when it isn't reported to the pricer observer.
+	started time.Time
+}
+
+// grow makes sure state decoding buffer holds at least n bytes.
+func (state *cryptoState) grow(n int) {
+	if cap(state.decoded) < n {
+		state.decoded = make([]byte, n)
+	}
+	state.decoded = state.decoded[:n]
+}
+
+// newCryptoState returns a new cryptoState keyed with pricer keys.
+func (dc *DoubleClickPricer) newCryptoState() *cryptoState {
+	state := &cryptoState{}
+	dc.rekeyState(state, dc.keys.Load())
+
+	return state
+}
+
+// rekeyState keys state HMACs with keys.
+func (dc *DoubleClickPricer) rekeyState(state *cryptoState, keys *pricerKeys) {
+	state.core = dc.newCoreState(keys.encryptionKey, keys.integrityKey)
+	state.keys = keys
+}
+
+// newCoreState returns a core State keyed with decoded keys, configured as the pricer is.
+func (dc *DoubleClickPricer) newCoreState(encryptionKey []byte, integrityKey []byte) *core.State {
+	if dc.newHash != nil {
+		return core.NewStateWithHash(encryptionKey, integrityKey, dc.signatureLayout, dc.newHash, dc.signatureLength, dc.padOffset)
+	}
+
+	return core.NewState(encryptionKey, integrityKey, dc.signatureLayout, dc.hashAlgorithm, dc.signatureLength, dc.padOffset)
+}
+
+// acquireState returns a cryptoState from pricer pool, allocating one
+// only if none is available. Hmacs are reset before each sum, so no
+// state is carried from one price to another.
+// Pooled states keyed with keys replaced since by SetKeys are keyed again,
+// the returned state holding a consistent pair of keys until released.
+// ErrClosed is returned once the pricer is closed.
+func (dc *DoubleClickPricer) acquireState() (*cryptoState, error) {
+	if dc.closed.Load() {
+		return nil, ErrClosed
+	}
+	state := dc.states.Get().(*cryptoState)
+	if keys := dc.keys.Load(); state.keys != keys {
+		dc.rekeyState(state, keys)
+	}
+	state.started = time.Time{}
+
+	return state, nil
+}
+
+// acquireDecryptState returns a cryptoState as acquireState does, for a
+// decryption reported to the pricer observer, see startDecrypt.
+func (dc *DoubleClickPricer) acquireDecryptState() (*cryptoState, error) {
+	state, err := dc.acquireState()
+	if err != nil {
+		return nil, err
+	}
+	dc.startDecrypt(state)
+
+	return state, nil
+}
+
+// releaseState puts back state into pricer pool.
+// state must not be used afterwards.
+func (dc *DoubleClickPricer) releaseState(state *cryptoState) {
+	dc.states.Put(state)
+}
+
+// Close drops the pricer decrypt cache and marks the pricer closed, e.g. on shutdown,
+// after which encryptions, decryptions and SetKeys return ErrClosed rather than
+// panicking. Encryptions and decryptions in flight complete. Pooled states are
+// left to the garbage collector. Closing a closed pricer does nothing, and
+// Config and KeyFingerprint keep describing the pricer.
+func (dc *DoubleClickPricer) Close() error {
+	if !dc.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	dc.dropCache()
+
+	return nil
+}
+
+// dropCache drops the decrypt cache of the current keys, keeping the keys themselves.
+// Keys stored meanwhile by SetKeys are never overwritten: the cache is dropped
+// from them instead.
+func (dc *DoubleClickPricer) dropCache() {
+	for {
+		keys := dc.keys.Load()
+		if keys.cache == nil {
+			return
+		}
+		withoutCache := *keys
+		withoutCache.cache = nil
+		if dc.keys.CompareAndSwap(keys, &withoutCache) {
+			return
+		}
+	}
+}
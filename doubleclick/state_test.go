@@ -0,0 +1,176 @@
+package doubleclick
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/helpers"
+	"github.com/benjaminch/pricers/internal/core"
+)
+
+func TestPooledStatesDoNotLeakBetweenPrices(t *testing.T) {
+	// Pooled states are recycled across goroutines, some of them failing
+	// half way through a decryption. Every valid price should still be
+	// encrypted as a fresh pricer would.
+	// Should be run with -race.
+
+	// Setup:
+	pricer, err := buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	const prices = 200
+	expected := make([]string, prices)
+	for i := range expected {
+		fresh := pricer.newCryptoState()
+		data, err := helpers.ScalePrice(float64(i)/100, pricer.scaleFactor)
+		assert.Nil(t, err)
+		expected[i], err = pricer.encryptWith(fresh, core.IV(fmt.Sprintf("seed-%d", i)), data)
+		assert.Nil(t, err)
+	}
+	invalid := []string{
+		tamperSignature(expected[0], 0),
+		"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lX!!",
+		"anCGGFJApcfB6ZGc",
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(prices)
+
+	for i := 0; i < prices; i++ {
+		go func(i int) {
+			defer wg.Done()
+
+			for n := 0; n < 10; n++ {
+				// Execute:
+				_, invalidErr := pricer.Decrypt(invalid[(i+n)%len(invalid)])
+				encrypted, err := pricer.Encrypt(fmt.Sprintf("seed-%d", i), float64(i)/100)
+				decrypted, decryptErr := pricer.Decrypt(encrypted)
+
+				// Verify:
+				assert.NotNil(t, invalidErr)
+				assert.Nil(t, err, "Encryption failed. Error : %s", err)
+				assert.Nil(t, decryptErr, "Decryption failed. Error : %s", decryptErr)
+				assert.Equal(t, expected[i], encrypted)
+				assert.InDelta(t, float64(i)/100, decrypted, 0.001)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+func TestClose(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithDecryptCache(8))
+	encrypted, err := pricer.Encrypt("seed", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	_, err = pricer.Decrypt(encrypted)
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.Equal(t, 1, pricer.keys.Load().cache.len())
+
+	// Execute:
+	err = pricer.Close()
+
+	// Verify:
+	assert.Nil(t, err, "Closing failed. Error : %s", err)
+	assert.Nil(t, pricer.keys.Load().cache)
+	assert.Nil(t, pricer.Close(), "Closing again should do nothing")
+
+	var calls = []struct {
+		name string
+		call func() error
+	}{
+		{"Encrypt", func() error { _, err := pricer.Encrypt("seed", 1.354); return err }},
+		{"EncryptMicros", func() error { _, err := pricer.EncryptMicros("seed", 1354000); return err }},
+		{"AppendEncrypt", func() error { _, err := pricer.AppendEncrypt(nil, "seed", 1.354); return err }},
+		{"EncryptRaw", func() error { _, err := pricer.EncryptRaw("seed", 1.354); return err }},
+		{"EncryptRandom", func() error { _, err := pricer.EncryptRandom(1.354); return err }},
+		{"EncryptWithTrace", func() error { _, _, err := pricer.EncryptWithTrace("seed", 1.354); return err }},
+		{"EncryptBatch", func() error {
+			return pricer.EncryptBatch(map[string]EncryptRequest{"imp": {Seed: "seed", Price: 1.354}})["imp"].Err
+		}},
+		{"NewSeededEncryptor", func() error { _, err := pricer.NewSeededEncryptor("seed"); return err }},
+		{"Decrypt", func() error { _, err := pricer.Decrypt(encrypted); return err }},
+		{"DecryptMicros", func() error { _, err := pricer.DecryptMicros(encrypted); return err }},
+		{"DecryptRaw", func() error { _, err := pricer.DecryptRaw(make([]byte, 28)); return err }},
+		{"DecryptInto", func() error { _, err := pricer.DecryptInto(make([]byte, 28), encrypted); return err }},
+		{"DecryptDetailed", func() error { _, err := pricer.DecryptDetailed(encrypted); return err }},
+		{"DecryptWithTrace", func() error { _, _, err := pricer.DecryptWithTrace(encrypted); return err }},
+		{"DecryptBatch", func() error { _, errs := pricer.DecryptBatch([]string{encrypted}); return errs[0] }},
+		{"DecryptStream", func() error { return pricer.DecryptStream(bytes.NewReader([]byte(encrypted)), &bytes.Buffer{}) }},
+		{"Verify", func() error { _, err := pricer.Verify(encrypted); return err }},
+		{"SelfTest", func() error { return pricer.SelfTest() }},
+		{"SetKeys", func() error {
+			return pricer.SetKeys(
+				"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+				"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+				false, // Keys are not base64
+				helpers.Hexa,
+			)
+		}},
+	}
+	for _, c := range calls {
+		// Execute:
+		err := c.call()
+
+		// Verify:
+		assert.True(t, errors.Is(err, ErrClosed), "%s after Close should return ErrClosed, got : %v", c.name, err)
+	}
+	assert.NotEmpty(t, pricer.Config().EncryptionKeyFingerprint)
+}
+
+func TestCloseConcurrentlyWithSetKeys(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		// Setup:
+		pricer := buildTestPricer(t, WithDecryptCache(8))
+		var wg sync.WaitGroup
+		var setKeysErr error
+		wg.Add(2)
+
+		// Execute:
+		go func() {
+			defer wg.Done()
+			setKeysErr = pricer.SetKeys(rotatedEncryptionKey, rotatedIntegrityKey, false, helpers.WebSafeBase64)
+		}()
+		go func() {
+			defer wg.Done()
+			pricer.Close()
+		}()
+		wg.Wait()
+
+		// Verify:
+		// Keys SetKeys stored are kept, Close never restoring the previous ones.
+		if setKeysErr == nil {
+			assert.Equal(t, buildRotatedPricer(t).KeyFingerprint(), pricer.KeyFingerprint())
+		} else {
+			assert.True(t, errors.Is(setKeysErr, ErrClosed), "Unexpected error : %s", setKeysErr)
+		}
+		assert.Nil(t, pricer.keys.Load().cache)
+	}
+}
+
+func TestCloseWithEncryptorInFlight(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+	encryptor, err := pricer.NewSeededEncryptor("seed")
+	assert.Nil(t, err, "Error creating new SeededEncryptor : ", err)
+
+	// Execute:
+	assert.Nil(t, pricer.Close())
+	_, err = encryptor.Encrypt(1.354)
+
+	// Verify:
+	assert.True(t, errors.Is(err, ErrClosed), "Unexpected error : %s", err)
+}
@@ -0,0 +1,55 @@
+package doubleclick
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// StreamErrorPrefix starts the lines DecryptStream writes for encrypted prices
+// failing to decrypt, followed by the error.
+const StreamErrorPrefix = "error: "
+
+// DecryptStream reads newline delimited encrypted prices from r and, for each line,
+// writes the clear price, or StreamErrorPrefix and the error, as a line to w,
+// so that line n of w is the result of line n of r. Blank lines are written back
+// blank and spaces around encrypted prices are ignored.
+// Lines are read one at a time, so that r can be larger than memory.
+// A failing price doesn't abort the stream, only read and write errors,
+// including lines longer than bufio.MaxScanTokenSize, are returned.
+func (dc *DoubleClickPricer) DecryptStream(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	writer := bufio.NewWriter(w)
+
+	state, err := dc.acquireState()
+	if err != nil {
+		return err
+	}
+	defer dc.releaseState(state)
+
+	var line []byte
+	for scanner.Scan() {
+		line = line[:0]
+
+		encryptedPrice := strings.TrimSpace(scanner.Text())
+		if encryptedPrice != "" {
+			dc.startDecrypt(state)
+			priceMicro, err := dc.decryptWith(state, encryptedPrice)
+			if err != nil {
+				line = append(append(line, StreamErrorPrefix...), err.Error()...)
+			} else {
+				line = strconv.AppendFloat(line, dc.toPrice(priceMicro), 'f', -1, 64)
+			}
+		}
+
+		if _, err := writer.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
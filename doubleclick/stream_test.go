@@ -0,0 +1,76 @@
+package doubleclick
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestDecryptStream(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+	input := strings.Join([]string{
+		"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg",
+		"not base64 !",
+		"",
+		"  anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg\r",
+		tamperSignature("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", 0),
+		"anCGGFJApcfB6ZGc6mindhpTrYXHY4",
+		"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg",
+	}, "\n")
+	var output bytes.Buffer
+
+	// Execute:
+	err := pricer.DecryptStream(strings.NewReader(input), &output)
+
+	// Verify:
+	require.NoError(t, err, "Unexpected error : %s", err)
+	lines := strings.Split(output.String(), "\n")
+	if assert.Len(t, lines, 8) {
+		assert.Equal(t, "1.354", lines[0])
+		assert.True(t, strings.HasPrefix(lines[1], StreamErrorPrefix+ErrMalformedBase64.Error()), lines[1])
+		assert.Equal(t, "", lines[2])
+		assert.Equal(t, "1.354", lines[3])
+		assert.Equal(t, StreamErrorPrefix+ErrSignatureMismatch.Error(), lines[4])
+		assert.Equal(t, StreamErrorPrefix+"invalid encrypted price: decoded 22 bytes, expected 28 (IV 16 + price 8 + sig 4)", lines[5])
+		assert.Equal(t, "1.354", lines[6])
+		assert.Equal(t, "", lines[7])
+	}
+}
+
+func TestDecryptStreamEmpty(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+	var output bytes.Buffer
+
+	// Execute:
+	err := pricer.DecryptStream(strings.NewReader(""), &output)
+
+	// Verify:
+	require.NoError(t, err, "Unexpected error : %s", err)
+	assert.Equal(t, "", output.String())
+}
+
+func TestDecryptStreamErrors(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+
+	// Execute:
+	writeErr := pricer.DecryptStream(strings.NewReader("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg\n"), failingWriter{})
+	tooLongErr := pricer.DecryptStream(strings.NewReader(strings.Repeat("A", 1<<17)), &bytes.Buffer{})
+
+	// Verify:
+	assert.EqualError(t, writeErr, "write failed")
+	assert.NotNil(t, tooLongErr)
+}
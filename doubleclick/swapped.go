@@ -0,0 +1,24 @@
+package doubleclick
+
+import (
+	"github.com/benjaminch/pricers/internal/core"
+)
+
+// keysAppearSwapped returns whether the signature of opened, which doesn't match
+// with keys, matches once encryption and integrity keys are swapped. keys are
+// those of the state opened was opened with, rather than the current pricer keys,
+// which SetKeys may have replaced since.
+// A new state is keyed for each call, only made on signature mismatch.
+func (dc *DoubleClickPricer) keysAppearSwapped(keys *pricerKeys, opened core.Opened) bool {
+	swapped := dc.newCoreState(keys.integrityKey, keys.encryptionKey)
+
+	// message = iv || enc_price || signature
+	var message [core.MaxMessageLength]byte
+	copy(message[:core.IVLength], opened.IV[:])
+	copy(message[core.IVLength:core.UnsignedMessageLength], opened.Encoded[:])
+	copy(message[core.UnsignedMessageLength:], opened.Signature[:dc.signatureLength])
+
+	reopened, err := swapped.Open(message[:swapped.MessageLength()])
+
+	return err == nil && reopened.IsIntegrityValid
+}
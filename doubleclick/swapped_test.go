@@ -0,0 +1,81 @@
+package doubleclick
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/benjaminch/pricers/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwappedKeysDetection(t *testing.T) {
+	// Setup:
+	encrypted, err := buildTestPricer(t).Encrypt("seed", 1.354)
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+	pricer := buildTestPricer(t, WithKeys(testIntegrityKey, testEncryptionKey), WithSwappedKeysDetection(true))
+
+	// Execute:
+	_, err = pricer.Decrypt(encrypted)
+	_, errMicros := pricer.DecryptMicros(encrypted)
+	_, errTampered := pricer.Decrypt(tamperSignature(encrypted, 0))
+
+	// Verify:
+	assert.True(t, errors.Is(err, ErrSignatureMismatch), "Unexpected error : %s", err)
+	assert.True(t, errors.Is(err, ErrKeysSwapped), "Unexpected error : %s", err)
+	assert.EqualError(t, err, "Failed to decrypt: keys appear swapped")
+	assert.True(t, errors.Is(errMicros, ErrKeysSwapped), "Unexpected error : %s", errMicros)
+	assert.Equal(t, ErrSignatureMismatch, errTampered)
+}
+
+func TestSwappedKeysDetectionDisabled(t *testing.T) {
+	// Setup:
+	encrypted, err := buildTestPricer(t).Encrypt("seed", 1.354)
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+	pricer := buildTestPricer(t, WithKeys(testIntegrityKey, testEncryptionKey))
+
+	// Execute:
+	_, err = pricer.Decrypt(encrypted)
+
+	// Verify:
+	assert.Equal(t, ErrSignatureMismatch, err)
+}
+
+func TestSwappedKeysDetectionWithOptions(t *testing.T) {
+	// Setup:
+	opts := []Option{WithSignatureLength(8), WithSwappedKeysDetection(true)}
+	encrypted, err := buildTestPricer(t, opts...).Encrypt("seed", 1.354)
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+	pricer := buildTestPricer(t, append([]Option{WithKeys(testIntegrityKey, testEncryptionKey)}, opts...)...)
+
+	// Execute:
+	_, err = pricer.Decrypt(encrypted)
+	price, errValid := buildTestPricer(t, opts...).Decrypt(encrypted)
+
+	// Verify:
+	assert.True(t, errors.Is(err, ErrKeysSwapped), "Unexpected error : %s", err)
+	require.NoError(t, errValid, "Decryption failed. Error : %s", errValid)
+	assert.InDelta(t, 1.354, price, 0.000001)
+}
+
+func TestSwappedKeysDetectionUsesStateKeys(t *testing.T) {
+	// Setup:
+	encrypted, err := buildTestPricer(t).Encrypt("seed", 1.354)
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+	pricer := buildTestPricer(t, WithKeys(testIntegrityKey, testEncryptionKey), WithSwappedKeysDetection(true))
+	state, err := pricer.acquireState()
+	require.NoError(t, err, "Unexpected error : %s", err)
+	defer pricer.releaseState(state)
+	decoded, err := pricer.decode(state, encrypted)
+	require.NoError(t, err, "Unexpected error : %s", err)
+	// Keys are rotated while the state still holds the swapped ones.
+	err = pricer.SetKeys(rotatedEncryptionKey, rotatedIntegrityKey, false, helpers.WebSafeBase64)
+	require.NoError(t, err, "Unexpected error : %s", err)
+
+	// Execute:
+	_, checkErr, err := pricer.openRawWith(state, decoded)
+
+	// Verify:
+	assert.Nil(t, err, "Unexpected error : %s", err)
+	assert.True(t, errors.Is(checkErr, ErrKeysSwapped), "Unexpected error : %s", checkErr)
+}
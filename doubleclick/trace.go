@@ -0,0 +1,94 @@
+package doubleclick
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// Trace holds the intermediate values of an encryption or a decryption,
+// for callers to inspect them programmatically rather than through debug lines.
+type Trace struct {
+	// IV is the Initialization Vector the price is encrypted with.
+	IV [16]byte
+	// Pad is the first 8 bytes of hmac(e_key, iv) price bytes are XORed with.
+	Pad [8]byte
+	// Micros is the price before the scale factor is applied.
+	Micros uint64
+	// Signature is the integrity signature of the price, the one received when
+	// decrypting, 4 bytes long unless set otherwise with WithSignatureLength,
+	// empty for unsigned pricers.
+	Signature []byte
+}
+
+// EncryptWithTrace encrypts a clear price and a given seed as Encrypt does,
+// also returning the intermediate values of the encryption.
+func (dc *DoubleClickPricer) EncryptWithTrace(seed string, price float64) (encrypted string, trace Trace, err error) {
+	if dc.observer != nil {
+		defer dc.observeEncrypt(time.Now(), &err)
+	}
+
+	if err := dc.validateSeed(seed); err != nil {
+		return "", trace, err
+	}
+	data, err := dc.scalePrice(price)
+	if err != nil {
+		return "", trace, err
+	}
+
+	state, err := dc.acquireState()
+	if err != nil {
+		return "", trace, err
+	}
+	defer dc.releaseState(state)
+
+	iv := dc.seedIV(seed)
+	sealed, err := dc.sealWith(state, iv, data)
+	if err != nil {
+		return "", trace, err
+	}
+	trace.IV = iv
+	trace.Pad = sealed.Pad
+	trace.Micros = binary.BigEndian.Uint64(data[:])
+	if !dc.isUnsigned {
+		trace.Signature = append([]byte(nil), sealed.Signature[:dc.signatureLength]...)
+	}
+
+	state.encoded = dc.appendEncoded(state.encoded[:0], sealed.Message[:dc.messageLength()])
+	return string(state.encoded), trace, err
+}
+
+// DecryptWithTrace decrypts an encrypted price as Decrypt does, also returning
+// the intermediate values of the decryption. The trace is returned even when
+// the integrity signature doesn't match or the price is out of range, so that
+// failures can be inspected, but is empty for malformed encrypted prices.
+// Prices are never decrypted from the decrypt cache.
+func (dc *DoubleClickPricer) DecryptWithTrace(encryptedPrice string) (price float64, trace Trace, err error) {
+	var errPrice float64
+
+	state, err := dc.acquireDecryptState()
+	if err != nil {
+		return errPrice, trace, err
+	}
+	defer dc.releaseState(state)
+
+	decoded, err := dc.decode(state, encryptedPrice)
+	if err != nil {
+		return errPrice, trace, err
+	}
+	opened, checkErr, err := dc.openRawWith(state, decoded)
+	if err != nil {
+		return errPrice, trace, err
+	}
+
+	trace.IV = opened.IV
+	trace.Pad = opened.Pad
+	trace.Micros = binary.BigEndian.Uint64(opened.Price[:])
+	if !dc.isUnsigned {
+		trace.Signature = append([]byte(nil), opened.Signature[:dc.signatureLength]...)
+	}
+	if checkErr != nil {
+		return errPrice, trace, checkErr
+	}
+
+	return dc.toPrice(opened.Price), trace, err
+}
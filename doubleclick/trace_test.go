@@ -0,0 +1,88 @@
+package doubleclick
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func TestEncryptWithTrace(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+	// 1.354 encrypted with an empty seed, whose IV is md5("")
+	message, _ := base64.RawURLEncoding.DecodeString("1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA")
+
+	// Execute:
+	encrypted, trace, err := pricer.EncryptWithTrace("", 1.354)
+
+	// Verify:
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+	assert.Equal(t, "1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA", encrypted)
+	assert.Equal(t, "d41d8cd98f00b204e9800998ecf8427e", hex.EncodeToString(trace.IV[:]))
+	assert.Equal(t, uint64(1354000), trace.Micros)
+	// enc_price = pad <xor> price
+	var encoded [8]byte
+	for i := range encoded {
+		encoded[i] = trace.Pad[i] ^ []byte{0, 0, 0, 0, 0, 0x14, 0xa9, 0x10}[i]
+	}
+	assert.Equal(t, message[16:24], encoded[:])
+	assert.Equal(t, message[24:], trace.Signature[:])
+}
+
+func TestDecryptWithTrace(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+	_, encryptTrace, err := pricer.EncryptWithTrace("", 1.354)
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+
+	// Execute:
+	price, trace, err := pricer.DecryptWithTrace("1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA")
+
+	// Verify:
+	require.NoError(t, err, "Decryption failed. Error : %s", err)
+	assert.InDelta(t, 1.354, price, 0.000001)
+	assert.Equal(t, encryptTrace, trace)
+}
+
+func TestDecryptWithTraceFailures(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+	tampered := tamperSignature("1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA", 0)
+	message, _ := base64.URLEncoding.DecodeString(tampered)
+
+	// Execute:
+	price, trace, err := pricer.DecryptWithTrace(tampered)
+	_, malformedTrace, malformedErr := pricer.DecryptWithTrace("1B2M2Y8Asg!!")
+
+	// Verify:
+	// Tampered prices are still traced
+	assert.Equal(t, float64(0), price)
+	assert.Equal(t, ErrSignatureMismatch, err)
+	assert.Equal(t, uint64(1354000), trace.Micros)
+	assert.Equal(t, message[24:], trace.Signature[:])
+	assert.True(t, errors.Is(malformedErr, ErrMalformedBase64), "Unexpected error : %s", malformedErr)
+	assert.Equal(t, Trace{}, malformedTrace)
+}
+
+func TestEncryptWithTraceUnsigned(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithUnsigned(true), WithPriceEncoding(helpers.Hex))
+
+	// Execute:
+	encrypted, trace, err := pricer.EncryptWithTrace("", 1.354)
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+	price, decryptTrace, decryptErr := pricer.DecryptWithTrace(encrypted)
+
+	// Verify:
+	assert.Len(t, encrypted, 48)
+	assert.Empty(t, trace.Signature)
+	assert.Nil(t, decryptErr, "Decryption failed. Error : %s", decryptErr)
+	assert.InDelta(t, 1.354, price, 0.000001)
+	assert.Equal(t, trace, decryptTrace)
+}
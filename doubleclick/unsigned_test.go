@@ -0,0 +1,104 @@
+package doubleclick
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnsignedRoundTrip(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithUnsigned(true))
+
+	for _, price := range []float64{0, 1.354, 12.75, 1000} {
+		// Execute:
+		encrypted, err := pricer.Encrypt("seed", price)
+		require.NoError(t, err, "Encryption failed. Error : %s", err)
+		raw, rawErr := pricer.EncryptRaw("seed", price)
+		decrypted, decryptErr := pricer.Decrypt(encrypted)
+		rawDecrypted, rawDecryptErr := pricer.DecryptRaw(raw)
+
+		// Verify:
+		assert.Len(t, encrypted, 32)
+		assert.Nil(t, rawErr, "Encryption failed. Error : %s", rawErr)
+		assert.Len(t, raw, 24)
+		assert.Nil(t, decryptErr, "Decryption failed. Error : %s", decryptErr)
+		assert.InDelta(t, price, decrypted, 0.000001)
+		assert.Nil(t, rawDecryptErr, "Decryption failed. Error : %s", rawDecryptErr)
+		assert.InDelta(t, price, rawDecrypted, 0.000001)
+	}
+}
+
+func TestUnsignedMatchesSignedWithoutSignature(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithUnsigned(true))
+	signed := buildTestPricer(t, WithUnsigned(true), WithUnsigned(false))
+
+	// Execute:
+	unsignedRaw, err := pricer.EncryptRaw("seed", 1.354)
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+	signedRaw, err := signed.EncryptRaw("seed", 1.354)
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+
+	// Verify:
+	assert.Equal(t, signedRaw[:24], unsignedRaw)
+}
+
+func TestSignedRejectsUnsigned(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithUnsigned(true))
+	signed := buildTestPricer(t, WithUnsigned(true), WithUnsigned(false))
+	encrypted, err := pricer.Encrypt("seed", 1.354)
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+	raw, _ := base64.RawURLEncoding.DecodeString(encrypted)
+
+	// Execute:
+	_, decryptErr := signed.Decrypt(encrypted)
+	_, rawErr := signed.DecryptRaw(raw)
+
+	// Verify:
+	assert.True(t, errors.Is(decryptErr, ErrInvalidCiphertextLength), "Unexpected error : %s", decryptErr)
+	assert.EqualError(t, decryptErr, "invalid encrypted price: decoded 24 bytes, expected 28 (IV 16 + price 8 + sig 4)")
+	assert.True(t, errors.Is(rawErr, ErrInvalidCiphertextLength), "Unexpected error : %s", rawErr)
+}
+
+func TestUnsignedLengthValidation(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithUnsigned(true))
+	strict := buildTestPricer(t, WithUnsigned(true), WithStrict(true))
+
+	// Execute:
+	_, shortErr := pricer.DecryptRaw(make([]byte, 23))
+	_, strictErr := strict.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	price, trailingErr := pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+
+	// Verify:
+	assert.EqualError(t, shortErr, "invalid encrypted price: decoded 23 bytes, expected 24 (IV 16 + price 8)")
+	assert.EqualError(t, strictErr, "invalid encrypted price: decoded 28 bytes, expected exactly 24 (IV 16 + price 8)")
+	// Signatures are trailing bytes to unsigned pricers
+	assert.Nil(t, trailingErr, "Decryption failed. Error : %s", trailingErr)
+	assert.InDelta(t, 1.354, price, 0.000001)
+}
+
+func TestUnsignedIntegrity(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithUnsigned(true))
+	encrypted, err := pricer.Encrypt("seed", 1.354)
+	require.NoError(t, err, "Encryption failed. Error : %s", err)
+
+	// Execute:
+	isValid, verifyErr := pricer.Verify(encrypted)
+	result, detailedErr := pricer.DecryptDetailed(encrypted)
+	selfTestErr := pricer.SelfTest()
+
+	// Verify:
+	assert.Nil(t, verifyErr)
+	assert.False(t, isValid)
+	assert.Nil(t, detailedErr)
+	assert.False(t, result.IntegrityValid)
+	assert.InDelta(t, 1.354, result.Price, 0.000001)
+	assert.Nil(t, selfTestErr, "Unexpected error : %s", selfTestErr)
+}
@@ -0,0 +1,62 @@
+package doubleclick
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+)
+
+// Verify checks that an encrypted price is authentic and untampered,
+// recomputing its integrity signature, without returning the clear price.
+// A malformed encrypted price returns an error, while a signature
+// mismatch only returns false.
+func (dc *DoubleClickPricer) Verify(encryptedPrice string) (bool, error) {
+	state, err := dc.acquireDecryptState()
+	if err != nil {
+		return false, err
+	}
+	defer dc.releaseState(state)
+
+	decoded, err := dc.decode(state, encryptedPrice)
+	if err != nil {
+		return false, err
+	}
+
+	opened, _, err := dc.openRawWith(state, decoded)
+	if err != nil {
+		return false, err
+	}
+
+	return opened.IsIntegrityValid, err
+}
+
+// VerifyPriceEquals checks that an encrypted price is authentic and that its
+// price is expectedMicros, e.g. to reconcile billed prices with win notices.
+// Micros are compared in constant time, without branching on the decrypted
+// price, which is never returned. Unlike Verify, a signature mismatch returns
+// ErrSignatureMismatch, so that false always means an authentic price other
+// than expectedMicros.
+func (dc *DoubleClickPricer) VerifyPriceEquals(encryptedPrice string, expectedMicros uint64) (bool, error) {
+	state, err := dc.acquireDecryptState()
+	if err != nil {
+		return false, err
+	}
+	defer dc.releaseState(state)
+
+	decoded, err := dc.decode(state, encryptedPrice)
+	if err != nil {
+		return false, err
+	}
+
+	opened, checkErr, err := dc.openRawWith(state, decoded)
+	if err != nil {
+		return false, err
+	}
+	if checkErr != nil {
+		return false, checkErr
+	}
+
+	var expected [8]byte
+	binary.BigEndian.PutUint64(expected[:], expectedMicros)
+
+	return subtle.ConstantTimeCompare(opened.Price[:], expected[:]) == 1, nil
+}
@@ -0,0 +1,96 @@
+package doubleclick
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func TestVerify(t *testing.T) {
+	// Setup:
+	var pricer *DoubleClickPricer
+	var err error
+	pricer, err = buildNewDoubleClickPricer(
+		"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+		"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		false, // Keys are not base64
+		helpers.Hexa,
+		1000000,
+		false,
+	)
+
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	var pricesTestCase = []struct {
+		encrypted string
+		isValid   bool
+		err       error
+	}{
+		// Valid
+		{"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", true, nil},
+		{"1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA", true, nil},
+		// Tampered signature
+		{tamperSignature("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", 1), false, nil},
+		// Tampered encrypted price
+		{"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg"[:24] + "AAAA" + "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg"[28:], false, nil},
+		// Malformed length
+		{"anCGGFJApcfB6ZGc6mindhpTrYXHY4", false, ErrInvalidCiphertextLength},
+	}
+
+	for _, price := range pricesTestCase {
+		// Execute:
+		isValid, err := pricer.Verify(price.encrypted)
+
+		// Verify:
+		assert.Equal(t, price.isValid, isValid, "Verification of %s should be %t", price.encrypted, price.isValid)
+		if price.err == nil {
+			assert.Nil(t, err, "Verification failed. Error : %s", err)
+		} else {
+			assert.True(t, errors.Is(err, price.err), "Error should be %v but was : %v", price.err, err)
+		}
+	}
+}
+
+func TestVerifyPriceEquals(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t)
+	encrypted, err := pricer.Encrypt("seed", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+
+	var pricesTestCase = []struct {
+		encrypted      string
+		expectedMicros uint64
+		isEqual        bool
+		err            error
+	}{
+		// Matching
+		{encrypted, 1354000, true, nil},
+		{"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", 1354000, true, nil},
+		// Not matching
+		{encrypted, 1354001, false, nil},
+		{encrypted, 0, false, nil},
+		{"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", 1353999, false, nil},
+		// Tampered signature
+		{tamperSignature(encrypted, 0), 1354000, false, ErrSignatureMismatch},
+		// Tampered encrypted price
+		{encrypted[:24] + "AAAA" + encrypted[28:], 1354000, false, ErrSignatureMismatch},
+		// Malformed length
+		{encrypted[:30], 1354000, false, ErrInvalidCiphertextLength},
+	}
+
+	for _, price := range pricesTestCase {
+		// Execute:
+		isEqual, err := pricer.VerifyPriceEquals(price.encrypted, price.expectedMicros)
+
+		// Verify:
+		assert.Equal(t, price.isEqual, isEqual, "Comparison of %s with %d should be %t", price.encrypted, price.expectedMicros, price.isEqual)
+		if price.err == nil {
+			assert.Nil(t, err, "Verification failed. Error : %s", err)
+		} else {
+			assert.True(t, errors.Is(err, price.err), "Error should be %v but was : %v", price.err, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package doubleclick
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+// encryptWithZeroIV returns 1.354 encrypted with an all zeros IV, as encrypted
+// by an upstream pricer deriving IVs from a missing seed.
+func encryptWithZeroIV(t *testing.T) string {
+	upstream := buildTestPricer(t, WithIVDeriver(func(seed string) [16]byte { return [16]byte{} }))
+	encrypted, err := upstream.Encrypt("", 1.354)
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+
+	return encrypted
+}
+
+func TestZeroIVPolicy(t *testing.T) {
+	// Setup:
+	encrypted := encryptWithZeroIV(t)
+	assert.Equal(t, "AAAAAAAAAAAAAAAAAAAAA", encrypted[:21])
+
+	var tests = []struct {
+		name     string
+		policy   helpers.ZeroIVPolicy
+		err      error
+		isWarned bool
+	}{
+		{name: "allowed by default", policy: helpers.AllowZeroIV},
+		{name: "warned", policy: helpers.WarnZeroIV, isWarned: true},
+		{name: "rejected", policy: helpers.RejectZeroIV, err: ErrZeroIV},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup:
+			logger := &recordingLogger{}
+			pricer := buildTestPricer(t, WithZeroIVPolicy(tt.policy), WithLogger(logger))
+
+			// Execute:
+			price, err := pricer.Decrypt(encrypted)
+			valid, validErr := pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+
+			// Verify:
+			assert.Nil(t, validErr, "Decryption failed. Error : %s", validErr)
+			assert.InDelta(t, 1.354, valid, 0.000001)
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err), "Unexpected error : %s", err)
+			} else {
+				assert.Nil(t, err, "Decryption failed. Error : %s", err)
+				assert.InDelta(t, 1.354, price, 0.000001)
+			}
+			if tt.isWarned {
+				assert.Equal(t, []string{ZeroIVWarning}, logger.lines)
+			} else {
+				assert.Empty(t, logger.lines)
+			}
+		})
+	}
+}
+
+func TestZeroIVWarningOnCacheHit(t *testing.T) {
+	// Setup:
+	encrypted := encryptWithZeroIV(t)
+	logger := &recordingLogger{}
+	pricer := buildTestPricer(t, WithZeroIVPolicy(helpers.WarnZeroIV), WithLogger(logger), WithDecryptCache(8))
+
+	for _, encryptedPrice := range []string{encrypted, "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg"} {
+		_, err := pricer.Decrypt(encryptedPrice)
+		require.NoError(t, err, "Decryption failed. Error : %s", err)
+	}
+
+	// Execute:
+	price, err := pricer.Decrypt(encrypted)
+	_, validErr := pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+
+	// Verify:
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.Nil(t, validErr, "Decryption failed. Error : %s", validErr)
+	assert.InDelta(t, 1.354, price, 0.000001)
+	assert.Equal(t, 2, pricer.keys.Load().cache.len())
+	assert.Equal(t, []string{ZeroIVWarning, ZeroIVWarning}, logger.lines)
+}
+
+func TestZeroIVPolicyTamperedSignature(t *testing.T) {
+	// Setup:
+	pricer := buildTestPricer(t, WithZeroIVPolicy(helpers.RejectZeroIV))
+
+	// Execute:
+	_, err := pricer.Decrypt(tamperSignature(encryptWithZeroIV(t), 0))
+
+	// Verify:
+	assert.True(t, errors.Is(err, ErrSignatureMismatch), "Unexpected error : %s", err)
+}
+
+func TestNewPricerUnknownZeroIVPolicy(t *testing.T) {
+	// Execute:
+	pricer, err := NewPricer(
+		WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+		WithZeroIVPolicy("ignore"),
+	)
+
+	// Verify:
+	assert.Nil(t, pricer)
+	assert.EqualError(t, err, "unknown zero IV policy: ignore")
+}
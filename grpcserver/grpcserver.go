@@ -0,0 +1,165 @@
+// Package grpcserver exposes a pricer as the Pricer gRPC service defined in
+// pricerpb/pricer.proto, so that non Go services can encrypt and decrypt prices.
+//
+// Unary failures are reported with status codes: InvalidArgument for prices
+// or seeds the pricer rejects, encrypted prices which are malformed or fail their
+// integrity checks, or seeds and encrypted prices longer than the server limit,
+// Unavailable once the pricer is closed and Internal otherwise. DecryptStream reports failures per price and only fails
+// the stream on transport errors. Keys are never echoed.
+//
+// Seeds and encrypted prices are only checked once their message is unmarshalled:
+// build the gRPC server with Server.ServerOptions for messages that couldn't hold
+// accepted inputs to be rejected with ResourceExhausted before being read.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/benjaminch/pricers"
+	"github.com/benjaminch/pricers/grpcserver/pricerpb"
+	"github.com/benjaminch/pricers/helpers"
+	"github.com/benjaminch/pricers/internal/serving"
+)
+
+// Server serves pricer operations over gRPC.
+// A Server is safe for concurrent use as long as its pricer is.
+type Server struct {
+	pricerpb.UnimplementedPricerServer
+
+	pricer         pricers.Pricer
+	maxInputLength int
+}
+
+var _ pricerpb.PricerServer = (*Server)(nil)
+
+// DefaultMaxInputLength is the longest seed or encrypted price accepted by default.
+const DefaultMaxInputLength = serving.DefaultMaxInputLength
+
+// messageOverhead bounds the bytes a request message holds on top of its seed
+// or encrypted price: field tags, a length varint and an 8 bytes price.
+const messageOverhead = 64
+
+// Option configures a Server built with New.
+type Option func(*Server)
+
+// WithMaxInputLength sets the longest seed or encrypted price accepted, in bytes,
+// DefaultMaxInputLength by default. Longer ones are rejected with InvalidArgument
+// without reaching the pricer.
+func WithMaxInputLength(maxInputLength int) Option {
+	return func(s *Server) {
+		s.maxInputLength = maxInputLength
+	}
+}
+
+// New returns a Server backed by pricer, with opts applied.
+func New(pricer pricers.Pricer, opts ...Option) *Server {
+	s := &Server{pricer: pricer, maxInputLength: DefaultMaxInputLength}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// ServerOptions returns the options to build the gRPC server with, limiting
+// received messages to what holds the longest seed or encrypted price accepted,
+// so that larger ones are rejected before being read and unmarshalled.
+//
+//	server := grpc.NewServer(s.ServerOptions()...)
+func (s *Server) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{grpc.MaxRecvMsgSize(s.maxInputLength + messageOverhead)}
+}
+
+// Register registers the server's Pricer service on registrar, e.g. a *grpc.Server.
+func (s *Server) Register(registrar grpc.ServiceRegistrar) {
+	pricerpb.RegisterPricerServer(registrar, s)
+}
+
+// Encrypt encrypts a clear price, with a unique seed when none is given.
+func (s *Server) Encrypt(_ context.Context, request *pricerpb.EncryptRequest) (*pricerpb.EncryptResponse, error) {
+	seed := request.GetSeed()
+	if err := s.checkLength("seed", seed); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if seed == "" {
+		seed = helpers.NewSeed()
+	}
+
+	encrypted, err := s.pricer.Encrypt(seed, request.GetPrice())
+	if err != nil {
+		code := codes.Internal
+		switch {
+		case serving.IsRejectedPrice(err):
+			code = codes.InvalidArgument
+		case serving.IsUnavailable(err):
+			code = codes.Unavailable
+		}
+		return nil, status.Error(code, err.Error())
+	}
+
+	return &pricerpb.EncryptResponse{Encrypted: encrypted}, nil
+}
+
+// Decrypt decrypts an encrypted price.
+func (s *Server) Decrypt(_ context.Context, request *pricerpb.DecryptRequest) (*pricerpb.DecryptResponse, error) {
+	if request.GetEncrypted() == "" {
+		return nil, status.Error(codes.InvalidArgument, "encrypted is missing")
+	}
+	if err := s.checkLength("encrypted", request.GetEncrypted()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	price, err := s.pricer.Decrypt(request.GetEncrypted())
+	if err != nil {
+		code := codes.Internal
+		switch {
+		case serving.IsRejectedEncrypted(err):
+			code = codes.InvalidArgument
+		case serving.IsUnavailable(err):
+			code = codes.Unavailable
+		}
+		return nil, status.Error(code, err.Error())
+	}
+
+	return &pricerpb.DecryptResponse{Price: price}, nil
+}
+
+// DecryptStream decrypts encrypted prices until the client closes its side,
+// sending one result per encrypted price in order.
+func (s *Server) DecryptStream(stream pricerpb.Pricer_DecryptStreamServer) error {
+	for {
+		request, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		response := &pricerpb.DecryptStreamResponse{Encrypted: request.GetEncrypted()}
+		if response.Encrypted == "" {
+			response.Error = "encrypted is missing"
+		} else if err := s.checkLength("encrypted", response.Encrypted); err != nil {
+			response.Error = err.Error()
+		} else if price, err := s.pricer.Decrypt(response.Encrypted); err != nil {
+			response.Error = err.Error()
+		} else {
+			response.Price = price
+		}
+
+		if err := stream.Send(response); err != nil {
+			return err
+		}
+	}
+}
+
+// checkLength returns an error if value of field is longer than the server accepts.
+func (s *Server) checkLength(field string, value string) error {
+	return serving.CheckLength(field, value, s.maxInputLength)
+}
@@ -0,0 +1,292 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/benjaminch/pricers/doubleclick"
+	"github.com/benjaminch/pricers/grpcserver/pricerpb"
+)
+
+const (
+	testEncryptionKey = "652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135"
+	testIntegrityKey  = "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5"
+)
+
+// buildClient serves a Server over an in-process connection and returns a client to it.
+func buildClient(t *testing.T, opts ...doubleclick.Option) pricerpb.PricerClient {
+	return buildClientWith(t, nil, opts...)
+}
+
+// buildClientWith is buildClient with serverOpts applied to the Server.
+func buildClientWith(t *testing.T, serverOpts []Option, opts ...doubleclick.Option) pricerpb.PricerClient {
+	pricer, err := doubleclick.NewPricer(append([]doubleclick.Option{doubleclick.WithKeys(testEncryptionKey, testIntegrityKey)}, opts...)...)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	return serveClient(t, New(pricer, serverOpts...))
+}
+
+// serveClient serves pricerServer over an in-process connection and returns a client to it.
+func serveClient(t *testing.T, pricerServer *Server) pricerpb.PricerClient {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(pricerServer.ServerOptions()...)
+	pricerServer.Register(server)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.Nil(t, err, "Error dialing server : ", err)
+	t.Cleanup(func() { conn.Close() })
+
+	return pricerpb.NewPricerClient(conn)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	// Setup:
+	client := buildClient(t)
+	ctx := context.Background()
+
+	// Execute:
+	encrypted, encryptErr := client.Encrypt(ctx, &pricerpb.EncryptRequest{Price: 1.354})
+	decrypted, decryptErr := client.Decrypt(ctx, &pricerpb.DecryptRequest{Encrypted: encrypted.GetEncrypted()})
+
+	// Verify:
+	assert.Nil(t, encryptErr)
+	assert.NotEmpty(t, encrypted.GetEncrypted())
+	assert.Nil(t, decryptErr)
+	assert.InDelta(t, 1.354, decrypted.GetPrice(), 0.000001)
+}
+
+func TestEncryptWithSeed(t *testing.T) {
+	// Setup:
+	client := buildClient(t)
+	ctx := context.Background()
+
+	// Execute:
+	first, firstErr := client.Encrypt(ctx, &pricerpb.EncryptRequest{Seed: "seed", Price: 1.354})
+	second, secondErr := client.Encrypt(ctx, &pricerpb.EncryptRequest{Seed: "seed", Price: 1.354})
+
+	// Verify:
+	assert.Nil(t, firstErr)
+	assert.Nil(t, secondErr)
+	assert.Equal(t, first.GetEncrypted(), second.GetEncrypted())
+}
+
+func TestEncryptRejected(t *testing.T) {
+	var tests = []struct {
+		name    string
+		client  pricerpb.PricerClient
+		request *pricerpb.EncryptRequest
+		message string
+	}{
+		{name: "overflow", client: buildClient(t), request: &pricerpb.EncryptRequest{Price: math.MaxFloat64}, message: "price overflow"},
+		{name: "negative", client: buildClient(t), request: &pricerpb.EncryptRequest{Price: -1}, message: "invalid price"},
+		{name: "above max", client: buildClient(t, doubleclick.WithMaxPrice(10)), request: &pricerpb.EncryptRequest{Price: 10.5}, message: "price above max price"},
+		{name: "short seed", client: buildClient(t, doubleclick.WithMinSeedLength(5)), request: &pricerpb.EncryptRequest{Seed: "seed", Price: 1.354}, message: "invalid seed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute:
+			_, err := tt.client.Encrypt(context.Background(), tt.request)
+
+			// Verify:
+			assert.Equal(t, codes.InvalidArgument, status.Code(err), "Unexpected error : %s", err)
+			assert.Contains(t, status.Convert(err).Message(), tt.message)
+		})
+	}
+}
+
+// failingPricer fails every encryption and decryption with err.
+type failingPricer struct {
+	err error
+}
+
+func (p failingPricer) Encrypt(string, float64) (string, error) { return "", p.err }
+
+func (p failingPricer) Decrypt(string) (float64, error) { return 0, p.err }
+
+func TestUnavailableAndInternal(t *testing.T) {
+	// Setup:
+	closed, err := doubleclick.NewPricer(doubleclick.WithKeys(testEncryptionKey, testIntegrityKey))
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	assert.Nil(t, closed.Close())
+
+	var tests = []struct {
+		name   string
+		client pricerpb.PricerClient
+		code   codes.Code
+	}{
+		{name: "closed", client: serveClient(t, New(closed)), code: codes.Unavailable},
+		{name: "internal", client: serveClient(t, New(failingPricer{err: errors.New("internal failure")})), code: codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute:
+			_, errEncrypt := tt.client.Encrypt(context.Background(), &pricerpb.EncryptRequest{Price: 1.354})
+			_, errDecrypt := tt.client.Decrypt(context.Background(), &pricerpb.DecryptRequest{Encrypted: "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg"})
+
+			// Verify:
+			assert.Equal(t, tt.code, status.Code(errEncrypt), "Unexpected error : %s", errEncrypt)
+			assert.Equal(t, tt.code, status.Code(errDecrypt), "Unexpected error : %s", errDecrypt)
+		})
+	}
+}
+
+func TestDecryptInvalid(t *testing.T) {
+	var tests = []struct {
+		name      string
+		encrypted string
+	}{
+		{name: "missing", encrypted: ""},
+		{name: "malformed", encrypted: "not base64 !"},
+		{name: "too short", encrypted: "YWJj"},
+		{name: "bad signature", encrypted: "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpA"},
+		{name: "out of range", encrypted: "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg"},
+	}
+
+	// Setup:
+	client := buildClient(t, doubleclick.WithMaxMicros(1000))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute:
+			_, err := client.Decrypt(context.Background(), &pricerpb.DecryptRequest{Encrypted: tt.encrypted})
+
+			// Verify:
+			assert.Equal(t, codes.InvalidArgument, status.Code(err), "Unexpected error : %s", err)
+			assert.NotContains(t, status.Convert(err).Message(), testEncryptionKey)
+			assert.NotContains(t, status.Convert(err).Message(), testIntegrityKey)
+		})
+	}
+}
+
+func TestInputLengthLimits(t *testing.T) {
+	// Setup:
+	client := buildClient(t)
+	limitedClient := buildClientWith(t, []Option{WithMaxInputLength(38)})
+	ctx := context.Background()
+	encrypted := "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg"
+
+	// Execute:
+	_, errSeed := client.Encrypt(ctx, &pricerpb.EncryptRequest{Seed: strings.Repeat("a", DefaultMaxInputLength+1), Price: 1.354})
+	_, errEncrypted := client.Decrypt(ctx, &pricerpb.DecryptRequest{Encrypted: strings.Repeat("a", DefaultMaxInputLength+1)})
+	decrypted, errLimited := limitedClient.Decrypt(ctx, &pricerpb.DecryptRequest{Encrypted: encrypted})
+	_, errAboveLimit := limitedClient.Decrypt(ctx, &pricerpb.DecryptRequest{Encrypted: encrypted + "="})
+
+	// Verify:
+	assert.Equal(t, codes.InvalidArgument, status.Code(errSeed), "Unexpected error : %s", errSeed)
+	assert.Equal(t, "seed too long: 1025 bytes, limit is 1024", status.Convert(errSeed).Message())
+	assert.Equal(t, codes.InvalidArgument, status.Code(errEncrypted), "Unexpected error : %s", errEncrypted)
+	assert.Equal(t, "encrypted too long: 1025 bytes, limit is 1024", status.Convert(errEncrypted).Message())
+	assert.Nil(t, errLimited)
+	assert.InDelta(t, 1.354, decrypted.GetPrice(), 0.000001)
+	assert.Equal(t, codes.InvalidArgument, status.Code(errAboveLimit), "Unexpected error : %s", errAboveLimit)
+	assert.Equal(t, "encrypted too long: 39 bytes, limit is 38", status.Convert(errAboveLimit).Message())
+}
+
+func TestOversizedInputAllocations(t *testing.T) {
+	// Setup:
+	client := buildClient(t)
+	// Below the 4 MiB gRPC servers accept by default.
+	request := &pricerpb.DecryptRequest{Encrypted: strings.Repeat("a", 2<<20)}
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	// Execute:
+	_, err := client.Decrypt(context.Background(), request)
+	runtime.ReadMemStats(&after)
+
+	// Verify:
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "Unexpected error : %s", err)
+	// The client marshals the 2 MiB input once, the server rejects the message
+	// from its length without reading nor unmarshalling it, which would take two more copies.
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(3<<20))
+}
+
+func TestServerOptionsAcceptLongestInput(t *testing.T) {
+	// Setup:
+	client := buildClient(t)
+	ctx := context.Background()
+
+	// Execute:
+	_, errAtLimit := client.Decrypt(ctx, &pricerpb.DecryptRequest{Encrypted: strings.Repeat("a", DefaultMaxInputLength)})
+	_, errAboveLimit := client.Decrypt(ctx, &pricerpb.DecryptRequest{Encrypted: strings.Repeat("a", DefaultMaxInputLength+1)})
+
+	// Verify:
+	// Inputs up to the limit reach the server, which only rejects them as bad input.
+	assert.Equal(t, codes.InvalidArgument, status.Code(errAtLimit), "Unexpected error : %s", errAtLimit)
+	assert.Equal(t, codes.InvalidArgument, status.Code(errAboveLimit), "Unexpected error : %s", errAboveLimit)
+	assert.Contains(t, errAboveLimit.Error(), "too long")
+}
+
+func TestDecryptStream(t *testing.T) {
+	// Setup:
+	client := buildClient(t)
+	ctx := context.Background()
+	prices := []float64{0.5, 1.354, 12.75}
+	var encrypted []string
+	for _, price := range prices {
+		response, err := client.Encrypt(ctx, &pricerpb.EncryptRequest{Price: price})
+		assert.Nil(t, err)
+		encrypted = append(encrypted, response.GetEncrypted())
+	}
+	// A bad price in the middle of the stream.
+	requests := []string{encrypted[0], "not base64 !", encrypted[1], "", encrypted[2], strings.Repeat("a", DefaultMaxInputLength+1)}
+
+	stream, err := client.DecryptStream(ctx)
+	assert.Nil(t, err)
+
+	// Execute:
+	for _, request := range requests {
+		assert.Nil(t, stream.Send(&pricerpb.DecryptRequest{Encrypted: request}))
+	}
+	assert.Nil(t, stream.CloseSend())
+
+	var responses []*pricerpb.DecryptStreamResponse
+	for {
+		response, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		if err != nil {
+			break
+		}
+		responses = append(responses, response)
+	}
+
+	// Verify:
+	if assert.Len(t, responses, len(requests)) {
+		for i, response := range responses {
+			assert.Equal(t, requests[i], response.GetEncrypted())
+		}
+		assert.Empty(t, responses[0].GetError())
+		assert.InDelta(t, prices[0], responses[0].GetPrice(), 0.000001)
+		assert.NotEmpty(t, responses[1].GetError())
+		assert.Empty(t, responses[2].GetError())
+		assert.InDelta(t, prices[1], responses[2].GetPrice(), 0.000001)
+		assert.NotEmpty(t, responses[3].GetError())
+		assert.Empty(t, responses[4].GetError())
+		assert.InDelta(t, prices[2], responses[4].GetPrice(), 0.000001)
+		assert.Equal(t, "encrypted too long: 1025 bytes, limit is 1024", responses[5].GetError())
+	}
+}
@@ -0,0 +1,6 @@
+// Package pricerpb holds the generated protobuf and gRPC code of the Pricer service.
+//
+// Regenerate it with buf, protoc-gen-go and protoc-gen-go-grpc installed.
+package pricerpb
+
+//go:generate buf generate --template buf.gen.yaml .
@@ -0,0 +1,226 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: pricer.proto
+
+package pricerpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Pricer_Encrypt_FullMethodName       = "/pricers.v1.Pricer/Encrypt"
+	Pricer_Decrypt_FullMethodName       = "/pricers.v1.Pricer/Decrypt"
+	Pricer_DecryptStream_FullMethodName = "/pricers.v1.Pricer/DecryptStream"
+)
+
+// PricerClient is the client API for Pricer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PricerClient interface {
+	// Encrypt encrypts a clear price and a given seed.
+	Encrypt(ctx context.Context, in *EncryptRequest, opts ...grpc.CallOption) (*EncryptResponse, error)
+	// Decrypt decrypts an encrypted price.
+	Decrypt(ctx context.Context, in *DecryptRequest, opts ...grpc.CallOption) (*DecryptResponse, error)
+	// DecryptStream decrypts a stream of encrypted prices, one result per
+	// encrypted price in the same order. A failing price is reported in its
+	// result and doesn't fail the stream.
+	DecryptStream(ctx context.Context, opts ...grpc.CallOption) (Pricer_DecryptStreamClient, error)
+}
+
+type pricerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPricerClient(cc grpc.ClientConnInterface) PricerClient {
+	return &pricerClient{cc}
+}
+
+func (c *pricerClient) Encrypt(ctx context.Context, in *EncryptRequest, opts ...grpc.CallOption) (*EncryptResponse, error) {
+	out := new(EncryptResponse)
+	err := c.cc.Invoke(ctx, Pricer_Encrypt_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pricerClient) Decrypt(ctx context.Context, in *DecryptRequest, opts ...grpc.CallOption) (*DecryptResponse, error) {
+	out := new(DecryptResponse)
+	err := c.cc.Invoke(ctx, Pricer_Decrypt_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pricerClient) DecryptStream(ctx context.Context, opts ...grpc.CallOption) (Pricer_DecryptStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Pricer_ServiceDesc.Streams[0], Pricer_DecryptStream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &pricerDecryptStreamClient{stream}
+	return x, nil
+}
+
+type Pricer_DecryptStreamClient interface {
+	Send(*DecryptRequest) error
+	Recv() (*DecryptStreamResponse, error)
+	grpc.ClientStream
+}
+
+type pricerDecryptStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *pricerDecryptStreamClient) Send(m *DecryptRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *pricerDecryptStreamClient) Recv() (*DecryptStreamResponse, error) {
+	m := new(DecryptStreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// PricerServer is the server API for Pricer service.
+// All implementations must embed UnimplementedPricerServer
+// for forward compatibility
+type PricerServer interface {
+	// Encrypt encrypts a clear price and a given seed.
+	Encrypt(context.Context, *EncryptRequest) (*EncryptResponse, error)
+	// Decrypt decrypts an encrypted price.
+	Decrypt(context.Context, *DecryptRequest) (*DecryptResponse, error)
+	// DecryptStream decrypts a stream of encrypted prices, one result per
+	// encrypted price in the same order. A failing price is reported in its
+	// result and doesn't fail the stream.
+	DecryptStream(Pricer_DecryptStreamServer) error
+	mustEmbedUnimplementedPricerServer()
+}
+
+// UnimplementedPricerServer must be embedded to have forward compatible implementations.
+type UnimplementedPricerServer struct {
+}
+
+func (UnimplementedPricerServer) Encrypt(context.Context, *EncryptRequest) (*EncryptResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Encrypt not implemented")
+}
+func (UnimplementedPricerServer) Decrypt(context.Context, *DecryptRequest) (*DecryptResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Decrypt not implemented")
+}
+func (UnimplementedPricerServer) DecryptStream(Pricer_DecryptStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method DecryptStream not implemented")
+}
+func (UnimplementedPricerServer) mustEmbedUnimplementedPricerServer() {}
+
+// UnsafePricerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PricerServer will
+// result in compilation errors.
+type UnsafePricerServer interface {
+	mustEmbedUnimplementedPricerServer()
+}
+
+func RegisterPricerServer(s grpc.ServiceRegistrar, srv PricerServer) {
+	s.RegisterService(&Pricer_ServiceDesc, srv)
+}
+
+func _Pricer_Encrypt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PricerServer).Encrypt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Pricer_Encrypt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PricerServer).Encrypt(ctx, req.(*EncryptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pricer_Decrypt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DecryptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PricerServer).Decrypt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Pricer_Decrypt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PricerServer).Decrypt(ctx, req.(*DecryptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pricer_DecryptStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PricerServer).DecryptStream(&pricerDecryptStreamServer{stream})
+}
+
+type Pricer_DecryptStreamServer interface {
+	Send(*DecryptStreamResponse) error
+	Recv() (*DecryptRequest, error)
+	grpc.ServerStream
+}
+
+type pricerDecryptStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *pricerDecryptStreamServer) Send(m *DecryptStreamResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *pricerDecryptStreamServer) Recv() (*DecryptRequest, error) {
+	m := new(DecryptRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Pricer_ServiceDesc is the grpc.ServiceDesc for Pricer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Pricer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pricers.v1.Pricer",
+	HandlerType: (*PricerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Encrypt",
+			Handler:    _Pricer_Encrypt_Handler,
+		},
+		{
+			MethodName: "Decrypt",
+			Handler:    _Pricer_Decrypt_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "DecryptStream",
+			Handler:       _Pricer_DecryptStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "pricer.proto",
+}
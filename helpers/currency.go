@@ -0,0 +1,16 @@
+package helpers
+
+import "errors"
+
+// ErrUnknownCurrency : Returned by RateProvider implementations for currencies they have no rate for.
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// ErrStaleRate : Returned by RateProvider implementations whose rate is too old to be used.
+var ErrStaleRate = errors.New("stale currency rate")
+
+// RateProvider : Describing where currency conversion rates come from, e.g. an FX feed.
+// Rate returns how many units of currency to one unit of currency from is worth,
+// currencies being ISO 4217 codes such as "USD".
+type RateProvider interface {
+	Rate(from string, to string) (float64, error)
+}
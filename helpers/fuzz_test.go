@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func FuzzAddBase64Padding(f *testing.F) {
+	// Setup:
+	f.Add("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	f.Add("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg==")
+	f.Add("a")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		// Execute:
+		padded := AddBase64Padding(input)
+
+		// Verify:
+		unpadded := strings.TrimRight(input, "=")
+		if len(unpadded)%4 == 1 {
+			if padded != input {
+				t.Errorf("AddBase64Padding(%q) = %q, invalid input is not kept", input, padded)
+			}
+			return
+		}
+		if len(padded)%4 != 0 {
+			t.Errorf("AddBase64Padding(%q) = %q, length is not a multiple of 4", input, padded)
+		}
+		if !strings.HasPrefix(padded, unpadded) {
+			t.Errorf("AddBase64Padding(%q) = %q, input is not kept", input, padded)
+		}
+		if padding := padded[len(unpadded):]; len(padding) > 2 || strings.Trim(padding, "=") != "" {
+			t.Errorf("AddBase64Padding(%q) = %q, unexpected padding %q", input, padded, padding)
+		}
+		if len(padded)%4 == 0 && len(input) == len(padded) && padded != input {
+			t.Errorf("AddBase64Padding(%q) = %q, padded input is not kept", input, padded)
+		}
+		if strings.ContainsAny(input, "\r\n") {
+			// Decoders skip new lines, which padding arithmetic doesn't.
+			return
+		}
+		expected, rawErr := base64.RawURLEncoding.DecodeString(unpadded)
+		decoded, err := base64.URLEncoding.DecodeString(padded)
+		if (rawErr == nil) != (err == nil) || (err == nil && !bytes.Equal(expected, decoded)) {
+			t.Errorf("AddBase64Padding(%q) = %q, decodes to %v (%v), expected %v (%v)", input, padded, decoded, err, expected, rawErr)
+		}
+	})
+}
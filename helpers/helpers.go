@@ -3,17 +3,59 @@ package helpers
 import (
 	"crypto/hmac"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/hex"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"hash"
+	"math"
+	"math/big"
+	"math/bits"
+	"strconv"
 	"strings"
-
-	"github.com/golang/glog"
+	"time"
 )
 
+// ErrPriceOverflow : Returned when a scaled price can't be represented on 8 bytes.
+var ErrPriceOverflow = errors.New("price overflow")
+
+// ErrSignatureMismatch : Returned when the integrity signature of an encrypted price doesn't match.
+var ErrSignatureMismatch = errors.New("Failed to decrypt")
+
+// ErrInvalidPrice : Returned when a clear price to encrypt is NaN, infinite,
+// or negative while negative prices aren't allowed.
+var ErrInvalidPrice = errors.New("invalid price")
+
+// ErrPriceAboveMax : Returned when a clear price to encrypt is above the highest price a pricer accepts.
+var ErrPriceAboveMax = errors.New("price above max price")
+
+// ErrInvalidSeed : Returned when a seed is shorter than a pricer minimum seed length.
+var ErrInvalidSeed = errors.New("invalid seed")
+
+// ErrMalformedBase64 : Returned when an encrypted price isn't valid web safe base 64.
+var ErrMalformedBase64 = errors.New("malformed base64 encrypted price")
+
+// ErrInvalidCiphertextLength : Returned when a decoded encrypted price doesn't hold enough bytes.
+var ErrInvalidCiphertextLength = errors.New("invalid encrypted price")
+
+// ErrMalformedHex : Returned when an encrypted price isn't a valid hexa string.
+var ErrMalformedHex = errors.New("malformed hex encrypted price")
+
+// ErrZeroIV : Returned when an encrypted price IV is all zeros while rejected with RejectZeroIV.
+var ErrZeroIV = errors.New("all zeros initialization vector")
+
+// ErrPriceOutOfRange : Returned when a decrypted price is above the highest price a pricer accepts.
+var ErrPriceOutOfRange = errors.New("decrypted price out of range")
+
+// ErrClosed : Returned when a pricer is used after being closed.
+var ErrClosed = errors.New("pricer closed")
+
+// maxScaledPrice : Smallest scaled price which can't be represented on 8 bytes, 2^64.
+const maxScaledPrice float64 = 1 << 64
+
 // KeyDecodingMode : Describing how keys should be decoded.
 type KeyDecodingMode string
 
@@ -27,8 +69,278 @@ const (
 	Utf8 KeyDecodingMode = "utf-8"
 	// Hexa : Key should be decoded as hexa string.
 	Hexa KeyDecodingMode = "hexa"
+	// WebSafeBase64 : Key should be decoded as web safe base 64 string, padded or not.
+	WebSafeBase64 KeyDecodingMode = "web-safe-base64"
+	// StdBase64 : Key should be decoded as standard base 64 string, padded or not.
+	StdBase64 KeyDecodingMode = "std-base64"
+	// PEM : Key should be decoded from a PEM block holding raw key bytes, whatever its type.
+	PEM KeyDecodingMode = "pem"
+	// Auto : Key decoding mode should be detected from the key, see DetectKeyEncoding.
+	Auto KeyDecodingMode = "auto"
+)
+
+// ErrAmbiguousKey : Returned when a key decoding mode can't be detected.
+var ErrAmbiguousKey = errors.New("ambiguous key encoding")
+
+// Base64Variant : Describing which base 64 alphabet encrypted prices are encoded with.
+type Base64Variant string
+
+// String : Returns the Base64Variant string representation.
+func (v Base64Variant) String() string {
+	return string(v)
+}
+
+const (
+	// URLSafe : Web safe base 64 alphabet, '-' and '_', as described by DoubleClick specs.
+	URLSafe Base64Variant = "url-safe"
+	// Standard : Standard base 64 alphabet, '+' and '/'.
+	Standard Base64Variant = "standard"
+)
+
+// ParseBase64Variant : Parses Base64Variant from string.
+func ParseBase64Variant(input string) (Base64Variant, error) {
+	var err error
+	var parsed Base64Variant
+
+	switch input {
+	case URLSafe.String():
+		parsed = URLSafe
+	case Standard.String():
+		parsed = Standard
+	default:
+		err = errors.New("input doesn't match to any base64 variant")
+	}
+
+	return parsed, err
+}
+
+// Encoding : Returns the padded base 64 encoding matching the variant, nil if unknown.
+func (v Base64Variant) Encoding() *base64.Encoding {
+	switch v {
+	case URLSafe:
+		return base64.URLEncoding
+	case Standard:
+		return base64.StdEncoding
+	}
+
+	return nil
+}
+
+// HashAlgorithm : Describing the hash function pads and signatures HMACs are computed with.
+type HashAlgorithm string
+
+// String : Returns the HashAlgorithm string representation.
+func (ha HashAlgorithm) String() string {
+	return string(ha)
+}
+
+const (
+	// SHA1 : HMAC-SHA1, as described by DoubleClick specs.
+	SHA1 HashAlgorithm = "sha1"
+	// SHA256 : HMAC-SHA256, as some exchanges use.
+	SHA256 HashAlgorithm = "sha256"
+)
+
+// ParseHashAlgorithm : Parses HashAlgorithm from string.
+func ParseHashAlgorithm(input string) (HashAlgorithm, error) {
+	switch input {
+	case SHA1.String():
+		return SHA1, nil
+	case SHA256.String():
+		return SHA256, nil
+	}
+
+	return "", errors.New("input doesn't match to any hash algorithm")
+}
+
+// Hash : Returns the hash constructor of the HashAlgorithm, nil if unknown.
+func (ha HashAlgorithm) Hash() func() hash.Hash {
+	switch ha {
+	case SHA1:
+		return sha1.New
+	case SHA256:
+		return sha256.New
+	}
+
+	return nil
+}
+
+// SignatureLayout : Describing how price and IV are concatenated before being signed.
+type SignatureLayout string
+
+// String : Returns the SignatureLayout string representation.
+func (sl SignatureLayout) String() string {
+	return string(sl)
+}
+
+const (
+	// PriceIV : Signing price || iv, as described by DoubleClick specs.
+	PriceIV SignatureLayout = "price-iv"
+	// IVPrice : Signing iv || price, as some exchanges do.
+	IVPrice SignatureLayout = "iv-price"
+)
+
+// PriceEncoding : Describing how encrypted prices are encoded as strings.
+type PriceEncoding string
+
+// String : Returns the PriceEncoding string representation.
+func (pe PriceEncoding) String() string {
+	return string(pe)
+}
+
+const (
+	// Base64 : Encrypted prices are base 64 encoded, as described by DoubleClick specs.
+	Base64 PriceEncoding = "base64"
+	// Hex : Encrypted prices are hexa encoded.
+	Hex PriceEncoding = "hex"
+)
+
+// ParsePriceEncoding : Parses PriceEncoding from string.
+func ParsePriceEncoding(input string) (PriceEncoding, error) {
+	var err error
+	var parsed PriceEncoding
+
+	switch input {
+	case Base64.String():
+		parsed = Base64
+	case Hex.String():
+		parsed = Hex
+	default:
+		err = errors.New("input doesn't match to any price encoding")
+	}
+
+	return parsed, err
+}
+
+// RoundingMode : Describing how decrypted prices are rounded.
+type RoundingMode string
+
+// String : Returns the RoundingMode string representation.
+func (rm RoundingMode) String() string {
+	return string(rm)
+}
+
+const (
+	// NoRounding : Prices are kept as is.
+	NoRounding RoundingMode = ""
+	// Nearest : Prices are rounded to the nearest value, half away from zero.
+	Nearest RoundingMode = "nearest"
+	// Floor : Prices are rounded down.
+	Floor RoundingMode = "floor"
+	// Ceil : Prices are rounded up.
+	Ceil RoundingMode = "ceil"
+)
+
+// ParseRoundingMode : Parses RoundingMode from string.
+func ParseRoundingMode(input string) (RoundingMode, error) {
+	var err error
+	var parsed RoundingMode
+
+	switch input {
+	case NoRounding.String():
+		parsed = NoRounding
+	case Nearest.String():
+		parsed = Nearest
+	case Floor.String():
+		parsed = Floor
+	case Ceil.String():
+		parsed = Ceil
+	default:
+		err = errors.New("input doesn't match to any rounding mode")
+	}
+
+	return parsed, err
+}
+
+// RoundPrice : Rounds price to decimals decimal places according to mode.
+// Float noise left by the scale factor division, e.g. 1.3539999999999999,
+// is ignored so that Floor and Ceil don't move already round prices.
+func RoundPrice(price float64, mode RoundingMode, decimals int) float64 {
+	if mode == NoRounding {
+		return price
+	}
+
+	factor := math.Pow10(decimals)
+	scaled := price * factor
+	if nearest := math.Round(scaled); math.Abs(scaled-nearest) <= 1e-9*math.Max(1, math.Abs(scaled)) {
+		scaled = nearest
+	}
+
+	switch mode {
+	case Nearest:
+		scaled = math.Round(scaled)
+	case Floor:
+		scaled = math.Floor(scaled)
+	case Ceil:
+		scaled = math.Ceil(scaled)
+	}
+
+	return scaled / factor
+}
+
+// ZeroIVPolicy : Describing how decryption treats encrypted prices whose IV is all zeros,
+// which hints at an empty or missing seed on the encrypting side.
+type ZeroIVPolicy string
+
+// String : Returns the ZeroIVPolicy string representation.
+func (p ZeroIVPolicy) String() string {
+	return string(p)
+}
+
+const (
+	// AllowZeroIV : All zeros IVs are decrypted as any other IV.
+	AllowZeroIV ZeroIVPolicy = ""
+	// WarnZeroIV : All zeros IVs are decrypted, a warning being emitted.
+	WarnZeroIV ZeroIVPolicy = "warn"
+	// RejectZeroIV : All zeros IVs are rejected.
+	RejectZeroIV ZeroIVPolicy = "reject"
 )
 
+// ParseZeroIVPolicy : Parses ZeroIVPolicy from string.
+func ParseZeroIVPolicy(input string) (ZeroIVPolicy, error) {
+	var err error
+	var parsed ZeroIVPolicy
+
+	switch input {
+	case AllowZeroIV.String():
+		parsed = AllowZeroIV
+	case WarnZeroIV.String():
+		parsed = WarnZeroIV
+	case RejectZeroIV.String():
+		parsed = RejectZeroIV
+	default:
+		err = errors.New("input doesn't match to any zero IV policy")
+	}
+
+	return parsed, err
+}
+
+// Logger : Describing how debug lines are emitted.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+}
+
+// LoggerFunc : Adapter allowing a printf like function (e.g. log.Printf) to be used as a Logger.
+type LoggerFunc func(format string, args ...interface{})
+
+// Debugf : Emits a debug line calling the LoggerFunc.
+func (f LoggerFunc) Debugf(format string, args ...interface{}) {
+	f(format, args...)
+}
+
+// NopLogger : Logger discarding every debug line.
+type NopLogger struct{}
+
+// Debugf : Discards the debug line.
+func (NopLogger) Debugf(format string, args ...interface{}) {}
+
+// Observer : Describing how encrypt / decrypt outcomes are reported, e.g. to metrics.
+// err is nil on success.
+type Observer interface {
+	ObserveEncrypt(d time.Duration, err error)
+	ObserveDecrypt(d time.Duration, err error)
+}
+
 // ParseKeyDecodingMode : Parses KeyDecodingMode from string.
 func ParseKeyDecodingMode(input string) (KeyDecodingMode, error) {
 	var err error
@@ -44,6 +356,18 @@ func ParseKeyDecodingMode(input string) (KeyDecodingMode, error) {
 		case Hexa.String():
 			parsed = Hexa
 			break
+		case WebSafeBase64.String():
+			parsed = WebSafeBase64
+			break
+		case StdBase64.String():
+			parsed = StdBase64
+			break
+		case PEM.String():
+			parsed = PEM
+			break
+		case Auto.String():
+			parsed = Auto
+			break
 		default:
 			err = errors.New("input doesn't match to any key decoding mode")
 		}
@@ -52,12 +376,59 @@ func ParseKeyDecodingMode(input string) (KeyDecodingMode, error) {
 	return parsed, err
 }
 
-// CreateHmac : Returns Hash from input string.
-func CreateHmac(key string, isBase64 bool, mode KeyDecodingMode) (hash.Hash, error) {
+// DetectKeyEncoding : Returns the decoding mode of key, either Hexa or WebSafeBase64.
+// A key decoding to KeyLength bytes in only one of them is detected as such.
+// Otherwise a key valid in a single mode is detected as such, and a key valid
+// in both or none returns ErrAmbiguousKey.
+func DetectKeyEncoding(key string) (KeyDecodingMode, error) {
+	hexaKey, hexaErr := hex.DecodeString(key)
+	base64Key, base64Err := base64.URLEncoding.DecodeString(AddBase64Padding(key))
+
+	isHexa := hexaErr == nil && len(hexaKey) > 0
+	isBase64 := base64Err == nil && len(base64Key) > 0
+
+	switch {
+	case isHexa && len(hexaKey) == KeyLength && !(isBase64 && len(base64Key) == KeyLength):
+		return Hexa, nil
+	case isBase64 && len(base64Key) == KeyLength && !(isHexa && len(hexaKey) == KeyLength):
+		return WebSafeBase64, nil
+	case isHexa && !isBase64:
+		return Hexa, nil
+	case isBase64 && !isHexa:
+		return WebSafeBase64, nil
+	case isHexa && isBase64:
+		return "", fmt.Errorf("%w: key is both valid hexa and web safe base 64", ErrAmbiguousKey)
+	}
+
+	return "", fmt.Errorf("%w: key is neither valid hexa nor web safe base 64", ErrAmbiguousKey)
+}
+
+// DecodeKey : Returns key bytes decoded from input string.
+// isBase64 is ignored for WebSafeBase64, StdBase64, PEM and Auto modes.
+// Whitespace around key, such as the trailing newline of keys read from files or
+// secret managers, is trimmed before decoding whatever the mode, so that utf-8 keys
+// can't start nor end with whitespace.
+func DecodeKey(key string, isBase64 bool, mode KeyDecodingMode) ([]byte, error) {
 	var err error
 	var b64DecodedKey []byte
 	var k []byte
 
+	key = strings.TrimSpace(key)
+
+	if mode == Auto {
+		if mode, err = DetectKeyEncoding(key); err != nil {
+			return nil, err
+		}
+	}
+	switch mode {
+	case WebSafeBase64:
+		return base64.URLEncoding.DecodeString(AddBase64Padding(key))
+	case StdBase64:
+		return base64.StdEncoding.DecodeString(AddBase64Padding(key))
+	case PEM:
+		return decodePEMKey(key)
+	}
+
 	if isBase64 {
 		b64DecodedKey, err = base64.URLEncoding.DecodeString(AddBase64Padding(key))
 		if err == nil {
@@ -76,38 +447,256 @@ func CreateHmac(key string, isBase64 bool, mode KeyDecodingMode) (hash.Hash, err
 		return nil, err
 	}
 
-	return hmac.New(sha1.New, k), nil
+	return k, nil
+}
+
+// decodePEMKey : Returns raw key bytes of the first PEM block of key.
+// Anything after the block is rejected, so that a key file isn't half read.
+func decodePEMKey(key string) ([]byte, error) {
+	block, rest := pem.Decode([]byte(key))
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if len(strings.TrimSpace(string(rest))) != 0 {
+		return nil, errors.New("unexpected data after PEM block")
+	}
+
+	return block.Bytes, nil
+}
+
+// KeyFingerprint : Returns a stable, non reversible fingerprint of decoded key bytes,
+// the first 8 bytes of their SHA-256 sum as hexa, so that keys can be compared
+// across deployments without being disclosed. Keys decoded from different
+// representations have the same fingerprint.
+func KeyFingerprint(key []byte) string {
+	sum := sha256.Sum256(key)
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// NewHmac : Returns a new HMAC-SHA1 Hash from decoded key bytes, as described by DoubleClick specs.
+// Returned Hash holds a state and shouldn't be shared across goroutines.
+func NewHmac(key []byte) hash.Hash {
+	return NewHmacWith(key, SHA1)
+}
+
+// NewHmacWith : Returns a new HMAC Hash from decoded key bytes, computed with algorithm.
+// Keys of any length are accepted: as HMAC requires, keys longer than the hash
+// block size are hashed first, and shorter ones are padded with zeros.
+// Returned Hash holds a state and shouldn't be shared across goroutines.
+// Unknown algorithms fall back to SHA1.
+func NewHmacWith(key []byte, algorithm HashAlgorithm) hash.Hash {
+	newHash := algorithm.Hash()
+	if newHash == nil {
+		newHash = sha1.New
+	}
+
+	return hmac.New(newHash, key)
+}
+
+// CreateHmac : Returns Hash from input string.
+func CreateHmac(key string, isBase64 bool, mode KeyDecodingMode) (hash.Hash, error) {
+	k, err := DecodeKey(key, isBase64, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewHmac(k), nil
 }
 
 // HmacSum : Returns Hmac sum bytes.
+// hmac is reset and written to, so that it must not be shared across goroutines,
+// see HmacSumKey for a variant holding no state.
 func HmacSum(hmac hash.Hash, buf []byte) []byte {
 	hmac.Reset()
 	hmac.Write(buf)
 	return hmac.Sum(nil)
 }
 
-// AddBase64Padding : Returns base 64 string adding extra padding if needed.
+// HmacSumKey : Returns HMAC sum bytes of buf from decoded key bytes computed with algorithm,
+// building a new HMAC on each call. Unlike HmacSum, no state is shared across calls, so that
+// it is safe for concurrent use, at the cost of allocating an HMAC each time.
+// Unknown algorithms fall back to SHA1, as for NewHmacWith.
+func HmacSumKey(key []byte, buf []byte, algorithm HashAlgorithm) []byte {
+	mac := NewHmacWith(key, algorithm)
+	mac.Write(buf)
+	return mac.Sum(nil)
+}
+
+// HmacSumTo : Appends Hmac sum bytes to dst and returns the extended buffer.
+// No allocation is made when dst has enough capacity.
+func HmacSumTo(hmac hash.Hash, buf []byte, dst []byte) []byte {
+	hmac.Reset()
+	hmac.Write(buf)
+	return hmac.Sum(dst)
+}
+
+// AddBase64Padding : Returns base 64 string with its padding normalized, adding missing padding
+// and removing extra one. Correctly padded input is returned unchanged, as is input
+// no padding can make valid, i.e. whose length without padding is 1 modulo 4, for decoders to reject it.
+// Padding character is the same for every Base64Variant.
 func AddBase64Padding(base64Input string) string {
-	var base64 string
+	unpadded := strings.TrimRight(base64Input, "=")
 
-	base64 = base64Input
+	var padding int
+	switch len(unpadded) % 4 {
+	case 1:
+		return base64Input
+	case 2:
+		padding = 2
+	case 3:
+		padding = 1
+	}
 
-	if i := len(base64) % 4; i != 0 {
-		base64 += strings.Repeat("=", 4-i)
+	if len(base64Input) == len(unpadded)+padding {
+		return base64Input
 	}
 
-	return base64
+	return unpadded + strings.Repeat("=", padding)
+}
+
+// AppendBase64Padding : Appends base 64 string to dst with its padding normalized
+// as AddBase64Padding does, and returns the extended buffer, so that callers
+// reusing dst pad encrypted prices without allocating.
+func AppendBase64Padding(dst []byte, base64Input string) []byte {
+	unpadded := strings.TrimRight(base64Input, "=")
+	if len(unpadded)%4 == 1 {
+		return append(dst, base64Input...)
+	}
+
+	dst = append(dst, unpadded...)
+	for i := len(unpadded); i%4 != 0; i++ {
+		dst = append(dst, '=')
+	}
+
+	return dst
 }
 
 // ApplyScaleFactor : Applies a scale factor to a given price.
 // Scaled price will be represented on 8 bytes.
+// Overflows aren't detected, ScalePrice should be preferred.
+// isDebugMode is kept for backward compatibility only, scaled price bytes
+// are logged by callers through their own Logger.
 func ApplyScaleFactor(price float64, scaleFactor float64, isDebugMode bool) [8]byte {
 	scaledPrice := [8]byte{}
 	binary.BigEndian.PutUint64(scaledPrice[:], uint64(price*scaleFactor))
 
-	if isDebugMode == true || glog.V(2) {
-		glog.Info(fmt.Sprintf("Micro price bytes: %v", scaledPrice))
+	return scaledPrice
+}
+
+// PriceToMicros : Converts a price to micros, or whatever unit scaleFactor converts to,
+// multiplying it by scaleFactor. Micros are truncated, e.g. 1.0000009 is 1000000 micros
+// with a 1,000,000 scale factor. ErrPriceOverflow is returned if micros don't fit
+// on 8 bytes, and an error if the scaled price is negative or NaN.
+func PriceToMicros(price float64, scaleFactor float64) (uint64, error) {
+	scaled := price * scaleFactor
+	if scaled >= maxScaledPrice {
+		return 0, fmt.Errorf("%w: %g scaled by %g doesn't fit on 8 bytes", ErrPriceOverflow, price, scaleFactor)
+	}
+	// Also true for NaN.
+	if !(scaled >= 0) {
+		return 0, fmt.Errorf("price %g scaled by %g can't be converted to micros", price, scaleFactor)
 	}
 
-	return scaledPrice
+	return uint64(scaled), nil
+}
+
+// MicrosToPrice : Converts micros, or whatever unit scaleFactor converts to, back to a price,
+// dividing them by scaleFactor. Micros above 2^53 may lose precision.
+func MicrosToPrice(micros uint64, scaleFactor float64) float64 {
+	return float64(micros) / scaleFactor
+}
+
+// centsPerUnit : Cents in a price unit, e.g. in a dollar.
+const centsPerUnit = 100
+
+// CentsToMicros : Converts a price in cents to micros, or whatever unit scaleFactor
+// converts to, multiplying it by scaleFactor / 100 with integer arithmetic only.
+// As with PriceToMicros micros are truncated, which only happens for scale factors
+// which aren't a multiple of 100. ErrPriceOverflow is returned if micros don't fit
+// on 8 bytes, and an error if cents are negative or scaleFactor is zero.
+func CentsToMicros(cents int64, scaleFactor uint64) (uint64, error) {
+	if cents < 0 {
+		return 0, fmt.Errorf("price of %d cents can't be converted to micros", cents)
+	}
+	if scaleFactor == 0 {
+		return 0, errors.New("scale factor should be positive, got 0")
+	}
+
+	hi, lo := bits.Mul64(uint64(cents), scaleFactor)
+	if hi >= centsPerUnit {
+		return 0, fmt.Errorf("%w: %d cents scaled by %d doesn't fit on 8 bytes", ErrPriceOverflow, cents, scaleFactor)
+	}
+	micros, _ := bits.Div64(hi, lo, centsPerUnit)
+
+	return micros, nil
+}
+
+// MicrosToCents : Converts micros, or whatever unit scaleFactor converts to, back to a price
+// in cents, multiplying them by 100 / scaleFactor with integer arithmetic only. Fractions
+// of cents are truncated, e.g. 1354000 micros are 135 cents with a 1,000,000 scale factor.
+// ErrPriceOverflow is returned if cents don't fit on a signed 64 bits integer, and an error
+// if scaleFactor is zero.
+func MicrosToCents(micros uint64, scaleFactor uint64) (int64, error) {
+	if scaleFactor == 0 {
+		return 0, errors.New("scale factor should be positive, got 0")
+	}
+
+	hi, lo := bits.Mul64(micros, centsPerUnit)
+	if hi >= scaleFactor {
+		return 0, fmt.Errorf("%w: %d micros scaled by %d don't fit on 8 bytes as cents", ErrPriceOverflow, micros, scaleFactor)
+	}
+	cents, _ := bits.Div64(hi, lo, scaleFactor)
+	if cents > math.MaxInt64 {
+		return 0, fmt.Errorf("%w: %d micros scaled by %d don't fit on 8 bytes as cents", ErrPriceOverflow, micros, scaleFactor)
+	}
+
+	return int64(cents), nil
+}
+
+// ScalePrice : Applies a scale factor to a given price.
+// Scaled price will be represented on 8 bytes, ErrPriceOverflow is returned
+// if it doesn't fit. See PriceToMicros.
+func ScalePrice(price float64, scaleFactor float64) ([8]byte, error) {
+	scaledPrice := [8]byte{}
+
+	micros, err := PriceToMicros(price, scaleFactor)
+	if err != nil {
+		return scaledPrice, err
+	}
+	binary.BigEndian.PutUint64(scaledPrice[:], micros)
+
+	return scaledPrice, nil
+}
+
+// ScalePriceInteger : Applies an integer scale factor to a given price.
+// The price is scaled from its shortest decimal representation, e.g. 1.005,
+// using exact arithmetic, so that it doesn't suffer from the float
+// representation error of price * scaleFactor (1004999.999... for 1.005 * 1e6).
+// As with ScalePrice, digits beyond the scale factor precision are truncated.
+// Scaled price will be represented on 8 bytes, ErrPriceOverflow is returned
+// if it doesn't fit.
+func ScalePriceInteger(price float64, scaleFactor int64) ([8]byte, error) {
+	scaledPrice := [8]byte{}
+
+	if price < 0 || math.IsNaN(price) || math.IsInf(price, 0) {
+		return scaledPrice, fmt.Errorf("price %g can't be scaled", price)
+	}
+	if scaleFactor <= 0 {
+		return scaledPrice, fmt.Errorf("scale factor should be positive, got %d", scaleFactor)
+	}
+
+	decimal, ok := new(big.Rat).SetString(strconv.FormatFloat(price, 'f', -1, 64))
+	if !ok {
+		return scaledPrice, fmt.Errorf("price %g can't be scaled", price)
+	}
+	decimal.Mul(decimal, new(big.Rat).SetInt64(scaleFactor))
+
+	scaled := new(big.Int).Quo(decimal.Num(), decimal.Denom())
+	if !scaled.IsUint64() {
+		return scaledPrice, fmt.Errorf("%w: %g scaled by %d doesn't fit on 8 bytes", ErrPriceOverflow, price, scaleFactor)
+	}
+	binary.BigEndian.PutUint64(scaledPrice[:], scaled.Uint64())
+
+	return scaledPrice, nil
 }
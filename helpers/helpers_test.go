@@ -0,0 +1,765 @@
+package helpers
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScalePrice(t *testing.T) {
+	// Setup:
+	var pricesTestCase = []struct {
+		price       float64
+		scaleFactor float64
+		micros      uint64
+	}{
+		{0, 1000000, 0},
+		{1.354, 1000000, 1354000},
+		{100, 500000, 50000000},
+		// Largest float64 scaled price fitting on 8 bytes
+		{math.Nextafter(1<<64, 0), 1, 18446744073709549568},
+	}
+
+	for _, price := range pricesTestCase {
+		// Execute:
+		scaled, err := ScalePrice(price.price, price.scaleFactor)
+
+		// Verify:
+		assert.Nil(t, err, "Scaling failed. Error : %s", err)
+		assert.Equal(t, price.micros, binary.BigEndian.Uint64(scaled[:]))
+	}
+}
+
+func TestApplyScaleFactor(t *testing.T) {
+	// Setup:
+	var pricesTestCase = []struct {
+		price       float64
+		scaleFactor float64
+		bytes       [8]byte
+	}{
+		// Scale factor 1 packs micros as is
+		{1354000, 1, [8]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x14, 0xa9, 0x10}},
+		{1.354, 1000000, [8]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x14, 0xa9, 0x10}},
+		{1 << 56, 1, [8]byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+	}
+
+	for _, price := range pricesTestCase {
+		// Execute:
+		scaled := ApplyScaleFactor(price.price, price.scaleFactor, false)
+
+		// Verify:
+		assert.Equal(t, price.bytes, scaled)
+	}
+}
+
+func TestScalePriceOverflow(t *testing.T) {
+	// Setup:
+	var pricesTestCase = []struct {
+		price       float64
+		scaleFactor float64
+	}{
+		{1 << 64, 1},
+		{math.Nextafter(1<<64, 0), 2},
+		{1e13 + 1, 1e6 * 2},
+		{math.Inf(1), 1000000},
+	}
+
+	for _, price := range pricesTestCase {
+		// Execute:
+		_, err := ScalePrice(price.price, price.scaleFactor)
+
+		// Verify:
+		assert.True(t, errors.Is(err, ErrPriceOverflow), "Error should be ErrPriceOverflow but was : %v", err)
+	}
+}
+
+func TestScalePriceInteger(t *testing.T) {
+	// Setup:
+	var pricesTestCase = []struct {
+		price         float64
+		scaleFactor   int64
+		floatMicros   uint64
+		integerMicros uint64
+	}{
+		// Float representation error, scaling is off by one unit
+		{1.005, 1000000, 1004999, 1005000},
+		{0.29, 100, 28, 29},
+		{4.35, 100, 434, 435},
+		{1.005, 1000, 1004, 1005},
+		// Same results
+		{1.354, 1000000, 1354000, 1354000},
+		{0, 1000000, 0, 0},
+		{100, 500000, 50000000, 50000000},
+		// Digits beyond the scale factor precision are truncated
+		{1.0000009, 1000000, 1000000, 1000000},
+	}
+
+	for _, price := range pricesTestCase {
+		// Execute:
+		floatScaled, floatErr := ScalePrice(price.price, float64(price.scaleFactor))
+		integerScaled, integerErr := ScalePriceInteger(price.price, price.scaleFactor)
+
+		// Verify:
+		assert.Nil(t, floatErr, "Scaling failed. Error : %s", floatErr)
+		assert.Nil(t, integerErr, "Scaling failed. Error : %s", integerErr)
+		assert.Equal(t, price.floatMicros, binary.BigEndian.Uint64(floatScaled[:]), "Float scaling of %f", price.price)
+		assert.Equal(t, price.integerMicros, binary.BigEndian.Uint64(integerScaled[:]), "Integer scaling of %f", price.price)
+	}
+}
+
+func TestScalePriceIntegerErrors(t *testing.T) {
+	// Setup:
+	var pricesTestCase = []struct {
+		price       float64
+		scaleFactor int64
+		err         error
+	}{
+		{1 << 64, 1, ErrPriceOverflow},
+		{1 << 45, 1000000, ErrPriceOverflow},
+		{-1, 1000000, nil},
+		{math.NaN(), 1000000, nil},
+		{math.Inf(1), 1000000, nil},
+		{1.354, 0, nil},
+	}
+
+	for _, price := range pricesTestCase {
+		// Execute:
+		_, err := ScalePriceInteger(price.price, price.scaleFactor)
+
+		// Verify:
+		assert.NotNil(t, err, "Scaling %f by %d should fail", price.price, price.scaleFactor)
+		if price.err != nil {
+			assert.True(t, errors.Is(err, price.err), "Unexpected error : %s", err)
+		}
+	}
+}
+
+func TestRoundPrice(t *testing.T) {
+	// Setup:
+	var pricesTestCase = []struct {
+		price    float64
+		mode     RoundingMode
+		decimals int
+		rounded  float64
+	}{
+		{1.23456, NoRounding, 2, 1.23456},
+		{1.23456, Nearest, 2, 1.23},
+		{1.235, Nearest, 2, 1.24},
+		{1.23456, Floor, 2, 1.23},
+		{1.23456, Ceil, 2, 1.24},
+		{1.23456, Nearest, 0, 1},
+		{1.5, Nearest, 0, 2},
+		{1.23456, Floor, 4, 1.2345},
+		// Float noise from the scale factor division is ignored
+		{1353999.0 / 1000000, Floor, 6, 1.353999},
+		{0.1 + 0.2, Floor, 1, 0.3},
+		{0.3 - 0.1, Ceil, 1, 0.2},
+		// Already round prices are kept
+		{1.25, Floor, 2, 1.25},
+		{1.25, Ceil, 2, 1.25},
+	}
+
+	for _, price := range pricesTestCase {
+		// Execute:
+		rounded := RoundPrice(price.price, price.mode, price.decimals)
+
+		// Verify:
+		assert.InDelta(t, price.rounded, rounded, 1e-12, "Rounding %v %s to %d decimals", price.price, price.mode, price.decimals)
+	}
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	for _, mode := range []RoundingMode{NoRounding, Nearest, Floor, Ceil} {
+		// Execute:
+		parsed, err := ParseRoundingMode(mode.String())
+
+		// Verify:
+		assert.Nil(t, err)
+		assert.Equal(t, mode, parsed)
+	}
+
+	_, err := ParseRoundingMode("half-even")
+	assert.NotNil(t, err)
+}
+
+func TestParseZeroIVPolicy(t *testing.T) {
+	for _, policy := range []ZeroIVPolicy{AllowZeroIV, WarnZeroIV, RejectZeroIV} {
+		// Execute:
+		parsed, err := ParseZeroIVPolicy(policy.String())
+
+		// Verify:
+		assert.Nil(t, err)
+		assert.Equal(t, policy, parsed)
+	}
+
+	_, err := ParseZeroIVPolicy("ignore")
+	assert.NotNil(t, err)
+}
+
+func TestDetectKeyEncoding(t *testing.T) {
+	// Setup:
+	var keysTestCase = []struct {
+		key  string
+		mode KeyDecodingMode
+		err  error
+	}{
+		// Clearly hexa, also valid base 64 but decoding to 48 bytes
+		{"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", Hexa, nil},
+		{"6356770B3C111C07F778AFD69F16643E9110090FD4C479D91181EED2523788F1", Hexa, nil},
+		// Clearly base 64, padded or not
+		{"ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU", WebSafeBase64, nil},
+		{"vQo9-4KtlcXmPhWaYvc8asqYuiSVMiGUdZ1RLXfrK7U=", WebSafeBase64, nil},
+		// Valid in a single mode, whatever its length
+		{"abc-", WebSafeBase64, nil},
+		{"abc", WebSafeBase64, nil},
+		// Valid in both, none decoding to 32 bytes
+		{"deadbeef", "", ErrAmbiguousKey},
+		// Valid in none
+		{"not a key!", "", ErrAmbiguousKey},
+		{"", "", ErrAmbiguousKey},
+	}
+
+	for _, k := range keysTestCase {
+		// Execute:
+		mode, err := DetectKeyEncoding(k.key)
+
+		// Verify:
+		assert.Equal(t, k.mode, mode, "Detecting %s", k.key)
+		if k.err == nil {
+			assert.Nil(t, err, "Detecting %s failed. Error : %s", k.key, err)
+		} else {
+			assert.True(t, errors.Is(err, k.err), "Detecting %s, unexpected error : %s", k.key, err)
+		}
+	}
+}
+
+func TestDecodeKeyAuto(t *testing.T) {
+	// Setup:
+	var keysTestCase = []struct {
+		key      string
+		isBase64 bool
+	}{
+		{"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", false},
+		{"ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU", false},
+		// isBase64 is ignored
+		{"ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU", true},
+	}
+
+	for _, k := range keysTestCase {
+		// Execute:
+		key, err := DecodeKey(k.key, k.isBase64, Auto)
+
+		// Verify:
+		assert.Nil(t, err, "Decoding failed. Error : %s", err)
+		assert.Equal(t, "652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", hex.EncodeToString(key))
+	}
+}
+
+func TestDecodeKeyEncodings(t *testing.T) {
+	// Setup:
+	// The same key in every supported representation
+	var keysTestCase = []struct {
+		key  string
+		mode KeyDecodingMode
+	}{
+		{"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", Hexa},
+		{"ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU", WebSafeBase64},
+		{"ZS+DraBUUVeht/sMDgn1nnM3My/nq9TrEESbjubDkTU=", StdBase64},
+		{"ZS+DraBUUVeht/sMDgn1nnM3My/nq9TrEESbjubDkTU", StdBase64},
+		{"-----BEGIN ENCRYPTION KEY-----\nZS+DraBUUVeht/sMDgn1nnM3My/nq9TrEESbjubDkTU=\n-----END ENCRYPTION KEY-----\n", PEM},
+	}
+	expectedSum := HmacSum(NewHmac([]byte{
+		0x65, 0x2f, 0x83, 0xad, 0xa0, 0x54, 0x51, 0x57, 0xa1, 0xb7, 0xfb, 0x0c, 0x0e, 0x09, 0xf5, 0x9e,
+		0x73, 0x37, 0x33, 0x2f, 0xe7, 0xab, 0xd4, 0xeb, 0x10, 0x44, 0x9b, 0x8e, 0xe6, 0xc3, 0x91, 0x35,
+	}), []byte("seed"))
+
+	for _, k := range keysTestCase {
+		// Execute:
+		key, err := DecodeKey(k.key, false, k.mode)
+		assert.Nil(t, err, "Decoding failed. Error : %s", err)
+		hmac, err := CreateHmac(k.key, false, k.mode)
+		assert.Nil(t, err, "Hmac creation failed. Error : %s", err)
+
+		// Verify:
+		assert.Equal(t, "652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", hex.EncodeToString(key))
+		assert.Equal(t, expectedSum, HmacSum(hmac, []byte("seed")), "Unexpected HMAC for %s key", k.mode)
+	}
+}
+
+func TestDecodeKeyTrimsWhitespace(t *testing.T) {
+	// Setup:
+	var keysTestCase = []struct {
+		key      string
+		isBase64 bool
+		mode     KeyDecodingMode
+	}{
+		{"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", false, Hexa},
+		{"ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU", false, WebSafeBase64},
+		{"ZS+DraBUUVeht/sMDgn1nnM3My/nq9TrEESbjubDkTU=", false, StdBase64},
+		{"ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU", false, Auto},
+		{"ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU", true, Utf8},
+	}
+	var whitespacesTestCase = []struct {
+		prefix string
+		suffix string
+	}{
+		{"", "\n"},
+		{"", "\r\n"},
+		{"  ", "  "},
+		{"\t\n", " \n\n"},
+	}
+
+	for _, k := range keysTestCase {
+		trimmed, err := DecodeKey(k.key, k.isBase64, k.mode)
+		assert.Nil(t, err, "Decoding failed. Error : %s", err)
+		trimmedHmac, err := CreateHmac(k.key, k.isBase64, k.mode)
+		assert.Nil(t, err, "Hmac creation failed. Error : %s", err)
+
+		for _, w := range whitespacesTestCase {
+			// Execute:
+			key, err := DecodeKey(w.prefix+k.key+w.suffix, k.isBase64, k.mode)
+			assert.Nil(t, err, "Decoding failed. Error : %s", err)
+			hmac, err := CreateHmac(w.prefix+k.key+w.suffix, k.isBase64, k.mode)
+			assert.Nil(t, err, "Hmac creation failed. Error : %s", err)
+
+			// Verify:
+			assert.Equal(t, trimmed, key, "Unexpected key for %q in %s mode", w.prefix+k.key+w.suffix, k.mode)
+			assert.Equal(t, HmacSum(trimmedHmac, []byte("seed")), HmacSum(hmac, []byte("seed")))
+		}
+	}
+}
+
+func TestDecodeKeyPEMErrors(t *testing.T) {
+	// Setup:
+	var keysTestCase = []struct {
+		key          string
+		errorMessage string
+	}{
+		{"ZS+DraBUUVeht/sMDgn1nnM3My/nq9TrEESbjubDkTU=", "no PEM block found"},
+		{"-----BEGIN KEY-----\nZS+D\n-----END KEY-----\ntrailing", "unexpected data after PEM block"},
+	}
+
+	for _, k := range keysTestCase {
+		// Execute:
+		_, err := DecodeKey(k.key, false, PEM)
+
+		// Verify:
+		assert.EqualError(t, err, k.errorMessage)
+	}
+}
+
+func TestParseKeyDecodingMode(t *testing.T) {
+	for _, mode := range []KeyDecodingMode{Utf8, Hexa, WebSafeBase64, StdBase64, PEM, Auto} {
+		// Execute:
+		parsed, err := ParseKeyDecodingMode(mode.String())
+
+		// Verify:
+		assert.Nil(t, err, "Parsing failed. Error : %s", err)
+		assert.Equal(t, mode, parsed)
+	}
+
+	// Execute:
+	_, err := ParseKeyDecodingMode("base32")
+
+	// Verify:
+	assert.NotNil(t, err)
+}
+
+func TestAddBase64Padding(t *testing.T) {
+	// Setup:
+	var tests = []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "empty", input: "", expected: ""},
+		{name: "residue 0", input: "YWJj", expected: "YWJj"},
+		{name: "residue 1", input: "YWJjZ", expected: "YWJjZ"},
+		{name: "residue 1, padded", input: "YWJjZ===", expected: "YWJjZ==="},
+		{name: "residue 2", input: "YWJjZA", expected: "YWJjZA=="},
+		{name: "residue 3", input: "YWJjZGU", expected: "YWJjZGU="},
+		{name: "padded residue 2", input: "YWJjZA==", expected: "YWJjZA=="},
+		{name: "padded residue 3", input: "YWJjZGU=", expected: "YWJjZGU="},
+		{name: "missing padding", input: "YWJjZA=", expected: "YWJjZA=="},
+		{name: "extra padding", input: "YWJjZGU===", expected: "YWJjZGU="},
+		{name: "extra padding, residue 0", input: "YWJj==", expected: "YWJj"},
+		{name: "url safe", input: "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", expected: "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg=="},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute:
+			padded := AddBase64Padding(tt.input)
+			appended := AppendBase64Padding([]byte("prefix"), tt.input)
+
+			// Verify:
+			assert.Equal(t, tt.expected, padded)
+			assert.Equal(t, "prefix"+tt.expected, string(appended))
+		})
+	}
+}
+
+func TestKeyFingerprint(t *testing.T) {
+	// Setup:
+	key, _ := hex.DecodeString("652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135")
+	otherKey, _ := hex.DecodeString("bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5")
+
+	// Execute:
+	fingerprint := KeyFingerprint(key)
+
+	// Verify:
+	assert.Len(t, fingerprint, 16)
+	assert.Equal(t, fingerprint, KeyFingerprint(append([]byte(nil), key...)))
+	assert.NotEqual(t, fingerprint, KeyFingerprint(otherKey))
+}
+
+func TestPriceToMicros(t *testing.T) {
+	// Setup:
+	var tests = []struct {
+		name        string
+		price       float64
+		scaleFactor float64
+		micros      uint64
+	}{
+		{name: "zero", price: 0, scaleFactor: 1000000, micros: 0},
+		{name: "negative zero", price: math.Copysign(0, -1), scaleFactor: 1000000, micros: 0},
+		{name: "micros", price: 1.354, scaleFactor: 1000000, micros: 1354000},
+		{name: "sub micros are truncated", price: 1.0000009, scaleFactor: 1000000, micros: 1000000},
+		{name: "float representation is truncated", price: 1.005, scaleFactor: 1000000, micros: 1004999},
+		{name: "smallest", price: 0.000001, scaleFactor: 1000000, micros: 1},
+		{name: "below smallest", price: 0.0000009, scaleFactor: 1000000, micros: 0},
+		{name: "other scale factor", price: 100, scaleFactor: 500000, micros: 50000000},
+		{name: "largest", price: math.Nextafter(1<<64, 0), scaleFactor: 1, micros: 18446744073709549568},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute:
+			micros, err := PriceToMicros(tt.price, tt.scaleFactor)
+			scaled, scaledErr := ScalePrice(tt.price, tt.scaleFactor)
+
+			// Verify:
+			assert.Nil(t, err, "Unexpected error : %s", err)
+			assert.Equal(t, tt.micros, micros)
+			assert.Nil(t, scaledErr, "Unexpected error : %s", scaledErr)
+			assert.Equal(t, micros, binary.BigEndian.Uint64(scaled[:]))
+		})
+	}
+}
+
+func TestPriceToMicrosErrors(t *testing.T) {
+	// Setup:
+	var tests = []struct {
+		name        string
+		price       float64
+		scaleFactor float64
+		isOverflow  bool
+	}{
+		{name: "2^64", price: 1 << 64, scaleFactor: 1, isOverflow: true},
+		{name: "largest scaled above 2^64", price: math.Nextafter(1<<64, 0), scaleFactor: 2, isOverflow: true},
+		{name: "infinity", price: math.Inf(1), scaleFactor: 1000000, isOverflow: true},
+		{name: "negative", price: -1, scaleFactor: 1000000},
+		{name: "negative scale factor", price: 1, scaleFactor: -1000000},
+		{name: "negative infinity", price: math.Inf(-1), scaleFactor: 1000000},
+		{name: "NaN", price: math.NaN(), scaleFactor: 1000000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute:
+			micros, err := PriceToMicros(tt.price, tt.scaleFactor)
+
+			// Verify:
+			assert.NotNil(t, err)
+			assert.Equal(t, uint64(0), micros)
+			assert.Equal(t, tt.isOverflow, errors.Is(err, ErrPriceOverflow), "Unexpected error : %s", err)
+		})
+	}
+}
+
+func TestMicrosToPrice(t *testing.T) {
+	// Setup:
+	var tests = []struct {
+		micros      uint64
+		scaleFactor float64
+		price       float64
+	}{
+		{0, 1000000, 0},
+		{1, 1000000, 0.000001},
+		{1354000, 1000000, 1.354},
+		{50000000, 500000, 100},
+		{math.MaxUint64, 1, 1 << 64},
+	}
+
+	for _, tt := range tests {
+		// Execute:
+		price := MicrosToPrice(tt.micros, tt.scaleFactor)
+
+		// Verify:
+		assert.Equal(t, tt.price, price)
+	}
+
+	// Round trip
+	for _, price := range []float64{0, 0.01, 1.354, 12.75, 1000} {
+		// Execute:
+		micros, err := PriceToMicros(price, 1000000)
+
+		// Verify:
+		assert.Nil(t, err, "Unexpected error : %s", err)
+		assert.Equal(t, price, MicrosToPrice(micros, 1000000))
+	}
+}
+
+// manualHmac computes HMAC as defined by RFC 2104, independently from crypto/hmac:
+// hash((key ^ opad) || hash((key ^ ipad) || data)), keys longer than the block size
+// being hashed first and keys shorter being padded with zeros.
+func manualHmac(newHash func() hash.Hash, key []byte, data []byte) []byte {
+	blockSize := newHash().BlockSize()
+	if len(key) > blockSize {
+		keyHash := newHash()
+		keyHash.Write(key)
+		key = keyHash.Sum(nil)
+	}
+	paddedKey := make([]byte, blockSize)
+	copy(paddedKey, key)
+
+	inner := newHash()
+	outer := newHash()
+	for _, b := range paddedKey {
+		inner.Write([]byte{b ^ 0x36})
+		outer.Write([]byte{b ^ 0x5c})
+	}
+	inner.Write(data)
+	outer.Write(inner.Sum(nil))
+
+	return outer.Sum(nil)
+}
+
+func TestNewHmacWithKeyLengths(t *testing.T) {
+	// Setup:
+	data := []byte("price || iv")
+	var tests = []struct {
+		algorithm HashAlgorithm
+		newHash   func() hash.Hash
+	}{
+		{SHA1, sha1.New},
+		{SHA256, sha256.New},
+	}
+
+	for _, tt := range tests {
+		// Shorter than, as long as and longer than hash sums, the latter longer than the block size
+		for _, keyLength := range []int{20, 32, 100} {
+			key := make([]byte, keyLength)
+			for i := range key {
+				key[i] = byte(i + 1)
+			}
+
+			// Execute:
+			mac := NewHmacWith(key, tt.algorithm)
+			sum := HmacSum(mac, data)
+
+			// Verify:
+			assert.Equal(t, manualHmac(tt.newHash, key, data), sum, "%s HMAC with a %d bytes key", tt.algorithm, keyLength)
+			assert.Equal(t, tt.newHash().Size(), len(sum))
+		}
+	}
+}
+
+func TestNewHmacWithKnownVectors(t *testing.T) {
+	// Setup:
+	// RFC 2202 and RFC 4231 test cases
+	var tests = []struct {
+		algorithm HashAlgorithm
+		key       []byte
+		data      string
+		expected  string
+	}{
+		{SHA1, bytes.Repeat([]byte{0x0b}, 20), "Hi There", "b617318655057264e28bc0b6fb378c8ef146be00"},
+		{SHA1, bytes.Repeat([]byte{0xaa}, 80), "Test Using Larger Than Block-Size Key - Hash Key First", "aa4ae5e15272d00e95705637ce8a3b55ed402112"},
+		{SHA256, bytes.Repeat([]byte{0x0b}, 20), "Hi There", "b0344c61d8db38535ca8afceaf0bf12b881dc200c9833da726e9376c2e32cff7"},
+	}
+
+	for _, tt := range tests {
+		// Execute:
+		sum := HmacSum(NewHmacWith(tt.key, tt.algorithm), []byte(tt.data))
+
+		// Verify:
+		assert.Equal(t, tt.expected, hex.EncodeToString(sum))
+	}
+}
+
+func TestNewHmacDefaultsToSHA1(t *testing.T) {
+	// Setup:
+	key := []byte("key")
+
+	// Execute:
+	sum := HmacSum(NewHmac(key), []byte("data"))
+	unknownSum := HmacSum(NewHmacWith(key, "md4"), []byte("data"))
+
+	// Verify:
+	assert.Equal(t, manualHmac(sha1.New, key, []byte("data")), sum)
+	assert.Equal(t, sum, unknownSum)
+}
+
+func TestParseHashAlgorithm(t *testing.T) {
+	// Execute:
+	sha1Algorithm, sha1Err := ParseHashAlgorithm("sha1")
+	sha256Algorithm, sha256Err := ParseHashAlgorithm("sha256")
+	_, unknownErr := ParseHashAlgorithm("md5")
+
+	// Verify:
+	assert.Nil(t, sha1Err)
+	assert.Equal(t, SHA1, sha1Algorithm)
+	assert.Nil(t, sha256Err)
+	assert.Equal(t, SHA256, sha256Algorithm)
+	assert.NotNil(t, unknownErr)
+}
+
+func TestCentsToMicros(t *testing.T) {
+	// Setup:
+	var tests = []struct {
+		cents       int64
+		scaleFactor uint64
+		micros      uint64
+	}{
+		{0, 1000000, 0},
+		{1, 1000000, 10000},
+		{201, 1000000, 2010000},
+		{135, 1000000, 1350000},
+		{1, 1000, 10},
+		{1, 10, 0},
+		{math.MaxInt64, 100, math.MaxInt64},
+	}
+
+	for _, tt := range tests {
+		// Execute:
+		micros, err := CentsToMicros(tt.cents, tt.scaleFactor)
+
+		// Verify:
+		assert.Nil(t, err, "Unexpected error : %s", err)
+		assert.Equal(t, tt.micros, micros)
+	}
+}
+
+func TestCentsToMicrosErrors(t *testing.T) {
+	// Setup:
+	var tests = []struct {
+		name        string
+		cents       int64
+		scaleFactor uint64
+		isOverflow  bool
+	}{
+		{name: "negative", cents: -1, scaleFactor: 1000000},
+		{name: "zero scale factor", cents: 1, scaleFactor: 0},
+		{name: "overflow", cents: math.MaxInt64, scaleFactor: 1000000, isOverflow: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute:
+			micros, err := CentsToMicros(tt.cents, tt.scaleFactor)
+
+			// Verify:
+			assert.NotNil(t, err)
+			assert.Equal(t, uint64(0), micros)
+			assert.Equal(t, tt.isOverflow, errors.Is(err, ErrPriceOverflow), "Unexpected error : %s", err)
+		})
+	}
+}
+
+func TestMicrosToCents(t *testing.T) {
+	// Setup:
+	var tests = []struct {
+		micros      uint64
+		scaleFactor uint64
+		cents       int64
+	}{
+		{0, 1000000, 0},
+		{2010000, 1000000, 201},
+		{1354000, 1000000, 135},
+		{9999, 1000000, 0},
+		{math.MaxUint64, 1000000, 1844674407370955},
+	}
+
+	for _, tt := range tests {
+		// Execute:
+		cents, err := MicrosToCents(tt.micros, tt.scaleFactor)
+
+		// Verify:
+		assert.Nil(t, err, "Unexpected error : %s", err)
+		assert.Equal(t, tt.cents, cents)
+	}
+
+	// Overflow
+	// Execute:
+	_, err := MicrosToCents(math.MaxUint64, 1)
+
+	// Verify:
+	assert.True(t, errors.Is(err, ErrPriceOverflow), "Unexpected error : %s", err)
+
+	// Zero scale factor
+	// Execute:
+	_, err = MicrosToCents(1, 0)
+
+	// Verify:
+	assert.NotNil(t, err)
+}
+
+func TestHmacSumKey(t *testing.T) {
+	// Setup:
+	key := []byte("key")
+
+	// Execute:
+	sum := HmacSumKey(key, []byte("data"), SHA1)
+	known := HmacSumKey(bytes.Repeat([]byte{0x0b}, 20), []byte("Hi There"), SHA1)
+	knownSHA256 := HmacSumKey(bytes.Repeat([]byte{0x0b}, 20), []byte("Hi There"), SHA256)
+	fallback := HmacSumKey(key, []byte("data"), HashAlgorithm("unknown"))
+
+	// Verify:
+	assert.Equal(t, manualHmac(sha1.New, key, []byte("data")), sum)
+	assert.Equal(t, HmacSum(NewHmac(key), []byte("data")), sum)
+	// RFC 2202 test case
+	assert.Equal(t, "b617318655057264e28bc0b6fb378c8ef146be00", hex.EncodeToString(known))
+	// RFC 4231 test case 1
+	assert.Equal(t, "b0344c61d8db38535ca8afceaf0bf12b881dc200c9833da726e9376c2e32cff7", hex.EncodeToString(knownSHA256))
+	assert.Equal(t, HmacSum(NewHmacWith(key, SHA256), []byte("data")), HmacSumKey(key, []byte("data"), SHA256))
+	assert.Equal(t, sum, fallback)
+}
+
+func TestHmacSumKeyConcurrently(t *testing.T) {
+	// Setup:
+	key := []byte("key")
+	data := [][]byte{[]byte("first"), []byte("second data, longer")}
+	expected := make([][]byte, len(data))
+	for i, d := range data {
+		expected[i] = manualHmac(sha1.New, key, d)
+	}
+
+	// Execute:
+	var wg sync.WaitGroup
+	mismatches := make([]int, len(data))
+	for i := range data {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for n := 0; n < 1000; n++ {
+				if !bytes.Equal(expected[i], HmacSumKey(key, data[i], SHA1)) {
+					mismatches[i]++
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Verify:
+	assert.Equal(t, []int{0, 0}, mismatches)
+}
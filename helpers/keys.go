@@ -0,0 +1,115 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// KeyLength : Expected length of decoded keys, in bytes.
+const KeyLength = 32
+
+// KeyConfig : Keys and settings needed to build a pricer, as loaded from a file or environment.
+type KeyConfig struct {
+	EncryptionKey   string          `json:"encryption_key"`
+	IntegrityKey    string          `json:"integrity_key"`
+	IsBase64        bool            `json:"is_base64"`
+	KeyDecodingMode KeyDecodingMode `json:"key_decoding_mode"`
+	// ScaleFactor : Zero means the pricer default should be used.
+	ScaleFactor float64 `json:"scale_factor"`
+}
+
+// Decode : Returns decoded keys, making sure they are KeyLength bytes long.
+func (kc *KeyConfig) Decode() (encryptionKey []byte, integrityKey []byte, err error) {
+	encryptionKey, err = decodeConfigKey("encryption key", kc.EncryptionKey, kc.IsBase64, kc.KeyDecodingMode)
+	if err != nil {
+		return nil, nil, err
+	}
+	integrityKey, err = decodeConfigKey("integrity key", kc.IntegrityKey, kc.IsBase64, kc.KeyDecodingMode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return encryptionKey, integrityKey, nil
+}
+
+// decodeConfigKey : Decodes a single key, naming it in errors.
+func decodeConfigKey(name string, key string, isBase64 bool, mode KeyDecodingMode) ([]byte, error) {
+	if key == "" {
+		return nil, fmt.Errorf("%s is missing", name)
+	}
+
+	decoded, err := DecodeKey(key, isBase64, mode)
+	if err != nil {
+		return nil, fmt.Errorf("%s can't be decoded as %s: %w", name, mode, err)
+	}
+	if len(decoded) != KeyLength {
+		return nil, fmt.Errorf("%s: expected %d bytes, got %d", name, KeyLength, len(decoded))
+	}
+
+	return decoded, nil
+}
+
+// LoadKeysFromEnv : Returns KeyConfig read from encVar and intVar environment variables.
+// Keys are read as is and decoded according to mode.
+func LoadKeysFromEnv(encVar string, intVar string, mode KeyDecodingMode) (*KeyConfig, error) {
+	config := &KeyConfig{KeyDecodingMode: mode}
+
+	for _, v := range []struct {
+		name string
+		key  *string
+	}{
+		{encVar, &config.EncryptionKey},
+		{intVar, &config.IntegrityKey},
+	} {
+		value, ok := os.LookupEnv(v.name)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("environment variable %s is not set", v.name)
+		}
+		*v.key = value
+	}
+
+	if _, _, err := config.Decode(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// LoadKeysFromFile : Returns KeyConfig read from a JSON file, e.g.
+//
+//	{
+//	  "encryption_key": "652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+//	  "integrity_key": "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+//	  "is_base64": false,
+//	  "key_decoding_mode": "hexa",
+//	  "scale_factor": 1000000
+//	}
+//
+// key_decoding_mode defaults to hexa and scale_factor to the pricer default.
+func LoadKeysFromFile(path string) (*KeyConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't read keys file: %w", err)
+	}
+
+	config := &KeyConfig{}
+	if err := json.Unmarshal(content, config); err != nil {
+		return nil, fmt.Errorf("can't parse keys file %s: %w", path, err)
+	}
+
+	if config.KeyDecodingMode == "" {
+		config.KeyDecodingMode = Hexa
+	} else if _, err := ParseKeyDecodingMode(config.KeyDecodingMode.String()); err != nil {
+		return nil, fmt.Errorf("keys file %s: %w", path, err)
+	}
+	if config.ScaleFactor < 0 {
+		return nil, fmt.Errorf("keys file %s: scale factor should be positive, got %f", path, config.ScaleFactor)
+	}
+
+	if _, _, err := config.Decode(); err != nil {
+		return nil, fmt.Errorf("keys file %s: %w", path, err)
+	}
+
+	return config, nil
+}
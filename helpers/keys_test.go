@@ -0,0 +1,129 @@
+package helpers
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testEncryptionKey = "652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135"
+	testIntegrityKey  = "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5"
+)
+
+func TestLoadKeysFromEnv(t *testing.T) {
+	// Setup:
+	t.Setenv("TEST_ENCRYPTION_KEY", testEncryptionKey)
+	t.Setenv("TEST_INTEGRITY_KEY", testIntegrityKey)
+
+	// Execute:
+	config, err := LoadKeysFromEnv("TEST_ENCRYPTION_KEY", "TEST_INTEGRITY_KEY", Hexa)
+
+	// Verify:
+	assert.Nil(t, err, "Loading keys failed. Error : %s", err)
+	assert.Equal(t, testEncryptionKey, config.EncryptionKey)
+	assert.Equal(t, testIntegrityKey, config.IntegrityKey)
+	assert.Equal(t, Hexa, config.KeyDecodingMode)
+	encryptionKey, integrityKey, err := config.Decode()
+	assert.Nil(t, err)
+	assert.Len(t, encryptionKey, KeyLength)
+	assert.Len(t, integrityKey, KeyLength)
+}
+
+func TestLoadKeysFromEnvErrors(t *testing.T) {
+	// Setup:
+	var envTestCase = []struct {
+		encryptionKey string
+		integrityKey  string
+		expectedError string
+	}{
+		{"", testIntegrityKey, "environment variable TEST_ENCRYPTION_KEY is not set"},
+		{testEncryptionKey, "", "environment variable TEST_INTEGRITY_KEY is not set"},
+		{"not-hexa", testIntegrityKey, "encryption key can't be decoded as hexa"},
+		{testEncryptionKey, "bd0a3dfb", "integrity key: expected 32 bytes, got 4"},
+	}
+
+	for _, e := range envTestCase {
+		t.Setenv("TEST_ENCRYPTION_KEY", e.encryptionKey)
+		t.Setenv("TEST_INTEGRITY_KEY", e.integrityKey)
+
+		// Execute:
+		config, err := LoadKeysFromEnv("TEST_ENCRYPTION_KEY", "TEST_INTEGRITY_KEY", Hexa)
+
+		// Verify:
+		assert.Nil(t, config)
+		if assert.NotNil(t, err) {
+			assert.Contains(t, err.Error(), e.expectedError)
+		}
+	}
+}
+
+func TestLoadKeysFromFile(t *testing.T) {
+	// Setup:
+	var filesTestCase = []struct {
+		content         string
+		keyDecodingMode KeyDecodingMode
+		isBase64        bool
+		scaleFactor     float64
+	}{
+		{`{"encryption_key": "` + testEncryptionKey + `", "integrity_key": "` + testIntegrityKey + `"}`, Hexa, false, 0},
+		{`{"encryption_key": "ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU", "integrity_key": "vQo9-4KtlcXmPhWaYvc8asqYuiSVMiGUdZ1RLXfrK7U", "is_base64": true, "key_decoding_mode": "utf-8", "scale_factor": 1000}`, Utf8, true, 1000},
+	}
+
+	for _, f := range filesTestCase {
+		path := filepath.Join(t.TempDir(), "keys.json")
+		assert.Nil(t, os.WriteFile(path, []byte(f.content), 0600))
+
+		// Execute:
+		config, err := LoadKeysFromFile(path)
+
+		// Verify:
+		assert.Nil(t, err, "Loading keys failed. Error : %s", err)
+		assert.Equal(t, f.keyDecodingMode, config.KeyDecodingMode)
+		assert.Equal(t, f.isBase64, config.IsBase64)
+		assert.Equal(t, f.scaleFactor, config.ScaleFactor)
+		encryptionKey, _, err := config.Decode()
+		assert.Nil(t, err)
+		assert.Equal(t, byte(0x65), encryptionKey[0])
+	}
+}
+
+func TestLoadKeysFromFileErrors(t *testing.T) {
+	// Setup:
+	var filesTestCase = []struct {
+		content       string
+		expectedError string
+	}{
+		{`{"encryption_key": "` + testEncryptionKey + `"`, "can't parse keys file"},
+		{`{"integrity_key": "` + testIntegrityKey + `"}`, "encryption key is missing"},
+		{`{"encryption_key": "` + testEncryptionKey + `", "integrity_key": "` + testIntegrityKey + `", "key_decoding_mode": "base32"}`, "input doesn't match to any key decoding mode"},
+		{`{"encryption_key": "` + testEncryptionKey + `", "integrity_key": "` + testIntegrityKey + `", "scale_factor": -1}`, "scale factor should be positive"},
+		{`{"encryption_key": "` + testEncryptionKey + `", "integrity_key": "` + testIntegrityKey + `", "key_decoding_mode": "utf-8"}`, "encryption key: expected 32 bytes, got 64"},
+	}
+
+	for _, f := range filesTestCase {
+		path := filepath.Join(t.TempDir(), "keys.json")
+		assert.Nil(t, os.WriteFile(path, []byte(f.content), 0600))
+
+		// Execute:
+		config, err := LoadKeysFromFile(path)
+
+		// Verify:
+		assert.Nil(t, config)
+		if assert.NotNil(t, err) {
+			assert.Contains(t, err.Error(), f.expectedError)
+		}
+	}
+}
+
+func TestLoadKeysFromMissingFile(t *testing.T) {
+	// Execute:
+	config, err := LoadKeysFromFile(filepath.Join(t.TempDir(), "missing.json"))
+
+	// Verify:
+	assert.Nil(t, config)
+	assert.True(t, errors.Is(err, os.ErrNotExist), "Unexpected error : %s", err)
+}
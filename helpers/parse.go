@@ -0,0 +1,27 @@
+package helpers
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// ParseEncryptedPrice : Splits a web safe base 64 encrypted price into its
+// Initialization Vector, encrypted price bytes and integrity signature.
+// Only decoding and length are checked, no key is needed and nothing is decrypted.
+func ParseEncryptedPrice(encrypted string) (iv [16]byte, encPrice [8]byte, signature [4]byte, err error) {
+	decoded, err := base64.URLEncoding.DecodeString(AddBase64Padding(encrypted))
+	if err != nil {
+		return iv, encPrice, signature, fmt.Errorf("%w: %w", ErrMalformedBase64, err)
+	}
+
+	// iv (16 bytes) || enc_price (8 bytes) || signature (4 bytes)
+	if len(decoded) != 28 {
+		return iv, encPrice, signature, fmt.Errorf("%w: expected 28 bytes, got %d", ErrInvalidCiphertextLength, len(decoded))
+	}
+
+	copy(iv[:], decoded[0:16])
+	copy(encPrice[:], decoded[16:24])
+	copy(signature[:], decoded[24:28])
+
+	return iv, encPrice, signature, nil
+}
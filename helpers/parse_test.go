@@ -0,0 +1,58 @@
+package helpers
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEncryptedPrice(t *testing.T) {
+	// Setup:
+	var pricesTestCase = []struct {
+		encrypted string
+		iv        string
+		encPrice  string
+		signature string
+	}{
+		{"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", "6a7086185240a5c7c1e9919cea68a776", "1a53ad85c763838d", "a3b957a6"},
+		// Padded
+		{"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg==", "6a7086185240a5c7c1e9919cea68a776", "1a53ad85c763838d", "a3b957a6"},
+		{"1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA", "d41d8cd98f00b204e9800998ecf8427e", "00e8f662466af1ce", "baefb648"},
+	}
+
+	for _, price := range pricesTestCase {
+		// Execute:
+		iv, encPrice, signature, err := ParseEncryptedPrice(price.encrypted)
+
+		// Verify:
+		assert.Nil(t, err, "Parsing failed. Error : %s", err)
+		assert.Equal(t, price.iv, hex.EncodeToString(iv[:]))
+		assert.Equal(t, price.encPrice, hex.EncodeToString(encPrice[:]))
+		assert.Equal(t, price.signature, hex.EncodeToString(signature[:]))
+	}
+}
+
+func TestParseEncryptedPriceMalformed(t *testing.T) {
+	// Setup:
+	var pricesTestCase = []struct {
+		encrypted string
+		err       error
+	}{
+		{"", ErrInvalidCiphertextLength},
+		{"anCGGFJApcfB6ZGc6mindhpTrYXHY4", ErrInvalidCiphertextLength},
+		{"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpgAAAA", ErrInvalidCiphertextLength},
+		{"anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lX!!", ErrMalformedBase64},
+		// Standard alphabet
+		{"1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu+2SA", ErrMalformedBase64},
+	}
+
+	for _, price := range pricesTestCase {
+		// Execute:
+		_, _, _, err := ParseEncryptedPrice(price.encrypted)
+
+		// Verify:
+		assert.True(t, errors.Is(err, price.err), "Parsing %s, unexpected error : %s", price.encrypted, err)
+	}
+}
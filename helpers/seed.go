@@ -0,0 +1,37 @@
+package helpers
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// SeedGenerator : Generates unique seeds to be given to Encrypt.
+// Each seed combines a nanosecond timestamp and a monotonic counter, so that
+// two seeds returned by the same generator never collide, even when generated
+// at the very same nanosecond. Reusing a seed reuses its Initialization Vector,
+// which leaks whether two encrypted prices are equal, so seeds should never
+// be reused.
+// A SeedGenerator is safe for concurrent use by multiple goroutines.
+type SeedGenerator struct {
+	counter uint64
+}
+
+// defaultSeedGenerator : SeedGenerator used by NewSeed.
+var defaultSeedGenerator = NewSeedGenerator()
+
+// NewSeedGenerator : Returns a new SeedGenerator.
+func NewSeedGenerator() *SeedGenerator {
+	return &SeedGenerator{}
+}
+
+// Next : Returns a new unique seed, 32 hexa characters long (16 bytes).
+func (g *SeedGenerator) Next() string {
+	counter := atomic.AddUint64(&g.counter, 1)
+	return fmt.Sprintf("%016x%016x", uint64(time.Now().UnixNano()), counter)
+}
+
+// NewSeed : Returns a new unique seed from the package SeedGenerator.
+func NewSeed() string {
+	return defaultSeedGenerator.Next()
+}
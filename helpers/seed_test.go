@@ -0,0 +1,49 @@
+package helpers
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeedGeneratorConcurrently(t *testing.T) {
+	// Setup:
+	const goroutines = 16
+	const seedsPerGoroutine = 5000
+
+	generator := NewSeedGenerator()
+	seeds := make(chan string, goroutines*seedsPerGoroutine)
+
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+
+	// Execute:
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < seedsPerGoroutine; j++ {
+				seeds <- generator.Next()
+			}
+		}()
+	}
+	wg.Wait()
+	close(seeds)
+
+	// Verify:
+	distinct := make(map[string]struct{})
+	for seed := range seeds {
+		assert.Len(t, seed, 32)
+		distinct[seed] = struct{}{}
+	}
+	assert.Len(t, distinct, goroutines*seedsPerGoroutine, "Every single seed should be distinct")
+}
+
+func TestNewSeed(t *testing.T) {
+	// Execute:
+	first := NewSeed()
+	second := NewSeed()
+
+	// Verify:
+	assert.NotEqual(t, first, second)
+}
@@ -0,0 +1,234 @@
+// Package httpserver exposes a pricer over HTTP with JSON endpoints,
+// so that non Go services can encrypt and decrypt prices, e.g. from a sidecar.
+//
+//	POST /encrypt {"seed": "...", "price": 1.354} -> {"encrypted": "..."}
+//	POST /decrypt {"encrypted": "..."}            -> {"price": 1.354}
+//	GET  /healthz                                 -> 200, or 503 if the pricer self test fails
+//
+// Failures are reported as {"error": "..."}: 400 for malformed requests,
+// including bodies, seeds and encrypted prices longer than the server limits,
+// 422 for prices or seeds the pricer rejects and for encrypted prices which are malformed
+// or fail their integrity checks, 503 once the pricer is closed and 500 otherwise.
+// Keys are never echoed.
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/benjaminch/pricers"
+	"github.com/benjaminch/pricers/helpers"
+	"github.com/benjaminch/pricers/internal/serving"
+)
+
+const (
+	// DefaultMaxBodyBytes is the largest request body accepted by default.
+	DefaultMaxBodyBytes = 64 << 10
+	// DefaultMaxInputLength is the longest seed or encrypted price accepted by default.
+	DefaultMaxInputLength = serving.DefaultMaxInputLength
+)
+
+// Request is the body of both /encrypt and /decrypt requests.
+type Request struct {
+	// Seed is the seed /encrypt derives the IV from, a unique one is used when empty.
+	Seed string `json:"seed,omitempty"`
+	// Price is the clear price /encrypt encrypts.
+	Price *float64 `json:"price,omitempty"`
+	// Encrypted is the encrypted price /decrypt decrypts.
+	Encrypted string `json:"encrypted,omitempty"`
+}
+
+// Response is the body of both /encrypt and /decrypt responses.
+type Response struct {
+	Price     *float64 `json:"price,omitempty"`
+	Encrypted string   `json:"encrypted,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// selfTester is implemented by pricers able to check their configuration.
+type selfTester interface {
+	SelfTest() error
+}
+
+// Server serves pricer operations over HTTP.
+// A Server is safe for concurrent use as long as its pricer is.
+type Server struct {
+	pricer         pricers.Pricer
+	mux            *http.ServeMux
+	maxBodyBytes   int64
+	maxInputLength int
+}
+
+var _ http.Handler = (*Server)(nil)
+
+// Option configures a Server built with New.
+type Option func(*Server)
+
+// WithMaxBodyBytes sets the largest request body accepted, DefaultMaxBodyBytes by default.
+// Requests with longer bodies are rejected with a 400 once the limit is read.
+func WithMaxBodyBytes(maxBodyBytes int64) Option {
+	return func(s *Server) {
+		s.maxBodyBytes = maxBodyBytes
+	}
+}
+
+// WithMaxInputLength sets the longest seed or encrypted price accepted, in bytes,
+// DefaultMaxInputLength by default. Longer ones are rejected with a 400 without
+// reaching the pricer.
+func WithMaxInputLength(maxInputLength int) Option {
+	return func(s *Server) {
+		s.maxInputLength = maxInputLength
+	}
+}
+
+// New returns a Server backed by pricer, with opts applied.
+func New(pricer pricers.Pricer, opts ...Option) *Server {
+	s := &Server{
+		pricer:         pricer,
+		mux:            http.NewServeMux(),
+		maxBodyBytes:   DefaultMaxBodyBytes,
+		maxInputLength: DefaultMaxInputLength,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.mux.HandleFunc("/encrypt", s.handleEncrypt)
+	s.mux.HandleFunc("/decrypt", s.handleDecrypt)
+	s.mux.HandleFunc("/healthz", s.handleHealthz)
+
+	return s
+}
+
+// ServeHTTP dispatches requests to endpoints.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleEncrypt serves POST /encrypt.
+func (s *Server) handleEncrypt(w http.ResponseWriter, r *http.Request) {
+	request, ok := s.readRequest(w, r)
+	if !ok {
+		return
+	}
+	if request.Price == nil {
+		writeResponse(w, http.StatusBadRequest, Response{Error: "price is missing"})
+		return
+	}
+	if !s.checkLength(w, "seed", request.Seed) {
+		return
+	}
+
+	seed := request.Seed
+	if seed == "" {
+		seed = helpers.NewSeed()
+	}
+
+	encrypted, err := s.pricer.Encrypt(seed, *request.Price)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case serving.IsRejectedPrice(err):
+			status = http.StatusUnprocessableEntity
+		case serving.IsUnavailable(err):
+			status = http.StatusServiceUnavailable
+		}
+		writeResponse(w, status, Response{Error: err.Error()})
+		return
+	}
+
+	writeResponse(w, http.StatusOK, Response{Encrypted: encrypted})
+}
+
+// handleDecrypt serves POST /decrypt.
+func (s *Server) handleDecrypt(w http.ResponseWriter, r *http.Request) {
+	request, ok := s.readRequest(w, r)
+	if !ok {
+		return
+	}
+	if request.Encrypted == "" {
+		writeResponse(w, http.StatusBadRequest, Response{Error: "encrypted is missing"})
+		return
+	}
+	if !s.checkLength(w, "encrypted", request.Encrypted) {
+		return
+	}
+
+	price, err := s.pricer.Decrypt(request.Encrypted)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case serving.IsRejectedEncrypted(err):
+			status = http.StatusUnprocessableEntity
+		case serving.IsUnavailable(err):
+			status = http.StatusServiceUnavailable
+		}
+		writeResponse(w, status, Response{Error: err.Error()})
+		return
+	}
+
+	writeResponse(w, http.StatusOK, Response{Price: &price})
+}
+
+// handleHealthz serves GET /healthz.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeResponse(w, http.StatusMethodNotAllowed, Response{Error: "method not allowed"})
+		return
+	}
+
+	if tester, ok := s.pricer.(selfTester); ok {
+		if err := tester.SelfTest(); err != nil {
+			writeResponse(w, http.StatusServiceUnavailable, Response{Error: err.Error()})
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// readRequest decodes a POST request body, writing an error response
+// and returning false if it can't.
+func (s *Server) readRequest(w http.ResponseWriter, r *http.Request) (Request, bool) {
+	var request Request
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeResponse(w, http.StatusMethodNotAllowed, Response{Error: "method not allowed"})
+		return request, false
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeResponse(w, http.StatusBadRequest, Response{Error: fmt.Sprintf("request body too large, limit is %d bytes", maxBytesErr.Limit)})
+			return request, false
+		}
+		writeResponse(w, http.StatusBadRequest, Response{Error: "malformed request body: " + err.Error()})
+		return request, false
+	}
+
+	return request, true
+}
+
+// checkLength writes an error response and returns false
+// if value of field is longer than the server accepts.
+func (s *Server) checkLength(w http.ResponseWriter, field string, value string) bool {
+	if err := serving.CheckLength(field, value, s.maxInputLength); err != nil {
+		writeResponse(w, http.StatusBadRequest, Response{Error: err.Error()})
+		return false
+	}
+
+	return true
+}
+
+// writeResponse writes response as JSON with status.
+func writeResponse(w http.ResponseWriter, status int, response Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,322 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/doubleclick"
+)
+
+const (
+	testEncryptionKey = "652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135"
+	testIntegrityKey  = "bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5"
+)
+
+func buildServer(t *testing.T, opts ...doubleclick.Option) *Server {
+	return buildServerWith(t, nil, opts...)
+}
+
+func buildServerWith(t *testing.T, serverOpts []Option, opts ...doubleclick.Option) *Server {
+	pricer, err := doubleclick.NewPricer(append([]doubleclick.Option{doubleclick.WithKeys(testEncryptionKey, testIntegrityKey)}, opts...)...)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	return New(pricer, serverOpts...)
+}
+
+// serve sends a request to server and returns the response status and body.
+func serve(server *Server, method string, path string, body string) (int, Response, string) {
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+
+	var response Response
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+
+	return recorder.Code, response, recorder.Body.String()
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	// Setup:
+	server := buildServer(t)
+
+	// Execute:
+	encryptStatus, encrypted, _ := serve(server, http.MethodPost, "/encrypt", `{"seed": "", "price": 1.354}`)
+	decryptStatus, decrypted, _ := serve(server, http.MethodPost, "/decrypt", `{"encrypted": "`+encrypted.Encrypted+`"}`)
+
+	// Verify:
+	assert.Equal(t, http.StatusOK, encryptStatus)
+	assert.Empty(t, encrypted.Error)
+	assert.NotEmpty(t, encrypted.Encrypted)
+	assert.Equal(t, http.StatusOK, decryptStatus)
+	assert.Empty(t, decrypted.Error)
+	if assert.NotNil(t, decrypted.Price) {
+		assert.InDelta(t, 1.354, *decrypted.Price, 0.000001)
+	}
+}
+
+func TestEncryptWithSeed(t *testing.T) {
+	// Setup:
+	server := buildServer(t)
+
+	// Execute:
+	status, response, _ := serve(server, http.MethodPost, "/encrypt", `{"seed": "test", "price": 1.354}`)
+
+	// Verify:
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "CY9rzUYh03PK3k6DJie09sczu2K8g808L63rHg", response.Encrypted)
+}
+
+func TestDecryptKnownPrice(t *testing.T) {
+	// Setup:
+	server := buildServer(t)
+
+	// Execute:
+	status, response, _ := serve(server, http.MethodPost, "/decrypt", `{"encrypted": "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg"}`)
+
+	// Verify:
+	assert.Equal(t, http.StatusOK, status)
+	if assert.NotNil(t, response.Price) {
+		assert.InDelta(t, 1.354, *response.Price, 0.000001)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	// Setup:
+	server := buildServer(t)
+
+	var requestsTestCase = []struct {
+		method string
+		path   string
+		body   string
+		status int
+		error  string
+	}{
+		// Malformed bodies
+		{http.MethodPost, "/encrypt", `{"price": `, http.StatusBadRequest, "malformed request body"},
+		{http.MethodPost, "/decrypt", `not json`, http.StatusBadRequest, "malformed request body"},
+		{http.MethodPost, "/decrypt", `{"encrypted": 1}`, http.StatusBadRequest, "malformed request body"},
+		{http.MethodPost, "/decrypt", `{"encrypted": "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", "key": ""}`, http.StatusBadRequest, "malformed request body"},
+		// Oversized inputs
+		{http.MethodPost, "/decrypt", strings.Repeat(" ", DefaultMaxBodyBytes+1) + `{}`, http.StatusBadRequest, "request body too large, limit is 65536 bytes"},
+		{http.MethodPost, "/decrypt", `{"encrypted": "` + strings.Repeat("a", DefaultMaxInputLength+1) + `"}`, http.StatusBadRequest, "encrypted too long: 1025 bytes, limit is 1024"},
+		{http.MethodPost, "/encrypt", `{"seed": "` + strings.Repeat("a", DefaultMaxInputLength+1) + `", "price": 1.354}`, http.StatusBadRequest, "seed too long: 1025 bytes, limit is 1024"},
+		// Missing fields
+		{http.MethodPost, "/encrypt", `{"seed": "test"}`, http.StatusBadRequest, "price is missing"},
+		{http.MethodPost, "/decrypt", `{}`, http.StatusBadRequest, "encrypted is missing"},
+		// Rejected prices
+		{http.MethodPost, "/encrypt", `{"price": 1e300}`, http.StatusUnprocessableEntity, "price overflow"},
+		{http.MethodPost, "/decrypt", `{"encrypted": "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lX!!"}`, http.StatusUnprocessableEntity, "malformed base64 encrypted price"},
+		{http.MethodPost, "/decrypt", `{"encrypted": "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpA"}`, http.StatusUnprocessableEntity, "Failed to decrypt"},
+		// Wrong methods
+		{http.MethodGet, "/encrypt", ``, http.StatusMethodNotAllowed, "method not allowed"},
+		{http.MethodPut, "/decrypt", `{}`, http.StatusMethodNotAllowed, "method not allowed"},
+		{http.MethodPost, "/healthz", ``, http.StatusMethodNotAllowed, "method not allowed"},
+	}
+
+	for _, request := range requestsTestCase {
+		// Execute:
+		status, response, body := serve(server, request.method, request.path, request.body)
+
+		// Verify:
+		assert.Equal(t, request.status, status, "%s %s %s", request.method, request.path, request.body)
+		assert.Contains(t, response.Error, request.error)
+		assert.NotContains(t, body, testEncryptionKey)
+		assert.NotContains(t, body, testIntegrityKey)
+	}
+}
+
+func TestRejectedPrices(t *testing.T) {
+	var requestsTestCase = []struct {
+		name   string
+		server *Server
+		body   string
+		error  string
+	}{
+		{"overflow", buildServer(t), `{"price": 1e300}`, "price overflow"},
+		{"negative", buildServer(t), `{"price": -1}`, "invalid price"},
+		{"above max", buildServer(t, doubleclick.WithMaxPrice(10)), `{"price": 10.5}`, "price above max price"},
+		{"short seed", buildServer(t, doubleclick.WithMinSeedLength(5)), `{"seed": "seed", "price": 1.354}`, "invalid seed"},
+	}
+
+	for _, request := range requestsTestCase {
+		t.Run(request.name, func(t *testing.T) {
+			// Execute:
+			status, response, _ := serve(request.server, http.MethodPost, "/encrypt", request.body)
+
+			// Verify:
+			assert.Equal(t, http.StatusUnprocessableEntity, status)
+			assert.Contains(t, response.Error, request.error)
+		})
+	}
+}
+
+// failingPricer fails every encryption and decryption with err.
+type failingPricer struct {
+	err error
+}
+
+func (p failingPricer) Encrypt(string, float64) (string, error) { return "", p.err }
+
+func (p failingPricer) Decrypt(string) (float64, error) { return 0, p.err }
+
+func TestDecryptStatuses(t *testing.T) {
+	// Setup:
+	closed, err := doubleclick.NewPricer(doubleclick.WithKeys(testEncryptionKey, testIntegrityKey))
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	assert.Nil(t, closed.Close())
+
+	var requestsTestCase = []struct {
+		name      string
+		server    *Server
+		encrypted string
+		status    int
+		error     string
+	}{
+		{"short", buildServer(t), "anCGGFJApcfB6ZGc", http.StatusUnprocessableEntity, "invalid encrypted price"},
+		{"out of range", buildServer(t, doubleclick.WithMaxMicros(1000)), "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", http.StatusUnprocessableEntity, "decrypted price out of range"},
+		{"closed", New(closed), "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", http.StatusServiceUnavailable, "pricer closed"},
+		{"internal", New(failingPricer{err: errors.New("internal failure")}), "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg", http.StatusInternalServerError, "internal failure"},
+	}
+
+	for _, request := range requestsTestCase {
+		t.Run(request.name, func(t *testing.T) {
+			// Execute:
+			status, response, _ := serve(request.server, http.MethodPost, "/decrypt", `{"encrypted": "`+request.encrypted+`"}`)
+
+			// Verify:
+			assert.Equal(t, request.status, status)
+			assert.Contains(t, response.Error, request.error)
+		})
+	}
+}
+
+func TestEncryptClosedPricer(t *testing.T) {
+	// Setup:
+	pricer, err := doubleclick.NewPricer(doubleclick.WithKeys(testEncryptionKey, testIntegrityKey))
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	assert.Nil(t, pricer.Close())
+
+	// Execute:
+	status, response, _ := serve(New(pricer), http.MethodPost, "/encrypt", `{"price": 1.354}`)
+	internalStatus, _, _ := serve(New(failingPricer{err: errors.New("internal failure")}), http.MethodPost, "/encrypt", `{"price": 1.354}`)
+
+	// Verify:
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Contains(t, response.Error, "pricer closed")
+	assert.Equal(t, http.StatusInternalServerError, internalStatus)
+}
+
+func TestLimits(t *testing.T) {
+	// Setup:
+	server := buildServerWith(t, []Option{WithMaxBodyBytes(128), WithMaxInputLength(38)})
+	encrypted := "anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg"
+
+	var requestsTestCase = []struct {
+		path   string
+		body   string
+		status int
+		error  string
+	}{
+		{"/decrypt", `{"encrypted": "` + encrypted + `"}`, http.StatusOK, ""},
+		{"/decrypt", `{"encrypted": "` + encrypted + `="}`, http.StatusBadRequest, "encrypted too long: 39 bytes, limit is 38"},
+		{"/encrypt", `{"seed": "` + strings.Repeat("a", 38) + `", "price": 1.354}`, http.StatusOK, ""},
+		{"/encrypt", `{"seed": "` + strings.Repeat("a", 39) + `", "price": 1.354}`, http.StatusBadRequest, "seed too long: 39 bytes, limit is 38"},
+		{"/decrypt", `{"encrypted": "` + encrypted + `"}` + strings.Repeat(" ", 128), http.StatusOK, ""},
+		{"/decrypt", strings.Repeat(" ", 129) + `{}`, http.StatusBadRequest, "request body too large, limit is 128 bytes"},
+	}
+
+	for _, request := range requestsTestCase {
+		// Execute:
+		status, response, _ := serve(server, http.MethodPost, request.path, request.body)
+
+		// Verify:
+		assert.Equal(t, request.status, status, "%s %s", request.path, request.body)
+		assert.Equal(t, request.error, response.Error)
+	}
+}
+
+func TestOversizedBodyAllocations(t *testing.T) {
+	// Setup:
+	server := buildServer(t)
+	bodies := []string{
+		`{"encrypted": "` + strings.Repeat("a", 8<<20) + `"}`,
+		`{"seed": "` + strings.Repeat("a", 8<<20) + `", "price": 1.354}`,
+	}
+
+	for i, path := range []string{"/decrypt", "/encrypt"} {
+		request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(bodies[i]))
+		recorder := httptest.NewRecorder()
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		// Execute:
+		server.ServeHTTP(recorder, request)
+		runtime.ReadMemStats(&after)
+
+		// Verify:
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "request body too large")
+		// Only the body read up to the limit is buffered, never the 8 MiB input.
+		assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1<<20))
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	// Setup:
+	var serversTestCase = []struct {
+		server *Server
+		status int
+	}{
+		{buildServer(t), http.StatusOK},
+		// Scale factor losing the price precision
+		{buildServer(t, doubleclick.WithScaleFactor(1)), http.StatusServiceUnavailable},
+	}
+
+	for _, s := range serversTestCase {
+		// Execute:
+		status, _, body := serve(s.server, http.MethodGet, "/healthz", ``)
+
+		// Verify:
+		assert.Equal(t, s.status, status)
+		assert.NotContains(t, body, testEncryptionKey)
+		assert.NotContains(t, body, testIntegrityKey)
+	}
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	// Should be run with -race.
+
+	// Setup:
+	server := buildServer(t)
+	const requests = 200
+	var wg sync.WaitGroup
+	wg.Add(requests)
+
+	for i := 0; i < requests; i++ {
+		go func(i int) {
+			defer wg.Done()
+			price := float64(i) / 100
+
+			// Execute:
+			_, encrypted, _ := serve(server, http.MethodPost, "/encrypt", fmt.Sprintf(`{"price": %g}`, price))
+			status, decrypted, _ := serve(server, http.MethodPost, "/decrypt", `{"encrypted": "`+encrypted.Encrypted+`"}`)
+
+			// Verify:
+			assert.Equal(t, http.StatusOK, status)
+			if assert.NotNil(t, decrypted.Price) {
+				assert.InDelta(t, price, *decrypted.Price, 0.000001)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,252 @@
+// Package core implements the price encryption math shared by exchanges
+// following Google's layout: iv || price <xor> pad || signature, where
+// pad = hmac(e_key, iv) and signature = hmac(i_key, price || iv), or
+// hmac(i_key, iv || price) for exchanges signing the other way around.
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+// Message elements lengths, in bytes.
+const (
+	IVLength    = 16
+	PriceLength = 8
+	// SignatureLength is the signature length from specs, some exchanges
+	// truncating signatures to another length, up to MaxSignatureLength.
+	SignatureLength    = 4
+	MaxSignatureLength = 32
+	MessageLength      = IVLength + PriceLength + SignatureLength
+	MaxMessageLength   = IVLength + PriceLength + MaxSignatureLength
+	// UnsignedMessageLength is the length of messages without signature,
+	// for exchanges skipping it.
+	UnsignedMessageLength = IVLength + PriceLength
+)
+
+// ErrShortSum is returned when an HMAC sum is too short to hold
+// a pad or a signature, e.g. for a misconfigured hash function.
+var ErrShortSum = errors.New("HMAC sum too short")
+
+// State holds HMACs and buffers which can be reused across several
+// seals / opens by a single goroutine. A State must not be copied.
+type State struct {
+	encryptionHmac hash.Hash
+	integrityHmac  hash.Hash
+	// padSum and signatureSum are large enough for any supported HMAC sum,
+	// so that sums are computed without allocating.
+	padSum       [64]byte
+	signatureSum [64]byte
+	// signedData holds price || iv, or iv || price, the signed part of a message.
+	signedData [PriceLength + IVLength]byte
+	// ivSlot and priceSlot are where iv and price are in signedData.
+	ivSlot    []byte
+	priceSlot []byte
+	// signatureLength is the length signatures are truncated to.
+	signatureLength int
+	// padOffset is where pads start in hmac(e_key, iv).
+	padOffset int
+}
+
+// NewState returns a new State keyed with decoded keys, computing HMACs
+// with algorithm and signing price and iv concatenated according to layout.
+// Any layout other than helpers.IVPrice signs price || iv. Signatures are
+// truncated to signatureLength bytes, which callers check to be between
+// 1 and MaxSignatureLength. Pads are the 8 bytes of hmac(e_key, iv) from
+// padOffset, 0 as described by specs, which callers check to be positive.
+func NewState(encryptionKey []byte, integrityKey []byte, layout helpers.SignatureLayout, algorithm helpers.HashAlgorithm, signatureLength int, padOffset int) *State {
+	return newState(helpers.NewHmacWith(encryptionKey, algorithm), helpers.NewHmacWith(integrityKey, algorithm), layout, signatureLength, padOffset)
+}
+
+// NewStateWithHash returns a new State as NewState does, computing HMACs
+// with newHash instead of a supported algorithm.
+func NewStateWithHash(encryptionKey []byte, integrityKey []byte, layout helpers.SignatureLayout, newHash func() hash.Hash, signatureLength int, padOffset int) *State {
+	return newState(hmac.New(newHash, encryptionKey), hmac.New(newHash, integrityKey), layout, signatureLength, padOffset)
+}
+
+// newState returns a new State computing pads with encryptionHmac and signatures with integrityHmac.
+func newState(encryptionHmac hash.Hash, integrityHmac hash.Hash, layout helpers.SignatureLayout, signatureLength int, padOffset int) *State {
+	s := &State{
+		encryptionHmac:  encryptionHmac,
+		integrityHmac:   integrityHmac,
+		signatureLength: signatureLength,
+		padOffset:       padOffset,
+	}
+	if layout == helpers.IVPrice {
+		s.ivSlot, s.priceSlot = s.signedData[:IVLength], s.signedData[IVLength:]
+	} else {
+		s.priceSlot, s.ivSlot = s.signedData[:PriceLength], s.signedData[PriceLength:]
+	}
+
+	return s
+}
+
+// MessageLength returns the length of signed messages, iv || enc_price || signature.
+func (s *State) MessageLength() int {
+	return UnsignedMessageLength + s.signatureLength
+}
+
+// IV returns the Initialization Vector derived from seed, md5(seed).
+func IV(seed string) [IVLength]byte {
+	return md5.Sum([]byte(seed))
+}
+
+// Sealed holds an encrypted price message along with the elements it is made of.
+// Only the first signature length bytes of Signature, and the first
+// State.MessageLength bytes of Message, are set.
+type Sealed struct {
+	Pad       [PriceLength]byte
+	Encoded   [PriceLength]byte
+	Signature [MaxSignatureLength]byte
+	Message   [MaxMessageLength]byte
+}
+
+// Seal encrypts price bytes with iv.
+// ErrShortSum is returned if HMAC sums can't hold a pad or a signature.
+func (s *State) Seal(iv [IVLength]byte, price [PriceLength]byte) (Sealed, error) {
+	pad, err := s.Pad(iv)
+	if err != nil {
+		return Sealed{}, err
+	}
+
+	return s.SealWithPad(iv, pad, price)
+}
+
+// Pad returns the pad derived from iv, hmac(e_key, iv), 8 bytes from the pad offset.
+// ErrShortSum is returned if HMAC sums can't hold a pad.
+func (s *State) Pad(iv [IVLength]byte) ([PriceLength]byte, error) {
+	var pad [PriceLength]byte
+
+	// iv is hashed from state buffer so that nothing escapes to the heap.
+	copy(s.ivSlot, iv[:])
+	sum, err := sumPrefix(s.encryptionHmac, s.ivSlot, s.padSum[:0], s.padOffset+PriceLength)
+	if err != nil {
+		return pad, err
+	}
+	copy(pad[:], sum[s.padOffset:])
+
+	return pad, nil
+}
+
+// SealWithPad encrypts price bytes with iv as Seal does, pad being the one
+// returned by Pad for iv with the same encryption key, so that it isn't recomputed.
+// ErrShortSum is returned if HMAC sums can't hold a signature.
+func (s *State) SealWithPad(iv [IVLength]byte, pad [PriceLength]byte, price [PriceLength]byte) (Sealed, error) {
+	var sealed Sealed
+	sealed.Pad = pad
+
+	// Signed data is assembled in state buffer, so that nothing escapes to the heap.
+	copy(s.ivSlot, iv[:])
+
+	// enc_price = pad <xor> price
+	for i := range price {
+		sealed.Encoded[i] = pad[i] ^ price[i]
+	}
+
+	// signature = hmac(i_key, price || iv), or iv || price, first 4 bytes by default
+	copy(s.priceSlot, price[:])
+	sig, err := sumPrefix(s.integrityHmac, s.signedData[:], s.signatureSum[:0], s.signatureLength)
+	if err != nil {
+		return Sealed{}, err
+	}
+	copy(sealed.Signature[:], sig)
+
+	// message = iv || enc_price || signature
+	copy(sealed.Message[:IVLength], iv[:])
+	copy(sealed.Message[IVLength:IVLength+PriceLength], sealed.Encoded[:])
+	copy(sealed.Message[IVLength+PriceLength:], sig)
+
+	return sealed, nil
+}
+
+// Opened holds the elements of an encrypted price message once opened,
+// whether or not its integrity signature is valid. Only the first signature
+// length bytes of Signature and ComputedSignature are set.
+type Opened struct {
+	IV        [IVLength]byte
+	Encoded   [PriceLength]byte
+	Signature [MaxSignatureLength]byte
+	// ComputedSignature is the signature recomputed from price bytes,
+	// left zero for messages without signature.
+	ComputedSignature [MaxSignatureLength]byte
+	Pad               [PriceLength]byte
+	Price             [PriceLength]byte
+	IsIntegrityValid  bool
+}
+
+// Open recomputes price bytes of an encrypted price message, at least
+// MessageLength bytes long, and checks its integrity signature.
+// ErrShortSum is returned if HMAC sums can't hold a pad or a signature.
+func (s *State) Open(message []byte) (Opened, error) {
+	if len(message) < s.MessageLength() {
+		return Opened{}, fmt.Errorf("message too short: expected %d bytes, got %d", s.MessageLength(), len(message))
+	}
+
+	// iv (16 bytes) || enc_price (8 bytes) || signature (4 bytes by default)
+	opened, err := s.open((*[UnsignedMessageLength]byte)(message[:UnsignedMessageLength]))
+	if err != nil {
+		return Opened{}, err
+	}
+	received := message[UnsignedMessageLength:s.MessageLength()]
+	copy(opened.Signature[:], received)
+
+	// conf_sig = hmac(i_key, price || iv), or iv || price
+	copy(s.priceSlot, opened.Price[:])
+	sig, err := sumPrefix(s.integrityHmac, s.signedData[:], s.signatureSum[:0], s.signatureLength)
+	if err != nil {
+		return Opened{}, err
+	}
+	copy(opened.ComputedSignature[:], sig)
+
+	// success = (conf_sig == sig)
+	// Compared in constant time so the integrity key can't be leaked
+	// through a timing side-channel.
+	opened.IsIntegrityValid = hmac.Equal(sig, received)
+
+	return opened, nil
+}
+
+// OpenUnsigned recomputes price bytes of an encrypted price message without
+// signature. Its integrity can't be checked, so IsIntegrityValid is false.
+func (s *State) OpenUnsigned(message *[UnsignedMessageLength]byte) (Opened, error) {
+	return s.open(message)
+}
+
+// open recomputes price bytes of iv || enc_price, leaving iv in state signed data.
+func (s *State) open(message *[UnsignedMessageLength]byte) (Opened, error) {
+	var opened Opened
+
+	copy(opened.IV[:], message[:IVLength])
+	copy(opened.Encoded[:], message[IVLength:])
+
+	// pad = hmac(e_key, iv), 8 bytes from pad offset
+	copy(s.ivSlot, opened.IV[:])
+	sum, err := sumPrefix(s.encryptionHmac, s.ivSlot, s.padSum[:0], s.padOffset+PriceLength)
+	if err != nil {
+		return Opened{}, err
+	}
+	copy(opened.Pad[:], sum[s.padOffset:])
+
+	// price = enc_price <xor> pad
+	for i := range opened.Encoded {
+		opened.Price[i] = opened.Pad[i] ^ opened.Encoded[i]
+	}
+
+	return opened, nil
+}
+
+// sumPrefix returns the first n bytes of the HMAC sum of buf, appended to dst.
+// Sums shorter than n bytes return ErrShortSum, rather than reading past them.
+func sumPrefix(mac hash.Hash, buf []byte, dst []byte, n int) ([]byte, error) {
+	sum := helpers.HmacSumTo(mac, buf, dst)
+	if len(sum) < n {
+		return nil, fmt.Errorf("%w: expected at least %d bytes, got %d", ErrShortSum, n, len(sum))
+	}
+
+	return sum[:n], nil
+}
@@ -0,0 +1,286 @@
+package core
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func buildState(t *testing.T) *State {
+	encryptionKey, err := hex.DecodeString("652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135")
+	assert.Nil(t, err)
+	integrityKey, err := hex.DecodeString("bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5")
+	assert.Nil(t, err)
+
+	return NewState(encryptionKey, integrityKey, helpers.PriceIV, helpers.SHA1, SignatureLength, 0)
+}
+
+func TestSealKnownVector(t *testing.T) {
+	// Setup:
+	state := buildState(t)
+	var price [PriceLength]byte
+	binary.BigEndian.PutUint64(price[:], 1354000)
+
+	// Execute:
+	sealed, err := state.Seal(IV(""), price)
+
+	// Verify:
+	assert.Nil(t, err)
+	assert.Equal(t, "1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA", base64.RawURLEncoding.EncodeToString(sealed.Message[:MessageLength]))
+}
+
+func TestOpenKnownVector(t *testing.T) {
+	// Setup:
+	state := buildState(t)
+	message, err := base64.RawURLEncoding.DecodeString("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	assert.Nil(t, err)
+
+	// Execute:
+	opened, err := state.Open(message)
+
+	// Verify:
+	assert.Nil(t, err)
+	assert.True(t, opened.IsIntegrityValid)
+	assert.Equal(t, uint64(1354000), binary.BigEndian.Uint64(opened.Price[:]))
+	assert.Equal(t, "6a7086185240a5c7c1e9919cea68a776", hex.EncodeToString(opened.IV[:]))
+}
+
+func TestSealOpen(t *testing.T) {
+	// Setup:
+	state := buildState(t)
+
+	for _, micros := range []uint64{0, 1, 1354000, 1<<64 - 1} {
+		var price [PriceLength]byte
+		binary.BigEndian.PutUint64(price[:], micros)
+
+		// Execute:
+		sealed, err := state.Seal(IV("seed"), price)
+		assert.Nil(t, err)
+		opened, err := state.Open(sealed.Message[:])
+
+		// Verify:
+		assert.Nil(t, err)
+		assert.True(t, opened.IsIntegrityValid)
+		assert.Equal(t, price, opened.Price)
+		assert.Equal(t, sealed.Pad, opened.Pad)
+		assert.Equal(t, sealed.Signature, opened.Signature)
+	}
+}
+
+func TestSealWithPad(t *testing.T) {
+	// Setup:
+	state := buildState(t)
+	pad, err := state.Pad(IV("seed"))
+	assert.Nil(t, err)
+
+	for _, micros := range []uint64{0, 1, 1354000, 1<<64 - 1} {
+		var price [PriceLength]byte
+		binary.BigEndian.PutUint64(price[:], micros)
+
+		// Execute:
+		sealed, err := state.Seal(IV("seed"), price)
+		assert.Nil(t, err)
+		sealedWithPad, err := state.SealWithPad(IV("seed"), pad, price)
+
+		// Verify:
+		assert.Nil(t, err)
+		assert.Equal(t, pad, sealed.Pad)
+		assert.Equal(t, sealed, sealedWithPad)
+	}
+}
+
+func TestOpenTamperedMessage(t *testing.T) {
+	// Setup:
+	state := buildState(t)
+	var price [PriceLength]byte
+	binary.BigEndian.PutUint64(price[:], 1354000)
+	sealed, err := state.Seal(IV("seed"), price)
+	assert.Nil(t, err)
+
+	for i := 0; i < state.MessageLength(); i++ {
+		tampered := sealed.Message
+		tampered[i] ^= 0x01
+
+		// Execute:
+		opened, err := state.Open(tampered[:])
+
+		// Verify:
+		assert.Nil(t, err)
+		assert.False(t, opened.IsIntegrityValid, "Tampering byte %d should invalidate signature", i)
+	}
+}
+
+func TestSealOpenIVPriceLayout(t *testing.T) {
+	// Setup:
+	encryptionKey, _ := hex.DecodeString("652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135")
+	integrityKey, _ := hex.DecodeString("bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5")
+	priceIV := NewState(encryptionKey, integrityKey, helpers.PriceIV, helpers.SHA1, SignatureLength, 0)
+	ivPrice := NewState(encryptionKey, integrityKey, helpers.IVPrice, helpers.SHA1, SignatureLength, 0)
+	var price [PriceLength]byte
+	binary.BigEndian.PutUint64(price[:], 1354000)
+	iv := IV("seed")
+
+	// Execute:
+	sealed, err := ivPrice.Seal(iv, price)
+	assert.Nil(t, err)
+	opened, err := ivPrice.Open(sealed.Message[:])
+	assert.Nil(t, err)
+	crossOpened, err := priceIV.Open(sealed.Message[:])
+	assert.Nil(t, err)
+	integrityHmac := helpers.NewHmac(integrityKey)
+	integrityHmac.Write(iv[:])
+	integrityHmac.Write(price[:])
+
+	// Verify:
+	assert.Equal(t, integrityHmac.Sum(nil)[:SignatureLength], sealed.Signature[:SignatureLength])
+	assert.True(t, opened.IsIntegrityValid)
+	assert.Equal(t, price, opened.Price)
+	assert.False(t, crossOpened.IsIntegrityValid)
+	assert.Equal(t, price, crossOpened.Price)
+}
+
+// truncatedHash is a hash whose sums are cut to size bytes, as a misconfigured hash would be.
+type truncatedHash struct {
+	hash.Hash
+	size int
+}
+
+func (h truncatedHash) Size() int {
+	return h.size
+}
+
+func (h truncatedHash) Sum(b []byte) []byte {
+	return append(b, h.Hash.Sum(nil)[:h.size]...)
+}
+
+func TestNewStateWithHash(t *testing.T) {
+	// Setup:
+	encryptionKey, _ := hex.DecodeString("652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135")
+	integrityKey, _ := hex.DecodeString("bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5")
+	state := NewStateWithHash(encryptionKey, integrityKey, helpers.PriceIV, sha1.New, SignatureLength, 0)
+	var price [PriceLength]byte
+	binary.BigEndian.PutUint64(price[:], 1354000)
+
+	// Execute:
+	sealed, err := state.Seal(IV(""), price)
+
+	// Verify:
+	assert.Nil(t, err)
+	assert.Equal(t, "1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA", base64.RawURLEncoding.EncodeToString(sealed.Message[:MessageLength]))
+}
+
+func TestShortSums(t *testing.T) {
+	// Setup:
+	encryptionKey, _ := hex.DecodeString("652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135")
+	integrityKey, _ := hex.DecodeString("bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5")
+	message, _ := base64.RawURLEncoding.DecodeString("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	var price [PriceLength]byte
+
+	for _, size := range []int{0, SignatureLength - 1, PriceLength - 1} {
+		newHash := func() hash.Hash { return truncatedHash{Hash: sha1.New(), size: size} }
+		state := NewStateWithHash(encryptionKey, integrityKey, helpers.PriceIV, newHash, SignatureLength, 0)
+
+		// Execute:
+		sealed, errSeal := state.Seal(IV(""), price)
+		_, errPad := state.Pad(IV(""))
+		opened, errOpen := state.Open(message)
+		unsigned, errOpenUnsigned := state.OpenUnsigned((*[UnsignedMessageLength]byte)(message[:UnsignedMessageLength]))
+
+		// Verify:
+		assert.True(t, errors.Is(errSeal, ErrShortSum), "Unexpected error : %s", errSeal)
+		assert.True(t, errors.Is(errPad, ErrShortSum), "Unexpected error : %s", errPad)
+		assert.True(t, errors.Is(errOpen, ErrShortSum), "Unexpected error : %s", errOpen)
+		assert.True(t, errors.Is(errOpenUnsigned, ErrShortSum), "Unexpected error : %s", errOpenUnsigned)
+		assert.Equal(t, Sealed{}, sealed)
+		assert.Equal(t, Opened{}, opened)
+		assert.Equal(t, Opened{}, unsigned)
+	}
+}
+
+func TestSealOpenSignatureLength(t *testing.T) {
+	// Setup:
+	encryptionKey, _ := hex.DecodeString("652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135")
+	integrityKey, _ := hex.DecodeString("bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5")
+	var price [PriceLength]byte
+	binary.BigEndian.PutUint64(price[:], 1354000)
+	iv := IV("seed")
+
+	for _, signatureLength := range []int{1, SignatureLength, 8, 20} {
+		state := NewState(encryptionKey, integrityKey, helpers.PriceIV, helpers.SHA1, signatureLength, 0)
+
+		// Execute:
+		sealed, err := state.Seal(iv, price)
+		assert.Nil(t, err)
+		opened, err := state.Open(sealed.Message[:state.MessageLength()])
+		assert.Nil(t, err)
+		_, errShort := state.Open(sealed.Message[:state.MessageLength()-1])
+		integrityHmac := helpers.NewHmac(integrityKey)
+		integrityHmac.Write(price[:])
+		integrityHmac.Write(iv[:])
+
+		// Verify:
+		assert.Equal(t, UnsignedMessageLength+signatureLength, state.MessageLength())
+		assert.Equal(t, integrityHmac.Sum(nil)[:signatureLength], sealed.Message[UnsignedMessageLength:state.MessageLength()])
+		assert.Equal(t, integrityHmac.Sum(nil)[:signatureLength], sealed.Signature[:signatureLength])
+		assert.True(t, opened.IsIntegrityValid)
+		assert.Equal(t, price, opened.Price)
+		assert.NotNil(t, errShort)
+	}
+
+	// Signatures longer than sums
+	// Setup:
+	state := NewState(encryptionKey, integrityKey, helpers.PriceIV, helpers.SHA1, 21, 0)
+
+	// Execute:
+	_, err := state.Seal(iv, price)
+
+	// Verify:
+	assert.True(t, errors.Is(err, ErrShortSum), "Unexpected error : %s", err)
+}
+
+func TestSealOpenPadOffset(t *testing.T) {
+	// Setup:
+	encryptionKey, _ := hex.DecodeString("652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135")
+	integrityKey, _ := hex.DecodeString("bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5")
+	var price [PriceLength]byte
+	binary.BigEndian.PutUint64(price[:], 1354000)
+	iv := IV("seed")
+	state := NewState(encryptionKey, integrityKey, helpers.PriceIV, helpers.SHA1, SignatureLength, 4)
+	encryptionHmac := helpers.NewHmac(encryptionKey)
+	encryptionHmac.Write(iv[:])
+
+	// Execute:
+	pad, err := state.Pad(iv)
+	assert.Nil(t, err)
+	sealed, err := state.Seal(iv, price)
+	assert.Nil(t, err)
+	opened, err := state.Open(sealed.Message[:MessageLength])
+	assert.Nil(t, err)
+	defaultOpened, err := buildState(t).Open(sealed.Message[:MessageLength])
+	assert.Nil(t, err)
+
+	// Verify:
+	assert.Equal(t, encryptionHmac.Sum(nil)[4:4+PriceLength], pad[:])
+	assert.True(t, opened.IsIntegrityValid)
+	assert.Equal(t, price, opened.Price)
+	assert.False(t, defaultOpened.IsIntegrityValid)
+	assert.NotEqual(t, price, defaultOpened.Price)
+
+	// Pads past sums
+	// Setup:
+	state = NewState(encryptionKey, integrityKey, helpers.PriceIV, helpers.SHA1, SignatureLength, 13)
+
+	// Execute:
+	_, err = state.Seal(iv, price)
+
+	// Verify:
+	assert.True(t, errors.Is(err, ErrShortSum), "Unexpected error : %s", err)
+}
@@ -0,0 +1,51 @@
+// Package serving holds what the HTTP and gRPC servers share: the default
+// input limits and the classification of pricer errors.
+package serving
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+// DefaultMaxInputLength is the longest seed or encrypted price accepted by default,
+// way above encrypted prices lengths, so that abusive inputs are rejected before
+// reaching the pricer.
+const DefaultMaxInputLength = 1 << 10
+
+// CheckLength returns an error if value of field is longer than maxInputLength.
+func CheckLength(field string, value string, maxInputLength int) error {
+	if len(value) > maxInputLength {
+		return fmt.Errorf("%s too long: %d bytes, limit is %d", field, len(value), maxInputLength)
+	}
+
+	return nil
+}
+
+// IsRejectedEncrypted returns whether err tells an encrypted price can't be trusted:
+// it is malformed, too short, its signature doesn't match, its IV is rejected or
+// its price is out of range, rather than the pricer failing for another reason.
+func IsRejectedEncrypted(err error) bool {
+	return errors.Is(err, helpers.ErrMalformedBase64) ||
+		errors.Is(err, helpers.ErrMalformedHex) ||
+		errors.Is(err, helpers.ErrInvalidCiphertextLength) ||
+		errors.Is(err, helpers.ErrSignatureMismatch) ||
+		errors.Is(err, helpers.ErrZeroIV) ||
+		errors.Is(err, helpers.ErrPriceOutOfRange)
+}
+
+// IsUnavailable returns whether err tells the pricer can't serve anymore,
+// e.g. once closed on shutdown.
+func IsUnavailable(err error) bool {
+	return errors.Is(err, helpers.ErrClosed)
+}
+
+// IsRejectedPrice returns whether err tells the pricer rejected a price or seed,
+// rather than failing for an internal reason.
+func IsRejectedPrice(err error) bool {
+	return errors.Is(err, helpers.ErrPriceOverflow) ||
+		errors.Is(err, helpers.ErrInvalidPrice) ||
+		errors.Is(err, helpers.ErrPriceAboveMax) ||
+		errors.Is(err, helpers.ErrInvalidSeed)
+}
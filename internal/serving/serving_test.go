@@ -0,0 +1,73 @@
+package serving
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+func TestCheckLength(t *testing.T) {
+	// Execute:
+	errAtLimit := CheckLength("seed", strings.Repeat("a", 8), 8)
+	errAboveLimit := CheckLength("seed", strings.Repeat("a", 9), 8)
+
+	// Verify:
+	assert.Nil(t, errAtLimit, "Unexpected error : %s", errAtLimit)
+	assert.EqualError(t, errAboveLimit, "seed too long: 9 bytes, limit is 8")
+}
+
+func TestIsRejectedPrice(t *testing.T) {
+	var tests = []struct {
+		err      error
+		rejected bool
+	}{
+		{fmt.Errorf("%w: 1e300", helpers.ErrPriceOverflow), true},
+		{fmt.Errorf("%w: price is NaN", helpers.ErrInvalidPrice), true},
+		{fmt.Errorf("%w: 10.5", helpers.ErrPriceAboveMax), true},
+		{fmt.Errorf("%w: seed", helpers.ErrInvalidSeed), true},
+		{errors.New("internal"), false},
+	}
+
+	for _, tt := range tests {
+		// Execute:
+		rejected := IsRejectedPrice(tt.err)
+
+		// Verify:
+		assert.Equal(t, tt.rejected, rejected, "Unexpected classification of %s", tt.err)
+	}
+}
+
+func TestIsRejectedEncrypted(t *testing.T) {
+	var tests = []struct {
+		err      error
+		rejected bool
+	}{
+		{fmt.Errorf("%w: illegal data", helpers.ErrMalformedBase64), true},
+		{fmt.Errorf("%w: odd length", helpers.ErrMalformedHex), true},
+		{fmt.Errorf("%w: decoded 3 bytes", helpers.ErrInvalidCiphertextLength), true},
+		{helpers.ErrSignatureMismatch, true},
+		{helpers.ErrZeroIV, true},
+		{fmt.Errorf("%w: 1e12 micros", helpers.ErrPriceOutOfRange), true},
+		{helpers.ErrClosed, false},
+		{errors.New("internal"), false},
+	}
+
+	for _, tt := range tests {
+		// Execute:
+		rejected := IsRejectedEncrypted(tt.err)
+
+		// Verify:
+		assert.Equal(t, tt.rejected, rejected, "Unexpected classification of %s", tt.err)
+	}
+}
+
+func TestIsUnavailable(t *testing.T) {
+	// Verify:
+	assert.True(t, IsUnavailable(fmt.Errorf("wrapped: %w", helpers.ErrClosed)))
+	assert.False(t, IsUnavailable(helpers.ErrSignatureMismatch))
+}
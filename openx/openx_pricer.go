@@ -0,0 +1,50 @@
+// Package openx implements OpenX price encryption.
+package openx
+
+import (
+	"github.com/benjaminch/pricers"
+	"github.com/benjaminch/pricers/doubleclick"
+)
+
+// OpenX defaults.
+const (
+	// ScaleFactor : OpenX encrypts prices as micros.
+	ScaleFactor = 1000000
+	// KeyLength : OpenX keys are 32 bytes long once decoded.
+	KeyLength = 32
+)
+
+var _ pricers.Pricer = (*Pricer)(nil)
+
+// Pricer implementing OpenX price encryption.
+// OpenX follows Google's 16 bytes IV || 8 bytes XOR pad || 4 bytes HMAC
+// signature layout, web safe base 64 encoded, so a Pricer encrypts and
+// decrypts through a pricer built with doubleclick.NewGoogleLayoutPricer
+// and OpenX defaults, which it doesn't expose.
+// A Pricer is safe for concurrent use by multiple goroutines.
+type Pricer struct {
+	pricer *doubleclick.DoubleClickPricer
+}
+
+// NewPricer returns a Pricer from web safe base 64 keys.
+func NewPricer(encryptionKey string, integrityKey string) (*Pricer, error) {
+	pricer, err := doubleclick.NewGoogleLayoutPricer(encryptionKey, integrityKey,
+		doubleclick.WithScaleFactor(ScaleFactor),
+		doubleclick.WithKeyLength(KeyLength),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Pricer{pricer: pricer}, nil
+}
+
+// Encrypt encrypts a clear price and a given seed.
+func (p *Pricer) Encrypt(seed string, price float64) (string, error) {
+	return p.pricer.Encrypt(seed, price)
+}
+
+// Decrypt decrypts an encrypted price.
+func (p *Pricer) Decrypt(encryptedPrice string) (float64, error) {
+	return p.pricer.Decrypt(encryptedPrice)
+}
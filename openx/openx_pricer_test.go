@@ -0,0 +1,111 @@
+package openx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers"
+	"github.com/benjaminch/pricers/doubleclick"
+)
+
+// No OpenX published sample could be checked byte compatibility against,
+// so vectors below are the DoubleClick ones: these tests only check NewPricer
+// sets Google's layout defaults, not compatibility with OpenX itself.
+const (
+	testEncryptionKey = "ZS-DraBUUVeht_sMDgn1nnM3My_nq9TrEESbjubDkTU"
+	testIntegrityKey  = "vQo9-4KtlcXmPhWaYvc8asqYuiSVMiGUdZ1RLXfrK7U"
+)
+
+func TestPricerIsAPricer(t *testing.T) {
+	// Setup:
+	var pricer pricers.Pricer
+	var err error
+
+	// Execute:
+	pricer, err = NewPricer(testEncryptionKey, testIntegrityKey)
+
+	// Verify:
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+	assert.NotNil(t, pricer)
+}
+
+func TestDecrypt(t *testing.T) {
+	// Setup:
+	pricer, err := NewPricer(testEncryptionKey, testIntegrityKey)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Execute:
+	result, err := pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+
+	// Verify:
+	assert.Nil(t, err, "Decryption failed. Error : %s", err)
+	assert.InDelta(t, 1.354, result, 0.000001)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	// Setup:
+	pricer, err := NewPricer(testEncryptionKey, testIntegrityKey)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	for _, price := range []float64{0, 0.01, 1.354, 25, 1000.123456} {
+		// Execute:
+		encrypted, err := pricer.Encrypt("seed", price)
+		assert.Nil(t, err, "Encryption failed. Error : %s", err)
+		decrypted, err := pricer.Decrypt(encrypted)
+
+		// Verify:
+		assert.Nil(t, err, "Decryption failed. Error : %s", err)
+		assert.InDelta(t, price, decrypted, 0.000001)
+	}
+}
+
+func TestEncryptKnownVector(t *testing.T) {
+	// Setup:
+	pricer, err := NewPricer(testEncryptionKey, testIntegrityKey)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Execute:
+	encrypted, err := pricer.Encrypt("", 1.354)
+
+	// Verify:
+	assert.Nil(t, err, "Encryption failed. Error : %s", err)
+	assert.Equal(t, "1B2M2Y8AsgTpgAmY7PhCfgDo9mJGavHOuu-2SA", encrypted)
+}
+
+func TestNewPricerInvalidKeys(t *testing.T) {
+	// Setup:
+	var keysTestCase = []struct {
+		encryptionKey string
+		integrityKey  string
+	}{
+		{"", testIntegrityKey},
+		{testEncryptionKey, ""},
+		// Hexa keys decode to 48 bytes
+		{"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135", testIntegrityKey},
+		// 16 bytes keys are shorter than KeyLength
+		{"AAAAAAAAAAAAAAAAAAAAAA", testIntegrityKey},
+	}
+
+	for _, k := range keysTestCase {
+		// Execute:
+		pricer, err := NewPricer(k.encryptionKey, k.integrityKey)
+
+		// Verify:
+		assert.Nil(t, pricer)
+		assert.True(t, errors.Is(err, doubleclick.ErrInvalidKey), "Unexpected error : %s", err)
+	}
+}
+
+func TestDecryptTamperedPrice(t *testing.T) {
+	// Setup:
+	pricer, err := NewPricer(testEncryptionKey, testIntegrityKey)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	// Execute:
+	_, err = pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpA")
+
+	// Verify:
+	assert.True(t, errors.Is(err, doubleclick.ErrSignatureMismatch), "Unexpected error : %s", err)
+}
@@ -0,0 +1,10 @@
+package pricers
+
+// Pricer is implemented by every supported price encryption protocol,
+// so that callers bidding into several exchanges can hold them behind one type.
+type Pricer interface {
+	// Encrypt encrypts a clear price and a given seed.
+	Encrypt(seed string, price float64) (string, error)
+	// Decrypt decrypts an encrypted price.
+	Decrypt(encryptedPrice string) (float64, error)
+}
@@ -0,0 +1,124 @@
+// Package pricermetrics reports pricer encrypt / decrypt outcomes as Prometheus metrics,
+// implementing helpers.Observer. It is kept apart so that pricers don't depend on the
+// Prometheus client unless this package is imported.
+//
+//	pricer, err := doubleclick.NewPricer(
+//		doubleclick.WithKeys(encryptionKey, integrityKey),
+//		doubleclick.WithObserver(pricermetrics.MustRegister(prometheus.DefaultRegisterer)),
+//	)
+//
+// Metrics are:
+//
+//	pricers_encrypt_total{result="success|failure"}   counter
+//	pricers_decrypt_total{result="success|failure"}   counter
+//	pricers_signature_failures_total                  counter, decryptions failing on signature mismatch
+//	pricers_duration_seconds{operation="encrypt|decrypt"} histogram
+package pricermetrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/benjaminch/pricers/helpers"
+)
+
+// Label values of the result and operation labels.
+const (
+	ResultSuccess    = "success"
+	ResultFailure    = "failure"
+	OperationEncrypt = "encrypt"
+	OperationDecrypt = "decrypt"
+)
+
+// Collector counts encrypt / decrypt outcomes and measures their latency.
+// It is both a helpers.Observer, to be given to doubleclick.WithObserver,
+// and a prometheus.Collector, to be registered. A Collector can be shared
+// by several pricers, and is safe for concurrent use by multiple goroutines.
+type Collector struct {
+	encryptTotal      *prometheus.CounterVec
+	decryptTotal      *prometheus.CounterVec
+	signatureFailures prometheus.Counter
+	duration          *prometheus.HistogramVec
+}
+
+var (
+	_ helpers.Observer     = (*Collector)(nil)
+	_ prometheus.Collector = (*Collector)(nil)
+)
+
+// NewCollector returns a Collector, to be registered with a prometheus.Registerer.
+// Durations are bucketed from 1µs to about 4ms, encryptions and decryptions
+// usually taking a few µs.
+func NewCollector() *Collector {
+	return &Collector{
+		encryptTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pricers_encrypt_total",
+			Help: "Prices encrypted, by result.",
+		}, []string{"result"}),
+		decryptTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pricers_decrypt_total",
+			Help: "Prices decrypted, by result.",
+		}, []string{"result"}),
+		signatureFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "pricers_signature_failures_total",
+			Help: "Prices failing to decrypt on integrity signature mismatch.",
+		}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "pricers_duration_seconds",
+			Help:    "Encryption and decryption latency, by operation.",
+			Buckets: prometheus.ExponentialBuckets(1e-6, 2, 13),
+		}, []string{"operation"}),
+	}
+}
+
+// MustRegister returns a new Collector registered with registerer,
+// panicking as prometheus.Registerer.MustRegister does if it can't be.
+func MustRegister(registerer prometheus.Registerer) *Collector {
+	c := NewCollector()
+	registerer.MustRegister(c)
+
+	return c
+}
+
+// ObserveEncrypt counts an encryption, failed if err isn't nil, and measures its latency.
+func (c *Collector) ObserveEncrypt(d time.Duration, err error) {
+	c.encryptTotal.WithLabelValues(result(err)).Inc()
+	c.duration.WithLabelValues(OperationEncrypt).Observe(d.Seconds())
+}
+
+// ObserveDecrypt counts a decryption, failed if err isn't nil, and measures its latency.
+// Failures matching helpers.ErrSignatureMismatch are also counted as signature failures.
+func (c *Collector) ObserveDecrypt(d time.Duration, err error) {
+	c.decryptTotal.WithLabelValues(result(err)).Inc()
+	if errors.Is(err, helpers.ErrSignatureMismatch) {
+		c.signatureFailures.Inc()
+	}
+	c.duration.WithLabelValues(OperationDecrypt).Observe(d.Seconds())
+}
+
+// Describe sends the descriptors of the Collector metrics to ch.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.encryptTotal.Describe(ch)
+	c.decryptTotal.Describe(ch)
+	c.signatureFailures.Describe(ch)
+	c.duration.Describe(ch)
+}
+
+// Collect sends the Collector metrics to ch.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.encryptTotal.Collect(ch)
+	c.decryptTotal.Collect(ch)
+	c.signatureFailures.Collect(ch)
+	c.duration.Collect(ch)
+}
+
+// result returns the result label value of an outcome.
+func result(err error) string {
+	if err != nil {
+		return ResultFailure
+	}
+
+	return ResultSuccess
+}
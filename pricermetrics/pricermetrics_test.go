@@ -0,0 +1,104 @@
+package pricermetrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers/doubleclick"
+)
+
+func buildObservedPricer(t *testing.T, registry *prometheus.Registry) *doubleclick.DoubleClickPricer {
+	pricer, err := doubleclick.NewPricer(
+		doubleclick.WithKeys(
+			"652f83ada0545157a1b7fb0c0e09f59e7337332fe7abd4eb10449b8ee6c39135",
+			"bd0a3dfb82ad95c5e63e159a62f73c6aca98ba2495322194759d512d77eb2bb5",
+		),
+		doubleclick.WithObserver(MustRegister(registry)),
+	)
+	assert.Nil(t, err, "Error creating new Pricer : ", err)
+
+	return pricer
+}
+
+func TestCollector(t *testing.T) {
+	// Setup:
+	registry := prometheus.NewRegistry()
+	pricer := buildObservedPricer(t, registry)
+
+	// Execute:
+	pricer.Encrypt("seed", 1.354)
+	pricer.Encrypt("seed", 2)
+	pricer.Encrypt("seed", -1)
+	pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+	pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpA")
+	pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lX!!")
+	pricer.Verify("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpA")
+
+	// Verify:
+	err := testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP pricers_decrypt_total Prices decrypted, by result.
+# TYPE pricers_decrypt_total counter
+pricers_decrypt_total{result="failure"} 3
+pricers_decrypt_total{result="success"} 1
+# HELP pricers_encrypt_total Prices encrypted, by result.
+# TYPE pricers_encrypt_total counter
+pricers_encrypt_total{result="failure"} 1
+pricers_encrypt_total{result="success"} 2
+# HELP pricers_signature_failures_total Prices failing to decrypt on integrity signature mismatch.
+# TYPE pricers_signature_failures_total counter
+pricers_signature_failures_total 2
+`), "pricers_encrypt_total", "pricers_decrypt_total", "pricers_signature_failures_total")
+	assert.Nil(t, err, "Unexpected metrics : %s", err)
+
+	families, err := registry.Gather()
+	assert.Nil(t, err, "Gathering failed. Error : %s", err)
+	counts := map[string]uint64{}
+	for _, family := range families {
+		if family.GetName() != "pricers_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			counts[metric.GetLabel()[0].GetValue()] = metric.GetHistogram().GetSampleCount()
+		}
+	}
+	assert.Equal(t, map[string]uint64{OperationEncrypt: 3, OperationDecrypt: 4}, counts)
+}
+
+func TestCollectorScrape(t *testing.T) {
+	// Setup:
+	registry := prometheus.NewRegistry()
+	pricer := buildObservedPricer(t, registry)
+	server := httptest.NewServer(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	defer server.Close()
+	pricer.Decrypt("anCGGFJApcfB6ZGc6mindhpTrYXHY4ONo7lXpg")
+
+	// Execute:
+	response, err := http.Get(server.URL)
+	assert.Nil(t, err, "Scraping failed. Error : %s", err)
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+
+	// Verify:
+	assert.Nil(t, err, "Scraping failed. Error : %s", err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Contains(t, string(body), `pricers_decrypt_total{result="success"} 1`)
+	assert.Contains(t, string(body), `pricers_duration_seconds_count{operation="decrypt"} 1`)
+	assert.Contains(t, string(body), "pricers_signature_failures_total 0")
+}
+
+func TestMustRegisterTwice(t *testing.T) {
+	// Setup:
+	registry := prometheus.NewRegistry()
+	MustRegister(registry)
+
+	// Verify:
+	assert.Panics(t, func() { MustRegister(registry) })
+}
@@ -0,0 +1,70 @@
+// Package pricertest provides pricers for testing code relying on pricers.Pricer,
+// without keys nor encryption.
+package pricertest
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/benjaminch/pricers"
+)
+
+// ErrFailing is the error a FailingPricer without Err returns.
+var ErrFailing = errors.New("pricertest: failing pricer")
+
+var (
+	_ pricers.Pricer = FakePricer{}
+	_ pricers.Pricer = FailingPricer{}
+)
+
+// FakePricer is a Pricer whose encrypted prices are clear prices, base 64 encoded,
+// so that tests can build and read them. Seeds are ignored.
+type FakePricer struct{}
+
+// Encrypt returns price formatted as a decimal string, web safe base 64 encoded without padding.
+func (FakePricer) Encrypt(seed string, price float64) (string, error) {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatFloat(price, 'f', -1, 64))), nil
+}
+
+// Decrypt returns the price encryptedPrice encodes, or an error if it wasn't
+// returned by FakePricer.Encrypt.
+func (FakePricer) Decrypt(encryptedPrice string) (float64, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(encryptedPrice)
+	if err != nil {
+		return 0, fmt.Errorf("pricertest: malformed encrypted price: %w", err)
+	}
+
+	price, err := strconv.ParseFloat(string(decoded), 64)
+	if err != nil {
+		return 0, fmt.Errorf("pricertest: malformed encrypted price: %w", err)
+	}
+
+	return price, nil
+}
+
+// FailingPricer is a Pricer failing every encryption and decryption with Err,
+// or ErrFailing if Err is nil, so that tests can check error handling.
+type FailingPricer struct {
+	Err error
+}
+
+// Encrypt returns the pricer error.
+func (p FailingPricer) Encrypt(seed string, price float64) (string, error) {
+	return "", p.err()
+}
+
+// Decrypt returns the pricer error.
+func (p FailingPricer) Decrypt(encryptedPrice string) (float64, error) {
+	return 0, p.err()
+}
+
+// err returns Err, or ErrFailing if Err is nil.
+func (p FailingPricer) err() error {
+	if p.Err == nil {
+		return ErrFailing
+	}
+
+	return p.Err
+}
@@ -0,0 +1,79 @@
+package pricertest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benjaminch/pricers"
+)
+
+func TestFakePricer(t *testing.T) {
+	// Setup:
+	var pricer pricers.Pricer = FakePricer{}
+
+	for _, price := range []float64{0, 1.354, 12.75, 1000} {
+		// Execute:
+		encrypted, encryptErr := pricer.Encrypt("seed", price)
+		decrypted, decryptErr := pricer.Decrypt(encrypted)
+
+		// Verify:
+		assert.Nil(t, encryptErr)
+		assert.Nil(t, decryptErr)
+		assert.Equal(t, price, decrypted)
+	}
+}
+
+func TestFakePricerEncoding(t *testing.T) {
+	// Setup:
+	var pricer pricers.Pricer = FakePricer{}
+
+	// Execute:
+	encrypted, err := pricer.Encrypt("", 1.354)
+
+	// Verify:
+	assert.Nil(t, err)
+	assert.Equal(t, "MS4zNTQ", encrypted)
+}
+
+func TestFakePricerMalformed(t *testing.T) {
+	// Setup:
+	var pricer pricers.Pricer = FakePricer{}
+
+	for _, encrypted := range []string{"not base64 !", "YWJj"} {
+		// Execute:
+		price, err := pricer.Decrypt(encrypted)
+
+		// Verify:
+		assert.NotNil(t, err)
+		assert.Equal(t, float64(0), price)
+	}
+}
+
+func TestFailingPricer(t *testing.T) {
+	// Setup:
+	errExpected := errors.New("exchange is down")
+	var tests = []struct {
+		name   string
+		pricer pricers.Pricer
+		err    error
+	}{
+		{name: "default error", pricer: FailingPricer{}, err: ErrFailing},
+		{name: "custom error", pricer: FailingPricer{Err: errExpected}, err: errExpected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute:
+			encrypted, encryptErr := tt.pricer.Encrypt("seed", 1.354)
+			price, decryptErr := tt.pricer.Decrypt("MS4zNTQ")
+
+			// Verify:
+			assert.Empty(t, encrypted)
+			assert.Equal(t, tt.err, encryptErr)
+			assert.Equal(t, float64(0), price)
+			assert.Equal(t, tt.err, decryptErr)
+		})
+	}
+}